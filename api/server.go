@@ -16,12 +16,14 @@ func StartServer(lc fx.Lifecycle, logger *zap.Logger, pool *pgxpool.Pool) *fiber
 		IdleTimeout:           5 * time.Second,
 		DisableStartupMessage: true,
 		EnableIPValidation:    true,
+		// Bulk requests carry thousands of entries
+		BodyLimit: 32 * 1024 * 1024,
 	})
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000",
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Last-Event-ID",
 		AllowCredentials: true,
 	}))
 
@@ -15,63 +15,98 @@ var roleHierarchy = map[string]int{
 	"admin":  3,
 }
 
+// HasRole reports whether the signed in user has at least the given role
+func HasRole(c *fiber.Ctx, role string) bool {
+	claims, ok := c.Locals("claims").(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	userRole, _ := claims["role"].(string)
+	return roleHierarchy[userRole] >= roleHierarchy[role]
+}
+
 func ProtectedRoute(logger *zap.Logger, queries *db.Queries, privilage string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		tokenStr := c.Cookies("token")
-		if tokenStr == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing token"})
+		claims, status, message := authenticate(c, logger, queries)
+		if claims == nil {
+			return c.Status(status).JSON(fiber.Map{"error": message})
 		}
 
-		// Verify token
-		token, err := utils.VerifyJWT(tokenStr)
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
-		}
+		requiredLevel := roleHierarchy[privilage]
+		userLevel := roleHierarchy[claims["role"].(string)]
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid claims"})
+		if userLevel < requiredLevel {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
 		}
 
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid user_id"})
-		}
+		// Attach claims for downstream handlers
+		c.Locals("claims", claims)
 
-		// Convert to UUID
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid user_id format"})
-		}
+		return c.Next()
+	}
+}
 
-		// Check existence in DB
-		exists, err := queries.UserExists(c.Context(), userID)
-		if err != nil {
-			logger.Error("failed to check user existence", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+// OptionalAuth attaches the claims of a valid token and lets everyone else
+// through as anonymous, for routes that check roles per request with HasRole
+func OptionalAuth(logger *zap.Logger, queries *db.Queries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, status, message := authenticate(c, logger, queries)
+		if status == fiber.StatusInternalServerError {
+			return c.Status(status).JSON(fiber.Map{"error": message})
 		}
-
-		if !exists {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "user does not exist"})
+		if claims != nil {
+			c.Locals("claims", claims)
 		}
+		return c.Next()
+	}
+}
 
-		//get role
-		role, ok := claims["role"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid role"})
-		}
+// authenticate verifies the token cookie and its user. On failure claims is
+// nil and status and message say how to reject the request.
+func authenticate(c *fiber.Ctx, logger *zap.Logger, queries *db.Queries) (jwt.MapClaims, int, string) {
+	tokenStr := c.Cookies("token")
+	if tokenStr == "" {
+		return nil, fiber.StatusUnauthorized, "missing token"
+	}
 
-		requiredLevel := roleHierarchy[privilage]
-		userLevel := roleHierarchy[role]
+	// Verify token
+	token, err := utils.VerifyJWT(tokenStr)
+	if err != nil || !token.Valid {
+		return nil, fiber.StatusUnauthorized, "invalid token"
+	}
 
-		if userLevel < requiredLevel {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
-		}
+	// Extract claims
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fiber.StatusUnauthorized, "invalid claims"
+	}
 
-		// Attach claims for downstream handlers
-		c.Locals("claims", claims)
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		return nil, fiber.StatusUnauthorized, "invalid user_id"
+	}
 
-		return c.Next()
+	// Convert to UUID
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, fiber.StatusUnauthorized, "invalid user_id format"
 	}
+
+	// Check existence in DB
+	exists, err := queries.UserExists(c.Context(), userID)
+	if err != nil {
+		logger.Error("failed to check user existence", zap.Error(err))
+		return nil, fiber.StatusInternalServerError, "internal server error"
+	}
+
+	if !exists {
+		return nil, fiber.StatusUnauthorized, "user does not exist"
+	}
+
+	//get role
+	if _, ok := claims["role"].(string); !ok {
+		return nil, fiber.StatusUnauthorized, "invalid role"
+	}
+
+	return claims, 0, ""
 }
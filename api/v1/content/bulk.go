@@ -0,0 +1,743 @@
+package content
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/searchindex"
+	"go.uber.org/zap"
+)
+
+// A bulk job runs create, update, delete, publish and unpublish operations in
+// order. Everything is validated before the first write. Atomic jobs run in one
+// transaction and stop at the first failure, other jobs apply every operation
+// they can and report each one. Runs of creates are inserted together.
+
+const (
+	bulkMaxOperations = 10000
+	bulkBatchSize     = 500
+)
+
+type bulkOperation struct {
+	Op        string                 `json:"op"`
+	ID        string                 `json:"id"`
+	SchemaID  string                 `json:"schema_id"`
+	Data      map[string]interface{} `json:"data"`
+	Published *bool                  `json:"published"`
+	Version   *int32                 `json:"version"` // expected version, like If-Match
+	Locale    string                 `json:"locale"`  // updates of other locales write a translation
+}
+
+type bulkResult struct {
+	Index   int        `json:"index"`
+	Op      string     `json:"op"`
+	ID      *uuid.UUID `json:"id,omitempty"`
+	OK      bool       `json:"ok"`
+	Version int32      `json:"version,omitempty"`
+	Status  int        `json:"status,omitempty"`
+	Error   string     `json:"error,omitempty"`
+	Skipped bool       `json:"skipped,omitempty"` // not applied because an atomic job failed
+}
+
+type bulkProgress struct {
+	Processed int `json:"processed"`
+	Total     int `json:"total"`
+	Failed    int `json:"failed"`
+}
+
+type bulkJob struct {
+	queries  *db.Queries
+	pool     *pgxpool.Pool
+	logger   *zap.Logger
+	user     pgtype.UUID
+	atomic   bool
+	ops      []bulkOperation
+	progress func(bulkProgress)
+
+	results  []bulkResult
+	ids      []uuid.UUID
+	schemas  map[uuid.UUID]db.Schema
+	contents map[uuid.UUID]db.Content
+	fields   map[uuid.UUID][]utils.Field
+	locales  map[string]*localeContext
+	reindex  map[uuid.UUID]db.Content
+	removed  []uuid.UUID
+}
+
+// BulkContentHandler applies many content operations in one request.
+// ?stream=true sends NDJSON progress lines before the final result.
+func BulkContentHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body struct {
+			Atomic     bool            `json:"atomic"`
+			Operations []bulkOperation `json:"operations"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid body",
+			})
+		}
+		if len(body.Operations) == 0 || len(body.Operations) > bulkMaxOperations {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("operations must hold between 1 and %d entries", bulkMaxOperations),
+			})
+		}
+
+		job := &bulkJob{
+			queries: queries,
+			pool:    pool,
+			logger:  logger,
+			user:    currentUser(c),
+			atomic:  body.Atomic,
+			ops:     body.Operations,
+		}
+
+		if c.QueryBool("stream") {
+			c.Set(fiber.HeaderContentType, "application/x-ndjson")
+			// The writer runs after the handler returned, so it must not touch c
+			c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+				enc := json.NewEncoder(w)
+				job.progress = func(p bulkProgress) {
+					_ = enc.Encode(p)
+					_ = w.Flush()
+				}
+				_, result := job.run(context.Background())
+				_ = enc.Encode(result)
+				_ = w.Flush()
+			})
+			return nil
+		}
+
+		status, result := job.run(c.Context())
+		return c.Status(status).JSON(result)
+	}
+}
+
+// run validates and applies the job, returning the status and body to answer with
+func (j *bulkJob) run(ctx context.Context) (int, fiber.Map) {
+	j.results = make([]bulkResult, len(j.ops))
+	j.ids = make([]uuid.UUID, len(j.ops))
+	j.reindex = map[uuid.UUID]db.Content{}
+
+	if err := j.prepare(ctx); err != nil {
+		j.logger.Error("Error preparing bulk operations", zap.Error(err))
+		return fiber.StatusInternalServerError, fiber.Map{"error": "Could not prepare bulk operations"}
+	}
+	if j.atomic && j.failed() > 0 {
+		j.skipPending()
+		return fiber.StatusBadRequest, j.summary(false)
+	}
+
+	if !j.atomic {
+		j.apply(ctx, j.queries)
+		j.index(ctx)
+		return fiber.StatusOK, j.summary(true)
+	}
+
+	tx, err := j.pool.Begin(ctx)
+	if err != nil {
+		j.logger.Error("Error starting bulk transaction", zap.Error(err))
+		return fiber.StatusInternalServerError, fiber.Map{"error": "Could not apply bulk operations"}
+	}
+	defer tx.Rollback(ctx)
+
+	if failed := j.apply(ctx, j.queries.WithTx(tx)); failed != nil {
+		j.skipPending()
+		status := failed.Status
+		if status == 0 {
+			status = fiber.StatusInternalServerError
+		}
+		return status, j.summary(false)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		j.logger.Error("Error committing bulk operations", zap.Error(err))
+		return fiber.StatusInternalServerError, fiber.Map{"error": "Could not apply bulk operations"}
+	}
+	j.index(ctx)
+	return fiber.StatusOK, j.summary(true)
+}
+
+// prepare checks every operation against its schema before anything is written.
+// Schemas and existing entries are each looked up once.
+func (j *bulkJob) prepare(ctx context.Context) error {
+	var existing []uuid.UUID
+	for i, op := range j.ops {
+		j.results[i] = bulkResult{Index: i, Op: op.Op}
+		switch op.Op {
+		case "create":
+			// Creates may bring their own id, as imports of exported files do
+			j.ids[i] = uuid.New()
+			if op.ID != "" {
+				id, err := uuid.Parse(op.ID)
+				if err != nil {
+					j.fail(i, fiber.StatusBadRequest, "Invalid content ID")
+					continue
+				}
+				j.ids[i] = id
+			}
+		case "update", "delete", "publish", "unpublish":
+			id, err := uuid.Parse(op.ID)
+			if err != nil {
+				j.fail(i, fiber.StatusBadRequest, "Invalid content ID")
+				continue
+			}
+			j.ids[i] = id
+			existing = append(existing, id)
+		default:
+			j.fail(i, fiber.StatusBadRequest, "op must be create, update, delete, publish or unpublish")
+		}
+	}
+
+	j.contents = map[uuid.UUID]db.Content{}
+	if len(existing) > 0 {
+		rows, err := j.queries.GetContentsByIDs(ctx, existing)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			j.contents[row.ID] = row
+		}
+	}
+
+	j.schemas = map[uuid.UUID]db.Schema{}
+	j.fields = map[uuid.UUID][]utils.Field{}
+	j.locales = map[string]*localeContext{}
+	for i, op := range j.ops {
+		if j.results[i].Status != 0 {
+			continue
+		}
+
+		var schemaID uuid.UUID
+		var content db.Content
+		if op.Op == "create" {
+			id, err := uuid.Parse(op.SchemaID)
+			if err != nil {
+				j.fail(i, fiber.StatusBadRequest, "Invalid schema ID")
+				continue
+			}
+			schemaID = id
+		} else {
+			var ok bool
+			if content, ok = j.contents[j.ids[i]]; !ok {
+				j.fail(i, fiber.StatusNotFound, "Content not found")
+				continue
+			}
+			schemaID = uuid.UUID(content.SchemaID.Bytes)
+			// A schema_id on other operations guards against touching another schema's entry
+			if op.SchemaID != "" && op.SchemaID != schemaID.String() {
+				j.fail(i, fiber.StatusNotFound, "Content not found in this schema")
+				continue
+			}
+		}
+
+		schema, err := j.schema(ctx, schemaID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			j.fail(i, fiber.StatusBadRequest, "Schema not found")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if op.Op == "create" || op.Op == "update" {
+			lc, err := j.locale(ctx, op.Locale)
+			if errors.Is(err, errUnknownLocale) {
+				j.fail(i, fiber.StatusBadRequest, "Unknown locale")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !lc.isDefault() {
+				if op.Op == "create" {
+					j.fail(i, fiber.StatusBadRequest, "Content must be created in the default locale ("+lc.Default+")")
+					continue
+				}
+				if err := j.checkTranslation(ctx, schema, content, lc, op.Data); err != nil {
+					j.fail(i, fiber.StatusBadRequest, err.Error())
+				}
+				continue
+			}
+			if err := validateData(ctx, j.queries, schema, op.Data); err != nil {
+				j.fail(i, fiber.StatusBadRequest, "Data does not match schema: "+err.Error())
+				continue
+			}
+		}
+
+		// Workflows only stop data from going live
+		goesLive := false
+		state := content.WorkflowState
+		switch op.Op {
+		case "create":
+			goesLive = op.Published != nil && *op.Published
+		case "update":
+			goesLive = !content.Published.Bool && op.Published != nil && *op.Published
+			if data, err := json.Marshal(op.Data); err == nil {
+				state = stateAfter(schema, content, data)
+			}
+		case "publish":
+			goesLive = true
+		}
+		if goesLive && workflowBlocks(schema, state) != nil {
+			j.fail(i, fiber.StatusConflict, "Content must pass review before it is published")
+		}
+	}
+	return nil
+}
+
+func (j *bulkJob) schema(ctx context.Context, id uuid.UUID) (db.Schema, error) {
+	if schema, ok := j.schemas[id]; ok {
+		return schema, nil
+	}
+	schema, err := j.queries.GetSchemaByID(ctx, id)
+	if err != nil {
+		return schema, err
+	}
+	j.schemas[id] = schema
+	j.fields[id], _ = utils.ParseFields(schema.Definition)
+	return schema, nil
+}
+
+func (j *bulkJob) locale(ctx context.Context, code string) (*localeContext, error) {
+	if lc, ok := j.locales[code]; ok {
+		return lc, nil
+	}
+	lc, err := loadLocales(ctx, j.queries, code)
+	if err != nil {
+		return nil, err
+	}
+	j.locales[code] = lc
+	return lc, nil
+}
+
+// checkTranslation validates a translation like updateTranslation does
+func (j *bulkJob) checkTranslation(ctx context.Context, schema db.Schema, content db.Content, lc *localeContext, data map[string]interface{}) error {
+	localized := map[string]bool{}
+	for _, name := range localizedFields(j.fields[schema.ID]) {
+		localized[name] = true
+	}
+	for key := range data {
+		if !localized[key] {
+			return fmt.Errorf("field %q is not localized and can only be set in the default locale (%s)", key, lc.Default)
+		}
+	}
+
+	merged := decodeData(workingData(content))
+	for k, v := range data {
+		merged[k] = v
+	}
+	if err := validateData(ctx, j.queries, schema, merged); err != nil {
+		return fmt.Errorf("Data does not match schema: %w", err)
+	}
+	for k := range data {
+		data[k] = merged[k]
+	}
+	return nil
+}
+
+// apply runs the valid operations in order. Atomic jobs stop at the first
+// failure and return it.
+func (j *bulkJob) apply(ctx context.Context, q *db.Queries) *bulkResult {
+	var batch []int
+	flush := func() *bulkResult {
+		if len(batch) == 0 {
+			return nil
+		}
+		failed := j.createBatch(ctx, q, batch)
+		batch = batch[:0]
+		return failed
+	}
+
+	for i, op := range j.ops {
+		if j.results[i].Status != 0 {
+			continue
+		}
+
+		if op.Op == "create" {
+			batch = append(batch, i)
+			if len(batch) < bulkBatchSize {
+				continue
+			}
+			if failed := flush(); failed != nil && j.atomic {
+				return failed
+			}
+			continue
+		}
+		if failed := flush(); failed != nil && j.atomic {
+			return failed
+		}
+
+		j.applyOne(ctx, q, i)
+		if j.atomic && j.results[i].Status != 0 {
+			return &j.results[i]
+		}
+		if (i+1)%bulkBatchSize == 0 {
+			j.report(i + 1)
+		}
+	}
+	if failed := flush(); failed != nil && j.atomic {
+		return failed
+	}
+	j.report(len(j.ops))
+	return nil
+}
+
+// createBatch inserts a run of creates, with their first revisions, in one statement
+func (j *bulkJob) createBatch(ctx context.Context, q *db.Queries, batch []int) *bulkResult {
+	params := db.CreateContentsParams{CreatedBy: j.user}
+	for _, i := range batch {
+		data, err := json.Marshal(j.ops[i].Data)
+		if err != nil {
+			j.fail(i, fiber.StatusBadRequest, "Could not encode data")
+			continue
+		}
+		params.Ids = append(params.Ids, j.ids[i])
+		params.SchemaIds = append(params.SchemaIds, uuid.MustParse(j.ops[i].SchemaID))
+		params.Data = append(params.Data, data)
+		params.Published = append(params.Published, j.ops[i].Published != nil && *j.ops[i].Published)
+	}
+
+	created, err := q.CreateContents(ctx, params)
+	field, unique := uniqueViolation(err)
+	if unique && !j.atomic && len(batch) > 1 {
+		// Create one at a time to find the entries that clash
+		for _, i := range batch {
+			if j.results[i].Status == 0 {
+				j.createBatch(ctx, q, []int{i})
+			}
+		}
+		for _, i := range batch {
+			if j.results[i].Status != 0 {
+				return &j.results[i]
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		status, message := fiber.StatusConflict, uniqueMessage(field)
+		if !unique {
+			j.logger.Error("Error creating contents", zap.Error(err))
+			status, message = fiber.StatusInternalServerError, "Error creating content"
+		}
+		for _, i := range batch {
+			if j.results[i].Status == 0 {
+				j.fail(i, status, message)
+			}
+		}
+	}
+	inserted := map[uuid.UUID]bool{}
+	for _, row := range created {
+		content := db.Content(row)
+		inserted[content.ID] = true
+		j.contents[content.ID] = content
+		j.reindex[content.ID] = content
+	}
+	if err == nil {
+		for _, i := range batch {
+			if j.results[i].Status != 0 || inserted[j.ids[i]] {
+				continue
+			}
+			// rows of a schema trashed since prepare are left out too
+			if _, err := q.GetSchemaByID(ctx, uuid.MustParse(j.ops[i].SchemaID)); errors.Is(err, pgx.ErrNoRows) {
+				j.fail(i, fiber.StatusNotFound, "Schema not found")
+			} else {
+				j.fail(i, fiber.StatusConflict, "Content ID is already in use")
+			}
+		}
+	}
+
+	var failed *bulkResult
+	for _, i := range batch {
+		if j.results[i].Status != 0 {
+			if failed == nil {
+				failed = &j.results[i]
+			}
+			continue
+		}
+		j.succeed(i, j.contents[j.ids[i]])
+	}
+	j.report(batch[len(batch)-1] + 1)
+	return failed
+}
+
+// applyOne runs an update, delete, publish or unpublish like its single-entry route
+func (j *bulkJob) applyOne(ctx context.Context, q *db.Queries, i int) {
+	op := j.ops[i]
+	content, ok := j.contents[j.ids[i]]
+	if !ok {
+		// Deleted by an earlier operation of this job
+		j.fail(i, fiber.StatusNotFound, "Content not found")
+		return
+	}
+
+	var expected pgtype.Int4
+	if op.Version != nil {
+		expected = pgtype.Int4{Int32: *op.Version, Valid: true}
+	}
+
+	var updated db.Content
+	var err error
+	live := true
+	switch op.Op {
+	case "update":
+		if lc := j.locales[op.Locale]; !lc.isDefault() {
+			j.translate(ctx, q, i, content, lc, expected)
+			return
+		}
+		published := content.Published.Bool
+		if op.Published != nil {
+			published = *op.Published
+		}
+		data, encErr := json.Marshal(op.Data)
+		if encErr != nil {
+			j.fail(i, fiber.StatusBadRequest, "Could not encode data")
+			return
+		}
+		// Published entries keep their live data, see updateBase
+		if content.Published.Bool && published {
+			live = false
+			if field := j.uniqueConflict(ctx, q, content, data); field != "" {
+				j.fail(i, fiber.StatusConflict, uniqueMessage(field))
+				return
+			}
+			var row db.SaveContentDraftRow
+			row, err = q.SaveContentDraft(ctx, db.SaveContentDraftParams{
+				DraftData:       data,
+				ID:              content.ID,
+				ExpectedVersion: expected,
+				Author:          j.user,
+			})
+			updated = db.Content(row)
+		} else {
+			var row db.UpdateContentRow
+			row, err = q.UpdateContent(ctx, db.UpdateContentParams{
+				Data:            data,
+				Published:       pgtype.Bool{Bool: published, Valid: true},
+				ID:              content.ID,
+				ExpectedVersion: expected,
+				Author:          j.user,
+			})
+			updated = db.Content(row)
+		}
+	case "publish":
+		var row db.PublishContentRow
+		row, err = q.PublishContent(ctx, db.PublishContentParams{
+			ID:              content.ID,
+			ExpectedVersion: expected,
+			Author:          j.user,
+		})
+		updated = db.Content(row)
+	case "unpublish":
+		var row db.UpdateContentRow
+		row, err = q.UpdateContent(ctx, db.UpdateContentParams{
+			Data:            workingData(content),
+			Published:       pgtype.Bool{Bool: false, Valid: true},
+			ID:              content.ID,
+			ExpectedVersion: expected,
+			Author:          j.user,
+		})
+		updated = db.Content(row)
+	case "delete":
+		var n int64
+		n, err = q.DeleteContent(ctx, db.DeleteContentParams{
+			ID:              content.ID,
+			ExpectedVersion: expected,
+		})
+		if err == nil && n == 0 && !expected.Valid {
+			j.fail(i, fiber.StatusNotFound, "Content not found")
+			return
+		}
+		if err == nil && n == 0 {
+			err = pgx.ErrNoRows
+		}
+		if err == nil {
+			delete(j.contents, content.ID)
+			delete(j.reindex, content.ID)
+			j.removed = append(j.removed, content.ID)
+			j.results[i].ID = &j.ids[i]
+			j.results[i].OK = true
+			return
+		}
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		j.fail(i, fiber.StatusPreconditionFailed, "Content was changed by someone else, reload it and try again")
+		return
+	}
+	if field, ok := uniqueViolation(err); ok {
+		j.fail(i, fiber.StatusConflict, uniqueMessage(field))
+		return
+	}
+	if err != nil {
+		j.logger.Error("Error applying bulk operation", zap.String("op", op.Op), zap.Error(err))
+		j.fail(i, fiber.StatusInternalServerError, "Could not "+op.Op+" content")
+		return
+	}
+
+	j.contents[updated.ID] = updated
+	if live {
+		j.reindex[updated.ID] = updated
+	}
+	j.succeed(i, updated)
+}
+
+// uniqueConflict runs checkUnique for a draft update, a failed check is logged
+// and lets the update through
+func (j *bulkJob) uniqueConflict(ctx context.Context, q *db.Queries, content db.Content, data []byte) string {
+	schema, err := j.schema(ctx, uuid.UUID(content.SchemaID.Bytes))
+	field := ""
+	if err == nil {
+		field, err = checkUnique(ctx, q, schema, content.ID, data)
+	}
+	if err != nil {
+		j.logger.Error("Error checking unique fields", zap.Error(err))
+	}
+	return field
+}
+
+// translate stores the localized fields of an update in another locale,
+// as a draft while the entry is published
+func (j *bulkJob) translate(ctx context.Context, q *db.Queries, i int, content db.Content, lc *localeContext, expected pgtype.Int4) {
+	op := j.ops[i]
+	data, err := json.Marshal(op.Data)
+	if err != nil {
+		j.fail(i, fiber.StatusBadRequest, "Could not encode data")
+		return
+	}
+
+	var published bool
+	if op.Published != nil {
+		published = *op.Published
+	} else {
+		rows, err := getTranslations(ctx, q, content)
+		if err != nil {
+			j.logger.Error("Error fetching translations", zap.Error(err))
+			j.fail(i, fiber.StatusInternalServerError, "Could not update content")
+			return
+		}
+		published = lc.isPublished(content, rows)
+	}
+
+	// An atomic job already runs in a transaction, otherwise each
+	// translation gets its own so the version and data change together
+	var bumped db.Content
+	if j.atomic {
+		bumped, _, err = writeTranslation(ctx, q, content, lc.Requested, data, published, expected)
+	} else {
+		bumped, _, err = saveTranslation(ctx, j.pool, q, content, lc.Requested, data, published, expected)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		j.fail(i, fiber.StatusPreconditionFailed, "Content was changed by someone else, reload it and try again")
+		return
+	}
+	if err != nil {
+		j.logger.Error("Error updating translation", zap.Error(err))
+		j.fail(i, fiber.StatusInternalServerError, "Could not update content")
+		return
+	}
+
+	j.contents[bumped.ID] = bumped
+	if !content.Published.Bool {
+		j.reindex[bumped.ID] = bumped
+	}
+	j.succeed(i, bumped)
+}
+
+// index refreshes search for the entries whose live data changed
+func (j *bulkJob) index(ctx context.Context) {
+	for _, id := range j.removed {
+		if err := j.queries.DeleteSearchDocuments(ctx, id); err != nil {
+			j.logger.Error("Error removing content from search", zap.Error(err))
+		}
+	}
+	if len(j.reindex) == 0 {
+		return
+	}
+
+	def, err := j.queries.GetDefaultLocale(ctx)
+	if err != nil {
+		j.logger.Error("Error fetching default locale", zap.Error(err))
+		return
+	}
+	for _, content := range j.reindex {
+		fields := j.fields[uuid.UUID(content.SchemaID.Bytes)]
+		if err := searchindex.Index(ctx, j.queries, content, fields, def.Code); err != nil {
+			j.logger.Error("Error indexing content for search", zap.Error(err))
+		}
+	}
+}
+
+func (j *bulkJob) fail(i, status int, message string) {
+	j.results[i].Status = status
+	j.results[i].Error = message
+	if j.ids[i] != uuid.Nil && j.ops[i].Op != "create" {
+		j.results[i].ID = &j.ids[i]
+	}
+}
+
+func (j *bulkJob) succeed(i int, content db.Content) {
+	j.results[i].ID = &j.ids[i]
+	j.results[i].OK = true
+	j.results[i].Version = content.Version
+}
+
+// skipPending marks what an aborted atomic job did not apply, and undoes
+// the successes that were rolled back
+func (j *bulkJob) skipPending() {
+	for i := range j.results {
+		if j.results[i].Status == 0 {
+			j.results[i].OK = false
+			j.results[i].Version = 0
+			j.results[i].Skipped = true
+			if j.ops[i].Op == "create" {
+				j.results[i].ID = nil
+			}
+		}
+	}
+	j.reindex = map[uuid.UUID]db.Content{}
+	j.removed = nil
+}
+
+func (j *bulkJob) failed() int {
+	n := 0
+	for _, r := range j.results {
+		if r.Status != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (j *bulkJob) report(processed int) {
+	if j.progress != nil {
+		j.progress(bulkProgress{Processed: processed, Total: len(j.ops), Failed: j.failed()})
+	}
+}
+
+func (j *bulkJob) summary(committed bool) fiber.Map {
+	failed := j.failed()
+	succeeded := 0
+	for _, r := range j.results {
+		if r.OK {
+			succeeded++
+		}
+	}
+	return fiber.Map{
+		"atomic":    j.atomic,
+		"committed": committed,
+		"total":     len(j.ops),
+		"succeeded": succeeded,
+		"failed":    failed,
+		"results":   j.results,
+	}
+}
@@ -0,0 +1,62 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+)
+
+// cacheHeaders sets the schema's Cache-Control and tags the response for CDN
+// purges with the schema, the given entries and the schema's extra keys
+func cacheHeaders(c *fiber.Ctx, schema db.Schema, ids ...uuid.UUID) {
+	settings, _ := utils.ParseSettings(schema.Settings)
+	if settings.Cache.CacheControl != "" {
+		c.Set(fiber.HeaderCacheControl, settings.Cache.CacheControl)
+	}
+
+	keys := []string{"schema:" + schema.Name}
+	for _, id := range ids {
+		keys = append(keys, "content:"+id.String())
+	}
+	keys = append(keys, settings.Cache.SurrogateKeys...)
+	c.Set("Surrogate-Key", strings.Join(keys, " "))
+}
+
+// bodyETag is a strong validator over a rendered response body
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the validators of a read and reports whether the client's
+// copy is still current. If-Modified-Since only counts without If-None-Match.
+func notModified(c *fiber.Ctx, tag string, modified time.Time) bool {
+	c.Set(fiber.HeaderETag, tag)
+	if !modified.IsZero() {
+		c.Set(fiber.HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+	}
+
+	if h := c.Get(fiber.HeaderIfNoneMatch); h != "" {
+		for _, t := range strings.Split(h, ",") {
+			// Weak comparison, as RFC 9110 asks for If-None-Match
+			t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+			if t == "*" || t == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if h := c.Get(fiber.HeaderIfModifiedSince); h != "" && !modified.IsZero() {
+		since, err := http.ParseTime(h)
+		return err == nil && !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
@@ -2,12 +2,16 @@ package content
 
 import (
 	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/manthan307/nota-cms/db/output"
 	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/searchindex"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +22,10 @@ func CreateContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			SchemaID  string                 `json:"schema_id"`
 			Data      map[string]interface{} `json:"data"`
 			Published bool                   `json:"published"`
+			Locale    string                 `json:"locale"`
+			// Optional schedule, see ScheduleContentHandler
+			PublishAt   *time.Time `json:"publish_at"`
+			UnpublishAt *time.Time `json:"unpublish_at"`
 		}
 
 		if err := c.BodyParser(&body); err != nil {
@@ -26,6 +34,25 @@ func CreateContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			})
 		}
 
+		// Entries start in the default locale, translations are added through update
+		lc, err := loadLocales(c.Context(), queries, body.Locale)
+		if err != nil {
+			if errors.Is(err, errUnknownLocale) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unknown locale",
+				})
+			}
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching locales",
+			})
+		}
+		if !lc.isDefault() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Content must be created in the default locale (" + lc.Default + ")",
+			})
+		}
+
 		//get schema from db using schemaID
 		uuidId, err := uuid.Parse(body.SchemaID)
 		if err != nil {
@@ -41,11 +68,22 @@ func CreateContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			})
 		}
 
-		ok, err := utils.CompareSchemaWithData(schema.Definition, body.Data)
-		if !ok {
+		if err := validateData(c.Context(), queries, schema, body.Data); err != nil {
 			logger.Error("Data does not match schema", zap.Error(err))
+			return validationError(c, err)
+		}
+
+		// New entries start in the workflow's initial state
+		if body.Published {
+			if reason := workflowBlocks(schema, pgtype.Text{}); reason != nil {
+				return c.Status(fiber.StatusConflict).JSON(reason)
+			}
+		}
+
+		publishAt, unpublishAt, err := scheduleTimes(body.PublishAt, body.UnpublishAt)
+		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Data does not match schema: " + err.Error(),
+				"error": err.Error(),
 			})
 		}
 
@@ -57,27 +95,48 @@ func CreateContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			})
 		}
 
-		pguuid := pgtype.UUID{Bytes: uuidId, Valid: true}
-
-		content, err := queries.CreateContent(c.Context(), db.CreateContentParams{
-			SchemaID:  pguuid,
-			Data:      dataBytes,
-			Published: pgtype.Bool{Bool: body.Published, Valid: true},
+		row, err := queries.CreateContent(c.Context(), db.CreateContentParams{
+			SchemaID:    uuidId,
+			Data:        dataBytes,
+			Published:   pgtype.Bool{Bool: body.Published, Valid: true},
+			CreatedBy:   currentUser(c),
+			PublishAt:   publishAt,
+			UnpublishAt: unpublishAt,
 		})
+		if field, ok := uniqueViolation(err); ok {
+			return uniqueConflict(c, field)
+		}
+		// the schema was trashed since it was read
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Schema not found",
+			})
+		}
 		if err != nil {
 			logger.Error("Error creating content", zap.Error(err))
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Error creating content",
 			})
 		}
+		content := db.Content(row)
+
+		fields, _ := utils.ParseFields(schema.Definition)
+		if err := searchindex.Index(c.Context(), queries, content, fields, lc.Default); err != nil {
+			logger.Error("Error indexing content for search", zap.Error(err))
+		}
 
+		setVersion(c, content)
 		return c.JSON(fiber.Map{
-			"id":        content.ID,
-			"schemaID":  content.SchemaID,
-			"data":      content.Data,
-			"published": content.Published,
-			"createdAt": content.CreatedAt,
-			"updateAt":  content.UpdatedAt,
+			"id":          content.ID,
+			"schemaID":    content.SchemaID,
+			"data":        content.Data,
+			"published":   content.Published,
+			"publishAt":   content.PublishAt,
+			"unpublishAt": content.UnpublishAt,
+			"version":     content.Version,
+			"locale":      lc.Requested,
+			"createdAt":   content.CreatedAt,
+			"updateAt":    content.UpdatedAt,
 		})
 	}
 }
@@ -1,8 +1,12 @@
 package content
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/manthan307/nota-cms/db/output"
 	"go.uber.org/zap"
 )
@@ -16,13 +20,43 @@ func DeleteContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 				"error": "Invalid content ID",
 			})
 		}
-		err = queries.DeleteContent(c.Context(), uuidId)
+
+		// Deletes are unconditional unless the client sends If-Match
+		var expected pgtype.Int4
+		if c.Get(fiber.HeaderIfMatch) != "" {
+			content, err := queries.GetContentByID(c.Context(), uuidId)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Content not found",
+				})
+			}
+			if err != nil {
+				logger.Error("Error fetching content by ID", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Error deleting content",
+				})
+			}
+			if expected, err = expectedVersion(c, content, nil); err != nil {
+				return preconditionFailed(c, content)
+			}
+		}
+
+		n, err := queries.DeleteContent(c.Context(), db.DeleteContentParams{
+			ID:              uuidId,
+			ExpectedVersion: expected,
+		})
+		if err == nil && n == 0 && expected.Valid {
+			return versionConflict(c, queries, logger, uuidId)
+		}
 		if err != nil {
 			logger.Error("Error deleting content", zap.Error(err))
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Error deleting content",
 			})
 		}
+		if err := queries.DeleteSearchDocuments(c.Context(), uuidId); err != nil {
+			logger.Error("Error removing content from search", zap.Error(err))
+		}
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
 			"message": "Content deleted successfully",
 		})
@@ -0,0 +1,165 @@
+package content
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils/searchindex"
+	"go.uber.org/zap"
+)
+
+// Published entries keep serving data to the public while edits pile up in
+// draft_data. Publishing promotes the drafts of the entry and its translations
+// together, discarding throws them away. Unpublished entries have no live copy
+// to protect and are written directly.
+
+// workingData is what editors see, the pending draft or else the live data
+func workingData(content db.Content) json.RawMessage {
+	if content.DraftData != nil {
+		return content.DraftData
+	}
+	return content.Data
+}
+
+// workingRows swaps in the translation drafts and their pending publish state for previews
+func workingRows(rows map[string]db.ContentLocale) map[string]db.ContentLocale {
+	out := make(map[string]db.ContentLocale, len(rows))
+	for code, row := range rows {
+		if row.DraftData != nil {
+			row.Data = row.DraftData
+		}
+		if row.DraftPublished.Valid {
+			row.Published = row.DraftPublished.Bool
+		}
+		out[code] = row
+	}
+	return out
+}
+
+// hasDraft reports whether an entry or one of its translations has unpublished edits
+func hasDraft(content db.Content, rows map[string]db.ContentLocale) bool {
+	if content.DraftData != nil {
+		return true
+	}
+	for _, row := range rows {
+		if row.DraftData != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishContentHandler makes the entry's drafts live and publishes it
+func PublishContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		content, err := fetchContent(c, queries)
+		if err != nil {
+			return contentError(c, logger, err)
+		}
+
+		schema, err := queries.GetSchemaByID(c.Context(), uuid.UUID(content.SchemaID.Bytes))
+		if err != nil {
+			logger.Error("Error fetching schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch schema",
+			})
+		}
+		if reason := workflowBlocks(schema, content.WorkflowState); reason != nil {
+			return c.Status(fiber.StatusConflict).JSON(reason)
+		}
+
+		expected, err := expectedVersion(c, content, nil)
+		if err != nil {
+			return preconditionFailed(c, content)
+		}
+
+		published, err := queries.PublishContent(c.Context(), db.PublishContentParams{
+			ID:              content.ID,
+			ExpectedVersion: expected,
+			Author:          currentUser(c),
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return versionConflict(c, queries, logger, content.ID)
+		}
+		if field, ok := uniqueViolation(err); ok {
+			return uniqueConflict(c, field)
+		}
+		if err != nil {
+			logger.Error("Error publishing content", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not publish content",
+			})
+		}
+
+		return afterDraftChange(c, queries, logger, db.Content(published), true)
+	}
+}
+
+// DiscardDraftHandler drops the unpublished edits of an entry and its translations
+func DiscardDraftHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		content, err := fetchContent(c, queries)
+		if err != nil {
+			return contentError(c, logger, err)
+		}
+
+		exists, err := queries.HasContentDraft(c.Context(), content.ID)
+		if err != nil {
+			logger.Error("Error checking content draft", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch content",
+			})
+		}
+		if !exists {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Content has no draft",
+			})
+		}
+
+		expected, err := expectedVersion(c, content, nil)
+		if err != nil {
+			return preconditionFailed(c, content)
+		}
+
+		discarded, err := queries.DiscardContentDraft(c.Context(), db.DiscardContentDraftParams{
+			ID:              content.ID,
+			ExpectedVersion: expected,
+			Author:          currentUser(c),
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return versionConflict(c, queries, logger, content.ID)
+		}
+		if err != nil {
+			logger.Error("Error discarding content draft", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not discard draft",
+			})
+		}
+
+		return afterDraftChange(c, queries, logger, db.Content(discarded), false)
+	}
+}
+
+// afterDraftChange answers with the new version and, when data went live, reindexes it
+func afterDraftChange(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger, content db.Content, wentLive bool) error {
+	if wentLive {
+		if err := searchindex.Reindex(c.Context(), queries, content); err != nil {
+			logger.Error("Error indexing content for search", zap.Error(err))
+		}
+	}
+
+	setVersion(c, content)
+	return c.JSON(fiber.Map{
+		"id":        content.ID,
+		"schemaID":  content.SchemaID,
+		"data":      content.Data,
+		"published": content.Published,
+		"hasDraft":  false,
+		"version":   content.Version,
+		"updatedAt": content.UpdatedAt,
+	})
+}
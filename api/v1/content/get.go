@@ -2,33 +2,130 @@ package content
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/listquery"
 	"go.uber.org/zap"
 )
 
-func GetContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+// GetContentHandler serves the live data of a published entry
+func GetContentHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return getContent(queries, logger, pool, false, entryByID)
+}
+
+// PreviewContentHandler serves an entry as editors see it, drafts included
+func PreviewContentHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return getContent(queries, logger, pool, true, entryByID)
+}
+
+// GetContentByFieldHandler serves the published entry holding a value of a unique field
+func GetContentByFieldHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return getContent(queries, logger, pool, false, entryByUniqueField)
+}
+
+// PreviewContentByFieldHandler is GetContentByFieldHandler for editors, drafts included
+func PreviewContentByFieldHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return getContent(queries, logger, pool, true, entryByUniqueField)
+}
+
+// entryResolver finds the entry a read addresses. When ok is false it has
+// already written the response.
+type entryResolver func(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger) (id uuid.UUID, ok bool, err error)
+
+func entryByID(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger) (uuid.UUID, bool, error) {
+	parsedID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		logger.Error("Error parsing UUID", zap.Error(err))
+		return uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	return parsedID, true, nil
+}
+
+// entryByUniqueField looks the entry up by /:schema_name/by/:field/:value,
+// matching the stored value exactly
+func entryByUniqueField(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger) (uuid.UUID, bool, error) {
+	schema, err := queries.GetSchemaByName(c.Context(), c.Params("schema_name"))
+	if err != nil {
+		return uuid.Nil, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Schema not found",
+		})
+	}
+
+	fields, _ := utils.ParseFields(schema.Definition)
+	field := c.Params("field")
+	if !slices.Contains(utils.UniqueFields(fields), field) {
+		return uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Field %q is not a unique field of the schema", field),
+		})
+	}
+	value, err := url.PathUnescape(c.Params("value"))
+	if err != nil {
+		return uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid value",
+		})
+	}
+
+	id, err := queries.GetContentIDByUniqueValue(c.Context(), db.GetContentIDByUniqueValueParams{
+		SchemaID: schema.ID,
+		Field:    field,
+		Value:    value,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Content not found",
+		})
+	}
+	if err != nil {
+		logger.Error("Error looking up content by field", zap.Error(err))
+		return uuid.Nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Error fetching content",
+		})
+	}
+	return id, true, nil
+}
+
+func getContent(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool, preview bool, resolve entryResolver) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		id := c.Params("id")
+		parsedID, ok, err := resolve(c, queries, logger)
+		if !ok {
+			return err
+		}
 
-		parsedID, err := uuid.Parse(id)
+		opts, err := parseReadOptions(c)
 		if err != nil {
-			logger.Error("Error parsing UUID", zap.Error(err))
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid ID format",
+				"error": err.Error(),
 			})
 		}
 
-		content, err := queries.GetContentByID(c.Context(), parsedID)
+		content, err := getProjectedContent(c.Context(), queries, pool, parsedID, opts.Fields)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && !preview && !content.Published.Bool) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Content not found",
+			})
+		}
 		if err != nil {
 			logger.Error("Error fetching content by ID", zap.Error(err))
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Error fetching content",
 			})
 		}
+		if preview {
+			content.Data = workingData(content)
+		}
 
 		// Unmarshal JSON data
 		var data map[string]interface{}
@@ -39,16 +136,90 @@ func GetContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
 			})
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"id":        content.ID,
-			"schemaID":  content.SchemaID,
-			"data":      data,
-			"createdAt": content.CreatedAt,
-		})
+		// Resolve locale and translations
+		lc, err := loadLocales(c.Context(), queries, c.Query("locale"))
+		if err != nil {
+			if errors.Is(err, errUnknownLocale) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unknown locale",
+				})
+			}
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching locales",
+			})
+		}
+
+		schemaID, _ := uuid.FromBytes(content.SchemaID.Bytes[:])
+		schema, err := queries.GetSchemaByID(c.Context(), schemaID)
+		if err != nil {
+			logger.Error("Error fetching schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching schema",
+			})
+		}
+		fields, _ := utils.ParseFields(schema.Definition)
+		if err := opts.Fields.Check(fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		fields = opts.Fields.Fields(fields)
+
+		// Translations bump the version too, so it validates every locale's view
+		if preview {
+			c.Set(fiber.HeaderCacheControl, "private, no-store")
+		} else {
+			cacheHeaders(c, schema, content.ID)
+		}
+		if notModified(c, etag(content.Version), content.UpdatedAt.Time) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		allRows, err := getProjectedTranslations(c.Context(), queries, pool, []uuid.UUID{content.ID}, opts.Fields)
+		if err != nil {
+			logger.Error("Error fetching translations", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching content",
+			})
+		}
+		rows := translations(allRows)
+		if preview {
+			rows = workingRows(rows)
+		}
+		localized, resolved := lc.localize(data, fields, rows, !preview)
+
+		result := fiber.Map{
+			"id":              content.ID,
+			"schemaID":        content.SchemaID,
+			"data":            opts.format(fields, localized),
+			"locale":          lc.Requested,
+			"fallbacks":       lc.fallbacks(resolved),
+			"completeLocales": lc.completeLocales(data, fields, rows),
+			"published":       lc.isPublished(content, rows),
+			"version":         content.Version,
+			"createdAt":       content.CreatedAt,
+			"updatedAt":       content.UpdatedAt,
+		}
+		if preview {
+			result["hasDraft"] = hasDraft(content, rows)
+		}
+		return c.Status(fiber.StatusOK).JSON(opts.shape(result))
 	}
 }
 
-func GetAllContentsBySchemaHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+// GetAllContentsBySchemaHandler lists the live data of published entries
+func GetAllContentsBySchemaHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return getAllContents(queries, logger, pool, false)
+}
+
+// PreviewAllContentsHandler lists entries as editors see them, ?published
+// picks published, unpublished or all entries
+func PreviewAllContentsHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return getAllContents(queries, logger, pool, true)
+}
+
+func getAllContents(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool, preview bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		schemaName := c.Params("schema_name")
 
@@ -61,72 +232,177 @@ func GetAllContentsBySchemaHandler(queries *db.Queries, logger *zap.Logger) fibe
 			})
 		}
 
+		lc, err := loadLocales(c.Context(), queries, c.Query("locale"))
+		if err != nil {
+			if errors.Is(err, errUnknownLocale) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unknown locale",
+				})
+			}
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching locales",
+			})
+		}
+
+		fields, _ := utils.ParseFields(schema.Definition)
+
+		opts, err := parseReadOptions(c)
+		if err == nil {
+			err = opts.Fields.Check(fields)
+		}
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		// Filter, sort and pagination
+		q, err := listquery.Parse(listquery.Params{
+			Filter: c.Query("filter"),
+			Sort:   c.Query("sort"),
+			Cursor: c.Query("cursor"),
+			Limit:  c.QueryInt("limit"),
+			Offset: c.QueryInt("offset"),
+		}, fields)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		// Taxonomy fields to count the terms of
+		var countFields []utils.Field
+		for _, name := range strings.Split(c.Query("counts"), ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			i := slices.IndexFunc(fields, func(f utils.Field) bool { return f.Name == name })
+			if i < 0 || fields[i].Taxonomy == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("counts: %q is not a taxonomy field", name),
+				})
+			}
+			countFields = append(countFields, fields[i])
+		}
+
 		// Check published query param: true | false | all
-		p := c.Query("published", "all")
-
-		var (
-			contents []db.Content
-		)
-
-		pgID := pgtype.UUID{Bytes: schema.ID, Valid: true}
-
-		switch p {
-		case "true":
-			b := true
-			contents, err = queries.GetContentsBySchema(
-				c.Context(),
-				db.GetContentsBySchemaParams{
-					SchemaID: pgID,
-					Published: pgtype.Bool{
-						Bool:  b,
-						Valid: true,
-					},
-				},
-			)
-		case "false":
-			b := false
-			contents, err = queries.GetContentsBySchema(
-				c.Context(),
-				db.GetContentsBySchemaParams{
-					SchemaID: pgID,
-					Published: pgtype.Bool{
-						Bool:  b,
-						Valid: true,
-					},
-				},
-			)
-		default: // "all"
-			contents, err = queries.GetAllContentsBySchema(c.Context(), pgID)
+		p := "true"
+		if preview {
+			p = c.Query("published", "all")
 		}
 
+		scope := listScope{
+			SchemaID:  schema.ID,
+			Published: p,
+			Locale:    lc,
+			Preview:   preview,
+			Fields:    opts.Fields,
+		}
+		page, err := listContents(c.Context(), pool, scope, q)
 		if err != nil {
 			logger.Error("Error fetching contents", zap.Error(err))
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Error fetching contents",
 			})
 		}
+		counts := fiber.Map{}
+		for _, f := range countFields {
+			if counts[f.Name], err = termCounts(c.Context(), pool, scope, q, f); err != nil {
+				logger.Error("Error counting terms", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Error fetching contents",
+				})
+			}
+		}
+
+		// Fetch translations for every entry in one query
+		ids := make([]uuid.UUID, len(page.Contents))
+		for i, content := range page.Contents {
+			ids[i] = content.ID
+		}
+		allRows, err := getProjectedTranslations(c.Context(), queries, pool, ids, opts.Fields)
+		if err != nil {
+			logger.Error("Error fetching translations", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching contents",
+			})
+		}
+		rowsByContent := map[uuid.UUID][]db.ContentLocale{}
+		for _, r := range allRows {
+			rowsByContent[r.ContentID] = append(rowsByContent[r.ContentID], r)
+		}
+
+		// Filter and sort above use every field, localizing only the selected ones
+		fields = opts.Fields.Fields(fields)
 
 		// Formatting output
-		var result []map[string]interface{}
-		for _, content := range contents {
+		result := []fiber.Map{}
+		var modified time.Time
+		for _, content := range page.Contents {
+			if content.UpdatedAt.Time.After(modified) {
+				modified = content.UpdatedAt.Time
+			}
+
 			var data map[string]interface{}
 			if err := json.Unmarshal(content.Data, &data); err != nil {
 				logger.Warn("Invalid JSON in content.Data", zap.Error(err))
 				continue
 			}
 
-			item := map[string]interface{}{
-				"id":        content.ID,
-				"schemaID":  content.SchemaID,
-				"data":      data,
-				"published": content.Published.Bool,
-				"createdAt": content.CreatedAt,
-				"updatedAt": content.UpdatedAt,
+			rows := translations(rowsByContent[content.ID])
+			if preview {
+				rows = workingRows(rows)
+			}
+			localized, resolved := lc.localize(data, fields, rows, p == "true")
+
+			item := fiber.Map{
+				"id":              content.ID,
+				"schemaID":        content.SchemaID,
+				"data":            opts.format(fields, localized),
+				"locale":          lc.Requested,
+				"fallbacks":       lc.fallbacks(resolved),
+				"completeLocales": lc.completeLocales(data, fields, rows),
+				"published":       lc.isPublished(content, rows),
+				"version":         content.Version,
+				"createdAt":       content.CreatedAt,
+				"updatedAt":       content.UpdatedAt,
 			}
+			if preview {
+				item["hasDraft"] = hasDraft(content, rows)
+			}
+
+			result = append(result, opts.shape(item))
+		}
 
-			result = append(result, item)
+		list := fiber.Map{
+			"count":      len(result),
+			"total":      page.Total,
+			"limit":      q.Limit,
+			"offset":     q.Offset,
+			"nextCursor": page.NextCursor,
+			"data":       result,
+		}
+		if len(countFields) > 0 {
+			list["termCounts"] = counts
+		}
+		body, err := json.Marshal(list)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not encode JSON",
+			})
 		}
 
-		return c.JSON(result)
+		// A list has no version of its own, its ETag is a hash of the page
+		if preview {
+			c.Set(fiber.HeaderCacheControl, "private, no-store")
+		} else {
+			cacheHeaders(c, schema)
+		}
+		if notModified(c, bodyETag(body), modified) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(body)
 	}
 }
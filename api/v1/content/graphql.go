@@ -0,0 +1,783 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/manthan307/nota-cms/api/v1/auth"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/codegen"
+	"github.com/manthan307/nota-cms/utils/graphql"
+	"github.com/manthan307/nota-cms/utils/listquery"
+	"go.uber.org/zap"
+)
+
+const (
+	graphqlMaxLength     = 64 << 10
+	graphqlMaxFragments  = 100
+	graphqlMaxDepth      = 15 // the usual introspection query of GraphQL tools nests 13 levels
+	graphqlMaxComplexity = 5000
+)
+
+// GraphQLHandler serves a GraphQL API generated from the content schemas.
+// Reads follow the REST rules: published entries for everyone, previews for
+// viewers; mutations need the editor role.
+func GraphQLHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	cache := &graphqlCache{}
+	return func(c *fiber.Ctx) error {
+		var req graphql.Request
+		if c.Method() == fiber.MethodGet {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if v := c.Query("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"error": "variables must be a JSON object",
+					})
+				}
+			}
+		} else if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid body",
+			})
+		}
+		if req.Query == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "query is required",
+			})
+		}
+
+		schema, err := cache.get(c.Context(), queries, pool, logger)
+		if err != nil {
+			logger.Error("Error building GraphQL schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not build GraphQL schema",
+			})
+		}
+
+		// GET requests may be replayed by caches and links, they only read
+		if c.Method() == fiber.MethodGet {
+			if kind, err := schema.Operation(req); err == nil && kind != "query" {
+				return c.Status(fiber.StatusMethodNotAllowed).JSON(fiber.Map{
+					"error": "Mutations must be sent with POST",
+				})
+			}
+		}
+
+		ctx := context.WithValue(c.Context(), gqlCallerKey{}, gqlCaller{
+			user:   currentUser(c),
+			viewer: auth.HasRole(c, "viewer"),
+			editor: auth.HasRole(c, "editor"),
+		})
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		return c.Status(fiber.StatusOK).JSON(schema.Execute(ctx, req))
+	}
+}
+
+// gqlCaller is who runs a request, resolvers check roles with it
+type gqlCaller struct {
+	user   pgtype.UUID
+	viewer bool
+	editor bool
+}
+
+type gqlCallerKey struct{}
+
+func callerOf(ctx context.Context) gqlCaller {
+	caller, _ := ctx.Value(gqlCallerKey{}).(gqlCaller)
+	return caller
+}
+
+// graphqlCache keeps the generated schema until the schemas table changes.
+// Every write to a schema bumps its updated_at, deletes and restores included.
+type graphqlCache struct {
+	mu      sync.Mutex
+	version db.GetSchemasVersionRow
+	schema  *graphql.Schema
+}
+
+func (g *graphqlCache) get(ctx context.Context, queries *db.Queries, pool *pgxpool.Pool, logger *zap.Logger) (*graphql.Schema, error) {
+	version, err := queries.GetSchemasVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.schema != nil && version.Count == g.version.Count && version.UpdatedAt.Time.Equal(g.version.UpdatedAt.Time) {
+		return g.schema, nil
+	}
+
+	schemas, err := queries.ListSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b := &gqlBuilder{queries: queries, pool: pool, logger: logger}
+	schema, err := b.build(schemas)
+	if err != nil {
+		return nil, err
+	}
+	g.schema, g.version = schema, version
+	return schema, nil
+}
+
+// gqlType is the generated API of one content schema
+type gqlType struct {
+	schema db.Schema
+	fields []utils.Field
+	name   string
+	keys   map[string]string // GraphQL field name to data key
+
+	entry *graphql.Object
+	data  *graphql.Object
+	input *graphql.InputObject
+}
+
+type gqlBuilder struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+	logger  *zap.Logger
+
+	names map[string]bool // type names taken
+	types map[string]*gqlType
+}
+
+// Types of the engine that generated names must not clash with
+var gqlReserved = []string{"Query", "Mutation", "String", "ID", "Int", "Float", "Boolean", "JSON", "RichTextFormat"}
+
+// richTextFormat is the format argument of rich text fields
+var richTextFormat = &graphql.Enum{
+	Name:        "RichTextFormat",
+	Description: "How rich text is returned: its JSON document, HTML or Markdown.",
+	Values:      []string{"JSON", "HTML", "MARKDOWN"},
+}
+
+// build generates the types, queries and mutations of every schema
+func (b *gqlBuilder) build(schemas []db.Schema) (*graphql.Schema, error) {
+	b.names = map[string]bool{}
+	for _, name := range gqlReserved {
+		b.names[name] = true
+	}
+	b.types = map[string]*gqlType{}
+
+	slices.SortFunc(schemas, func(x, y db.Schema) int { return strings.Compare(x.Name, y.Name) })
+	var types []*gqlType
+	for _, s := range schemas {
+		fields, err := utils.ParseFields(s.Definition)
+		if err != nil {
+			b.logger.Warn("Skipping schema with an invalid definition", zap.String("schema", s.Name))
+			continue
+		}
+		name := b.typeName(s.Name)
+		t := &gqlType{
+			schema: s,
+			fields: fields,
+			name:   name,
+			keys:   map[string]string{},
+			entry:  &graphql.Object{Name: name, Description: fmt.Sprintf("An entry of the %q schema.", s.Name)},
+			data:   &graphql.Object{Name: name + "Data"},
+			input:  &graphql.InputObject{Name: name + "Input"},
+		}
+		b.types[s.Name] = t
+		types = append(types, t)
+	}
+
+	query := &graphql.Object{Name: "Query"}
+	mutation := &graphql.Object{Name: "Mutation"}
+	for _, t := range types {
+		b.dataFields(t)
+		b.entryFields(t)
+		query.Fields = append(query.Fields, b.getField(t), b.listField(t))
+		mutation.Fields = append(mutation.Fields, b.mutationFields(t)...)
+	}
+	if len(mutation.Fields) == 0 {
+		mutation = nil
+	}
+
+	return graphql.NewSchema(query, mutation, graphql.Options{
+		MaxLength:     graphqlMaxLength,
+		MaxFragments:  graphqlMaxFragments,
+		MaxDepth:      graphqlMaxDepth,
+		MaxComplexity: graphqlMaxComplexity,
+	})
+}
+
+// typeName turns a schema name into a free type name, the type's Data, Page,
+// Input and List companions included
+func (b *gqlBuilder) typeName(schemaName string) string {
+	base := sanitizeName(codegen.Pascal(schemaName))
+	if base[0] == '_' {
+		base = "T" + strings.TrimLeft(base, "_")
+	}
+	suffixes := []string{"", "Data", "Page", "Input", "List"}
+	name := base
+	for i := 2; slices.ContainsFunc(suffixes, func(s string) bool { return b.names[name+s] }); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	for _, s := range suffixes {
+		b.names[name+s] = true
+	}
+	return name
+}
+
+// sanitizeName replaces what GraphQL names cannot hold with underscores
+func sanitizeName(s string) string {
+	out := []byte(s)
+	for i, r := range out {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			out[i] = '_'
+		}
+	}
+	if len(out) == 0 || out[0] >= '0' && out[0] <= '9' {
+		out = append([]byte{'_'}, out...)
+	}
+	return string(out)
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// dataFields maps the schema's fields onto the Data and Input types. Fields
+// whose names cannot be told apart once sanitized are left out.
+func (b *gqlBuilder) dataFields(t *gqlType) {
+	for _, f := range t.fields {
+		name := sanitizeName(f.Name)
+		if strings.HasPrefix(name, "__") || t.keys[name] != "" {
+			b.logger.Warn("Leaving a field out of the GraphQL schema", zap.String("schema", t.schema.Name), zap.String("field", f.Name))
+			continue
+		}
+		t.keys[name] = f.Name
+
+		elem, isArray := f.ElemType()
+		out, in := gqlScalar(elem)
+		field := &graphql.Field{Name: name, Type: out, Resolve: dataValue(f.Name)}
+		if elem == "reference" {
+			if target, ok := b.types[f.Ref]; ok {
+				field.Type = target.entry
+				field.Resolve = b.reference(target, f.Name)
+			}
+		}
+		if elem == "richtext" {
+			field.Args = []*graphql.InputValue{{Name: "format", Type: richTextFormat, Description: "Defaults to JSON."}}
+			field.Resolve = richTextValue(f)
+		}
+		if isArray {
+			field.Type = &graphql.List{Of: field.Type}
+			in = &graphql.List{Of: &graphql.NonNull{Of: in}}
+		}
+		if f.UI != nil {
+			field.Description = f.UI.Description
+		}
+		t.data.Fields = append(t.data.Fields, field)
+		// Required fields are checked against the schema, translations send only some
+		t.input.Fields = append(t.input.Fields, &graphql.InputValue{Name: name, Type: in, Description: field.Description})
+	}
+}
+
+// gqlScalar picks the output and input types of a field type
+func gqlScalar(elem string) (graphql.Type, graphql.Type) {
+	switch elem {
+	case "number":
+		return graphql.Float, graphql.Float
+	case "boolean":
+		return graphql.Boolean, graphql.Boolean
+	case "json", "richtext":
+		// rich text is written as a document, Markdown or HTML
+		return graphql.JSON, graphql.JSON
+	case "reference":
+		return graphql.ID, graphql.ID
+	}
+	return graphql.String, graphql.String
+}
+
+// richTextValue reads a rich text field in the requested format
+func richTextValue(f utils.Field) func(graphql.ResolveParams) (interface{}, error) {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		format, _ := p.Args["format"].(string)
+		if format == "" {
+			format = "JSON"
+		}
+		return utils.FormatRichTextValue(f, p.Source.(*gqlEntry).data[f.Name], strings.ToLower(format)), nil
+	}
+}
+
+func dataValue(key string) func(graphql.ResolveParams) (interface{}, error) {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return p.Source.(*gqlEntry).data[key], nil
+	}
+}
+
+// reference resolves the entries a field points to, read like the entry holding it
+func (b *gqlBuilder) reference(target *gqlType, key string) func(graphql.ResolveParams) (interface{}, error) {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		e := p.Source.(*gqlEntry)
+		load := func(v interface{}) (interface{}, error) {
+			s, _ := v.(string)
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return nil, nil
+			}
+			return b.load(p.Context, target, id, e.lc, e.preview)
+		}
+
+		items, isArray := e.data[key].([]interface{})
+		if !isArray {
+			return load(e.data[key])
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			entry, err := load(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = entry
+		}
+		return out, nil
+	}
+}
+
+// gqlEntry is an entry read for a response, localized and with its metadata
+type gqlEntry struct {
+	content   db.Content
+	data      map[string]interface{}
+	fallbacks map[string]string
+	complete  []string
+	published bool
+	hasDraft  *bool // previews only
+
+	lc      *localeContext
+	preview bool
+}
+
+func (b *gqlBuilder) entryFields(t *gqlType) {
+	entry := func(p graphql.ResolveParams) *gqlEntry { return p.Source.(*gqlEntry) }
+	t.entry.Fields = []*graphql.Field{
+		{Name: "id", Type: &graphql.NonNull{Of: graphql.ID}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return entry(p).content.ID.String(), nil
+		}},
+		{Name: "data", Type: &graphql.NonNull{Of: t.data}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source, nil
+		}},
+		{Name: "locale", Type: &graphql.NonNull{Of: graphql.String}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return entry(p).lc.Requested, nil
+		}},
+		{Name: "fallbacks", Type: graphql.JSON, Description: "The locale each localized field was read from, when it is not the requested one.", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return entry(p).fallbacks, nil
+		}},
+		{Name: "completeLocales", Type: &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: graphql.String}}}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return nonNilStrings(entry(p).complete), nil
+		}},
+		{Name: "published", Type: &graphql.NonNull{Of: graphql.Boolean}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return entry(p).published, nil
+		}},
+		{Name: "hasDraft", Type: graphql.Boolean, Description: "Set on previews only.", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if d := entry(p).hasDraft; d != nil {
+				return *d, nil
+			}
+			return nil, nil
+		}},
+		{Name: "version", Type: &graphql.NonNull{Of: graphql.Int}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return entry(p).content.Version, nil
+		}},
+		{Name: "createdAt", Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return timestamp(entry(p).content.CreatedAt), nil
+		}},
+		{Name: "updatedAt", Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return timestamp(entry(p).content.UpdatedAt), nil
+		}},
+	}
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func timestamp(t pgtype.Timestamptz) interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time.Format("2006-01-02T15:04:05.999999Z07:00")
+}
+
+// Arguments every read takes
+func readArgs() []*graphql.InputValue {
+	return []*graphql.InputValue{
+		{Name: "locale", Type: graphql.String, Description: "Defaults to the default locale."},
+		{Name: "preview", Type: graphql.Boolean, Default: false, Description: "Read drafts and unpublished entries, needs the viewer role."},
+	}
+}
+
+// readScope checks the preview permission and resolves the locale of a read
+func (b *gqlBuilder) readScope(p graphql.ResolveParams) (*localeContext, bool, error) {
+	preview, _ := p.Args["preview"].(bool)
+	if preview && !callerOf(p.Context).viewer {
+		return nil, false, errors.New("preview needs the viewer role")
+	}
+	code, _ := p.Args["locale"].(string)
+	lc, err := b.locale(p.Context, code)
+	return lc, preview, err
+}
+
+func (b *gqlBuilder) locale(ctx context.Context, code string) (*localeContext, error) {
+	lc, err := loadLocales(ctx, b.queries, code)
+	if errors.Is(err, errUnknownLocale) {
+		return nil, errors.New("unknown locale")
+	}
+	if err != nil {
+		b.logger.Error("Error fetching locales", zap.Error(err))
+		return nil, errors.New("error fetching locales")
+	}
+	return lc, nil
+}
+
+// getField is the single entry query, by id or by one unique field
+func (b *gqlBuilder) getField(t *gqlType) *graphql.Field {
+	args := []*graphql.InputValue{{Name: "id", Type: graphql.ID}}
+	unique := map[string]string{}
+	for name, key := range t.keys {
+		f := t.fields[slices.IndexFunc(t.fields, func(f utils.Field) bool { return f.Name == key })]
+		if f.Unique && name != "id" && name != "locale" && name != "preview" {
+			unique[name] = key
+		}
+	}
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		args = append(args, &graphql.InputValue{Name: name, Type: graphql.String, Description: "Look the entry up by this unique field."})
+	}
+
+	return &graphql.Field{
+		Name:        lowerFirst(t.name),
+		Description: fmt.Sprintf("One %q entry, by id or by a unique field.", t.schema.Name),
+		Type:        t.entry,
+		Args:        append(args, readArgs()...),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			lc, preview, err := b.readScope(p)
+			if err != nil {
+				return nil, err
+			}
+
+			var lookups []string
+			for _, name := range append([]string{"id"}, names...) {
+				if _, ok := p.Args[name]; ok {
+					lookups = append(lookups, name)
+				}
+			}
+			if len(lookups) != 1 {
+				return nil, fmt.Errorf("give exactly one of %s", strings.Join(append([]string{"id"}, names...), ", "))
+			}
+
+			value, _ := p.Args[lookups[0]].(string)
+			var id uuid.UUID
+			if lookups[0] == "id" {
+				if id, err = uuid.Parse(value); err != nil {
+					return nil, errors.New("invalid ID format")
+				}
+			} else {
+				id, err = b.queries.GetContentIDByUniqueValue(p.Context, db.GetContentIDByUniqueValueParams{
+					SchemaID: t.schema.ID,
+					Field:    unique[lookups[0]],
+					Value:    value,
+				})
+				if errors.Is(err, pgx.ErrNoRows) {
+					return nil, nil
+				}
+				if err != nil {
+					b.logger.Error("Error looking up content by field", zap.Error(err))
+					return nil, errors.New("error fetching content")
+				}
+			}
+			return b.load(p.Context, t, id, lc, preview)
+		},
+	}
+}
+
+// load reads one entry of the type, nil when it is missing or not visible
+func (b *gqlBuilder) load(ctx context.Context, t *gqlType, id uuid.UUID, lc *localeContext, preview bool) (interface{}, error) {
+	content, err := b.queries.GetContentByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		b.logger.Error("Error fetching content by ID", zap.Error(err))
+		return nil, errors.New("error fetching content")
+	}
+	if uuid.UUID(content.SchemaID.Bytes) != t.schema.ID || (!preview && !content.Published.Bool) {
+		return nil, nil
+	}
+
+	rows, err := b.queries.GetContentLocalesByContentIDs(ctx, []uuid.UUID{id})
+	if err != nil {
+		b.logger.Error("Error fetching translations", zap.Error(err))
+		return nil, errors.New("error fetching content")
+	}
+	return b.entry(t, content, rows, lc, preview, !preview), nil
+}
+
+// entry localizes an entry the way the REST reads do
+func (b *gqlBuilder) entry(t *gqlType, content db.Content, allRows []db.ContentLocale, lc *localeContext, preview, publishedOnly bool) *gqlEntry {
+	if preview {
+		content.Data = workingData(content)
+	}
+	data := decodeData(content.Data)
+	rows := translations(allRows)
+	if preview {
+		rows = workingRows(rows)
+	}
+	localized, resolved := lc.localize(data, t.fields, rows, publishedOnly)
+
+	e := &gqlEntry{
+		content:   content,
+		data:      localized,
+		fallbacks: lc.fallbacks(resolved),
+		complete:  lc.completeLocales(data, t.fields, rows),
+		published: lc.isPublished(content, rows),
+		lc:        lc,
+		preview:   preview,
+	}
+	if preview {
+		draft := hasDraft(content, rows)
+		e.hasDraft = &draft
+	}
+	return e
+}
+
+// listField is the filtered, sorted and paginated list query, its arguments
+// work like the query parameters of get_all
+func (b *gqlBuilder) listField(t *gqlType) *graphql.Field {
+	page := &graphql.Object{Name: t.name + "Page", Fields: []*graphql.Field{
+		{Name: "count", Type: &graphql.NonNull{Of: graphql.Int}},
+		{Name: "total", Type: &graphql.NonNull{Of: graphql.Int}},
+		{Name: "limit", Type: &graphql.NonNull{Of: graphql.Int}},
+		{Name: "offset", Type: &graphql.NonNull{Of: graphql.Int}},
+		{Name: "nextCursor", Type: graphql.String},
+		{Name: "items", Type: &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: t.entry}}}},
+	}}
+
+	return &graphql.Field{
+		Name:        lowerFirst(t.name) + "List",
+		Description: fmt.Sprintf("A page of %q entries.", t.schema.Name),
+		Type:        &graphql.NonNull{Of: page},
+		Args: append([]*graphql.InputValue{
+			{Name: "filter", Type: graphql.JSON, Description: "A filter object, as the filter query parameter takes."},
+			{Name: "sort", Type: graphql.String, Description: "Comma separated fields, - sorts descending."},
+			{Name: "limit", Type: graphql.Int},
+			{Name: "offset", Type: graphql.Int},
+			{Name: "cursor", Type: graphql.String},
+			{Name: "published", Type: graphql.String, Description: "true, false or all, previews only."},
+		}, readArgs()...),
+		Multiplier: func(args map[string]interface{}) int {
+			if limit, ok := args["limit"].(int64); ok && limit > 0 {
+				return int(limit)
+			}
+			return listquery.DefaultLimit
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			lc, preview, err := b.readScope(p)
+			if err != nil {
+				return nil, err
+			}
+
+			params := listquery.Params{}
+			switch filter := p.Args["filter"].(type) {
+			case nil:
+			case string:
+				params.Filter = filter
+			default:
+				raw, _ := json.Marshal(filter)
+				params.Filter = string(raw)
+			}
+			params.Sort, _ = p.Args["sort"].(string)
+			params.Cursor, _ = p.Args["cursor"].(string)
+			if limit, ok := p.Args["limit"].(int64); ok {
+				params.Limit = int(limit)
+			}
+			if offset, ok := p.Args["offset"].(int64); ok {
+				params.Offset = int(offset)
+			}
+			q, err := listquery.Parse(params, t.fields)
+			if err != nil {
+				return nil, err
+			}
+
+			published := "true"
+			if preview {
+				published = "all"
+				if v, ok := p.Args["published"].(string); ok {
+					published = v
+				}
+			}
+
+			result, err := listContents(p.Context, b.pool, listScope{
+				SchemaID:  t.schema.ID,
+				Published: published,
+				Locale:    lc,
+				Preview:   preview,
+			}, q)
+			if err != nil {
+				b.logger.Error("Error fetching contents", zap.Error(err))
+				return nil, errors.New("error fetching contents")
+			}
+
+			ids := make([]uuid.UUID, len(result.Contents))
+			for i, content := range result.Contents {
+				ids[i] = content.ID
+			}
+			allRows, err := b.queries.GetContentLocalesByContentIDs(p.Context, ids)
+			if err != nil {
+				b.logger.Error("Error fetching translations", zap.Error(err))
+				return nil, errors.New("error fetching contents")
+			}
+			rowsByContent := map[uuid.UUID][]db.ContentLocale{}
+			for _, r := range allRows {
+				rowsByContent[r.ContentID] = append(rowsByContent[r.ContentID], r)
+			}
+
+			items := make([]*gqlEntry, len(result.Contents))
+			for i, content := range result.Contents {
+				items[i] = b.entry(t, content, rowsByContent[content.ID], lc, preview, published == "true")
+			}
+			var next interface{}
+			if result.NextCursor != "" {
+				next = result.NextCursor
+			}
+			return map[string]interface{}{
+				"count":      len(items),
+				"total":      result.Total,
+				"limit":      q.Limit,
+				"offset":     q.Offset,
+				"nextCursor": next,
+				"items":      items,
+			}, nil
+		},
+	}
+}
+
+// mutationFields create, update and delete entries through the bulk engine,
+// so they validate and record revisions like the REST routes
+func (b *gqlBuilder) mutationFields(t *gqlType) []*graphql.Field {
+	data := &graphql.InputValue{Name: "data", Type: &graphql.NonNull{Of: t.input}}
+	published := &graphql.InputValue{Name: "published", Type: graphql.Boolean}
+	id := &graphql.InputValue{Name: "id", Type: &graphql.NonNull{Of: graphql.ID}}
+	version := &graphql.InputValue{Name: "version", Type: graphql.Int, Description: "Fail unless the entry is still at this version."}
+
+	return []*graphql.Field{
+		{
+			Name:        "create" + t.name,
+			Description: fmt.Sprintf("Create a %q entry in the default locale.", t.schema.Name),
+			Type:        t.entry,
+			Args:        []*graphql.InputValue{data, published},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return b.mutate(p, t, bulkOperation{Op: "create", SchemaID: t.schema.ID.String()})
+			},
+		},
+		{
+			Name:        "update" + t.name,
+			Description: "Replace an entry's data, or its translation with locale. Published entries get a draft.",
+			Type:        t.entry,
+			Args:        []*graphql.InputValue{id, data, published, version, {Name: "locale", Type: graphql.String}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				locale, _ := p.Args["locale"].(string)
+				return b.mutate(p, t, bulkOperation{Op: "update", SchemaID: t.schema.ID.String(), Locale: locale})
+			},
+		},
+		{
+			Name:        "delete" + t.name,
+			Description: "Move an entry to the trash, returning its id.",
+			Type:        graphql.ID,
+			Args:        []*graphql.InputValue{id, version},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return b.mutate(p, t, bulkOperation{Op: "delete", SchemaID: t.schema.ID.String()})
+			},
+		},
+	}
+}
+
+// mutate runs one operation with the arguments of a mutation field and reads
+// the entry back as a preview
+func (b *gqlBuilder) mutate(p graphql.ResolveParams, t *gqlType, op bulkOperation) (interface{}, error) {
+	caller := callerOf(p.Context)
+	if !caller.editor {
+		return nil, errors.New("mutations need the editor role")
+	}
+
+	op.ID, _ = p.Args["id"].(string)
+	if v, ok := p.Args["published"].(bool); ok {
+		op.Published = &v
+	}
+	if v, ok := p.Args["version"].(int64); ok {
+		version := int32(v)
+		op.Version = &version
+	}
+	if in, ok := p.Args["data"].(map[string]interface{}); ok {
+		data, err := t.inputData(in)
+		if err != nil {
+			return nil, err
+		}
+		op.Data = data
+	}
+
+	job := &bulkJob{
+		queries: b.queries,
+		pool:    b.pool,
+		logger:  b.logger,
+		user:    caller.user,
+		ops:     []bulkOperation{op},
+	}
+	status, body := job.run(p.Context)
+	result := job.results[0]
+	if !result.OK {
+		if result.Error == "" && status == fiber.StatusInternalServerError {
+			return nil, fmt.Errorf("%v", body["error"])
+		}
+		return nil, errors.New(result.Error)
+	}
+
+	if op.Op == "delete" {
+		return result.ID.String(), nil
+	}
+	lc, err := b.locale(p.Context, op.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return b.load(p.Context, t, *result.ID, lc, true)
+}
+
+// inputData maps an input object back onto data keys. Null values are left
+// out, round tripping through JSON makes numbers float64 as validation expects.
+func (t *gqlType) inputData(in map[string]interface{}) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	for name, v := range in {
+		if v != nil {
+			data[t.keys[name]] = v
+		}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.New("could not encode data")
+	}
+	data = map[string]interface{}{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, errors.New("could not encode data")
+	}
+	return data, nil
+}
@@ -0,0 +1,72 @@
+package content
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// ContentLinksHandler lists the links in an entry's rich text, to other
+// entries and to URLs, and the entries whose rich text links to it.
+// Links of drafts are included, so nothing is deleted that a draft needs.
+func ContentLinksHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		content, err := fetchContent(c, queries)
+		if err != nil {
+			return contentError(c, logger, err)
+		}
+
+		lc, err := loadLocales(c.Context(), queries, "")
+		if err != nil {
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch locales",
+			})
+		}
+		locale := func(l pgtype.Text) string {
+			if l.Valid {
+				return l.String
+			}
+			return lc.Default
+		}
+
+		links, err := queries.ListContentLinks(c.Context(), content.ID)
+		if err != nil {
+			logger.Error("Error fetching content links", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch links",
+			})
+		}
+		out := make([]fiber.Map, 0, len(links))
+		for _, l := range links {
+			link := fiber.Map{"locale": locale(l.Locale), "field": l.Field}
+			if l.TargetContentID.Valid {
+				link["contentId"] = uuid.UUID(l.TargetContentID.Bytes)
+			} else {
+				link["url"] = l.Url.String
+			}
+			out = append(out, link)
+		}
+
+		backlinks, err := queries.ListLinksToContent(c.Context(), pgtype.UUID{Bytes: content.ID, Valid: true})
+		if err != nil {
+			logger.Error("Error fetching content backlinks", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch links",
+			})
+		}
+		from := make([]fiber.Map, 0, len(backlinks))
+		for _, l := range backlinks {
+			from = append(from, fiber.Map{
+				"id":        l.ContentID,
+				"schema_id": uuid.UUID(l.SchemaID.Bytes),
+				"locale":    locale(l.Locale),
+				"field":     l.Field,
+			})
+		}
+
+		return c.JSON(fiber.Map{"links": out, "linkedFrom": from})
+	}
+}
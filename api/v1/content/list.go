@@ -0,0 +1,188 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/listquery"
+)
+
+// contentColumns matches the field order of db.Content, keep in sync with the contents table
+const contentColumns = "id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published"
+
+func scanContent(row interface{ Scan(...interface{}) error }, extra ...interface{}) (db.Content, error) {
+	var i db.Content
+	dest := []interface{}{
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	return i, err
+}
+
+// listScope narrows a content list before user filters apply
+type listScope struct {
+	SchemaID  uuid.UUID
+	Published string // true | false | all
+	Locale    *localeContext
+	Preview   bool // read pending drafts and unpublished entries
+	Fields    *listquery.Projection
+}
+
+type listPage struct {
+	Contents   []db.Content
+	Total      int64
+	NextCursor string
+}
+
+// where renders the scope conditions, a zero SchemaID spans every schema.
+// Publish state of non-default locales lives in content_locales.
+func (s listScope) where(args *listquery.Args) string {
+	cond := "contents.deleted_at IS NULL"
+	if !s.Preview {
+		cond += " AND contents.published IS TRUE"
+	}
+	if s.SchemaID != uuid.Nil {
+		cond += " AND contents.schema_id = " + args.Add(pgtype.UUID{Bytes: s.SchemaID, Valid: true})
+	}
+	if s.Published != "true" && s.Published != "false" {
+		return cond
+	}
+
+	var published string
+	if s.Locale == nil || s.Locale.isDefault() {
+		published = "contents.published IS TRUE"
+	} else {
+		published = "EXISTS (SELECT 1 FROM content_locales cl WHERE cl.content_id = contents.id AND cl.locale = " +
+			args.Add(s.Locale.Requested) + " AND cl.published)"
+	}
+	if s.Published == "false" {
+		published = "NOT (" + published + ")"
+	}
+	return cond + " AND " + published
+}
+
+// source is the table a list reads from, previews see drafts as data
+func (s listScope) source() string {
+	if !s.Preview {
+		return "contents"
+	}
+	columns := strings.Replace(contentColumns, " data,", " COALESCE(draft_data, data) AS data,", 1)
+	return "(SELECT " + columns + " FROM contents) contents"
+}
+
+// columns selects contentColumns with the data projected
+func (s listScope) columns(args *listquery.Args) string {
+	return projectedColumns(contentColumns, s.Fields, args)
+}
+
+// projectedColumns swaps data and draft_data in a column list for their projection
+func projectedColumns(columns string, fields *listquery.Projection, args *listquery.Args) string {
+	if fields == nil {
+		return columns
+	}
+	columns = strings.Replace(columns, " data,", " "+fields.SQL("data", args)+" AS data,", 1)
+	return strings.Replace(columns, " draft_data", " "+fields.SQL("draft_data", args)+" AS draft_data", 1)
+}
+
+// listContents runs a filtered, sorted and paginated content query
+func listContents(ctx context.Context, pool *pgxpool.Pool, scope listScope, q *listquery.Query) (*listPage, error) {
+	page := &listPage{}
+
+	// Total ignores pagination
+	countArgs := &listquery.Args{}
+	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s AND %s",
+		scope.source(), scope.where(countArgs), q.Where(countArgs))
+	if err := pool.QueryRow(ctx, countSQL, countArgs.Values...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	args := &listquery.Args{}
+	where := scope.where(args) + " AND " + q.Where(args) + " AND " + q.After(args)
+	sortValues := q.SortValues(args)
+	orderBy := q.OrderBy(args)
+	// Fetch one extra row to know whether there is a next page
+	pageSQL := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s ORDER BY %s LIMIT %s OFFSET %s",
+		scope.columns(args), sortValues, scope.source(), where, orderBy, args.Add(q.Limit+1), args.Add(q.Offset))
+
+	rows, err := pool.Query(ctx, pageSQL, args.Values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lastValues []pgtype.Text
+	for rows.Next() {
+		var values []pgtype.Text
+		content, err := scanContent(rows, &values)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Contents) == q.Limit {
+			last := page.Contents[len(page.Contents)-1]
+			cursor := make([]*string, len(lastValues))
+			for i, v := range lastValues {
+				if v.Valid {
+					s := v.String
+					cursor[i] = &s
+				}
+			}
+			page.NextCursor = q.NextCursor(cursor, last.ID)
+			break
+		}
+		page.Contents = append(page.Contents, content)
+		lastValues = values
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// termCounts counts the entries of a list under each term of a taxonomy
+// field, ignoring pagination. An entry counts for its terms and every
+// term above them.
+func termCounts(ctx context.Context, pool *pgxpool.Pool, scope listScope, q *listquery.Query, field utils.Field) (map[string]int64, error) {
+	args := &listquery.Args{}
+	key := args.Add(field.Name) + "::text"
+	terms := fmt.Sprintf("CASE jsonb_typeof(data->%[1]s) WHEN 'array' THEN data->%[1]s WHEN 'string' THEN jsonb_build_array(data->%[1]s) ELSE '[]'::jsonb END", key)
+	countSQL := fmt.Sprintf("SELECT a.ancestor, COUNT(DISTINCT contents.id) FROM %s, LATERAL jsonb_array_elements_text(%s) v(slug), taxonomy_ancestry(%s) a WHERE a.slug = v.slug AND %s AND %s GROUP BY a.ancestor",
+		scope.source(), terms, args.Add(field.Taxonomy), scope.where(args), q.Where(args))
+
+	rows, err := pool.Query(ctx, countSQL, args.Values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int64{}
+	for rows.Next() {
+		var slug string
+		var n int64
+		if err := rows.Scan(&slug, &n); err != nil {
+			return nil, err
+		}
+		counts[slug] = n
+	}
+	return counts, rows.Err()
+}
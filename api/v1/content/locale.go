@@ -0,0 +1,189 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+)
+
+var errUnknownLocale = errors.New("unknown locale")
+
+// localeContext describes the locale a request reads or writes in.
+// The default locale's values live in contents.data, other locales in content_locales.
+type localeContext struct {
+	Requested string
+	Default   string
+	Chain     []string // lookup order: requested locale, its fallbacks, then the default
+	All       []db.Locale
+}
+
+func loadLocales(ctx context.Context, queries *db.Queries, requested string) (*localeContext, error) {
+	locales, err := queries.ListLocales(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := make(map[string]db.Locale, len(locales))
+	lc := &localeContext{All: locales}
+	for _, l := range locales {
+		byCode[l.Code] = l
+		if l.IsDefault {
+			lc.Default = l.Code
+		}
+	}
+
+	if requested == "" {
+		requested = lc.Default
+	}
+	if _, ok := byCode[requested]; !ok {
+		return nil, errUnknownLocale
+	}
+	lc.Requested = requested
+
+	// Walk the fallback chain, guarding against cycles
+	seen := map[string]bool{}
+	for code := requested; code != "" && !seen[code]; {
+		seen[code] = true
+		lc.Chain = append(lc.Chain, code)
+		code = byCode[code].Fallback.String
+	}
+	if !seen[lc.Default] {
+		lc.Chain = append(lc.Chain, lc.Default)
+	}
+
+	return lc, nil
+}
+
+// isDefault reports whether the request targets the default locale
+func (lc *localeContext) isDefault() bool {
+	return lc.Requested == lc.Default
+}
+
+func localizedFields(fields []utils.Field) []string {
+	var names []string
+	for _, f := range fields {
+		if f.Localized {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// translations indexes content_locales rows by locale
+func translations(rows []db.ContentLocale) map[string]db.ContentLocale {
+	out := make(map[string]db.ContentLocale, len(rows))
+	for _, r := range rows {
+		out[r.Locale] = r
+	}
+	return out
+}
+
+func decodeData(raw []byte) map[string]interface{} {
+	data := map[string]interface{}{}
+	_ = json.Unmarshal(raw, &data)
+	return data
+}
+
+// localize overlays translated values onto the base data following the fallback chain.
+// It returns the localized data and, for each localized field, the locale its value came from.
+// With publishedOnly, unpublished translations are skipped.
+func (lc *localeContext) localize(base map[string]interface{}, fields []utils.Field, rows map[string]db.ContentLocale, publishedOnly bool) (map[string]interface{}, map[string]string) {
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+
+	decoded := map[string]map[string]interface{}{}
+	resolved := map[string]string{}
+
+	for _, name := range localizedFields(fields) {
+		delete(out, name)
+		for _, code := range lc.Chain {
+			var src map[string]interface{}
+			if code == lc.Default {
+				src = base
+			} else {
+				row, ok := rows[code]
+				if !ok || (publishedOnly && !row.Published) {
+					continue
+				}
+				if decoded[code] == nil {
+					decoded[code] = decodeData(row.Data)
+				}
+				src = decoded[code]
+			}
+			if v, ok := src[name]; ok {
+				out[name] = v
+				resolved[name] = code
+				break
+			}
+		}
+	}
+
+	return out, resolved
+}
+
+// fallbacks lists the localized fields that were served from another locale
+func (lc *localeContext) fallbacks(resolved map[string]string) map[string]string {
+	out := map[string]string{}
+	for field, code := range resolved {
+		if code != lc.Requested {
+			out[field] = code
+		}
+	}
+	return out
+}
+
+// completeLocales lists the locales that have a value for every localized field
+func (lc *localeContext) completeLocales(base map[string]interface{}, fields []utils.Field, rows map[string]db.ContentLocale) []string {
+	names := localizedFields(fields)
+	complete := []string{}
+
+	for _, l := range lc.All {
+		src := base
+		if !l.IsDefault {
+			row, ok := rows[l.Code]
+			if !ok {
+				if len(names) == 0 {
+					complete = append(complete, l.Code)
+				}
+				continue
+			}
+			src = decodeData(row.Data)
+		}
+
+		ok := true
+		for _, name := range names {
+			if _, found := src[name]; !found {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			complete = append(complete, l.Code)
+		}
+	}
+
+	return complete
+}
+
+// isPublished reports the publish state of an entry in the requested locale
+func (lc *localeContext) isPublished(content db.Content, rows map[string]db.ContentLocale) bool {
+	if lc.isDefault() {
+		return content.Published.Bool
+	}
+	row, ok := rows[lc.Requested]
+	return ok && row.Published
+}
+
+// getTranslations loads the content_locales rows of one entry
+func getTranslations(ctx context.Context, queries *db.Queries, content db.Content) (map[string]db.ContentLocale, error) {
+	rows, err := queries.GetContentLocales(ctx, content.ID)
+	if err != nil {
+		return nil, err
+	}
+	return translations(rows), nil
+}
@@ -0,0 +1,172 @@
+// Send patch request on the url /api/v1/content/:id with either body below.
+// The patch target is the entry as { "data": {...}, "published": bool },
+// add ?locale=de to patch a translation instead.
+//
+// Content-Type: application/merge-patch+json (or application/json)
+// { "published": true, "data": { "title": "New title", "subtitle": null } }
+//
+// Content-Type: application/json-patch+json
+// [ { "op": "replace", "path": "/data/title", "value": "New title" } ]
+
+package content
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils/jsonpatch"
+	"go.uber.org/zap"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+func PatchContentHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid content ID",
+			})
+		}
+
+		content, err := queries.GetContentByID(c.Context(), contentID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Content not found",
+				})
+			}
+			logger.Error("Error fetching content by ID", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch content",
+			})
+		}
+
+		schema, err := queries.GetSchemaByID(c.Context(), uuid.UUID(content.SchemaID.Bytes))
+		if err != nil {
+			logger.Error("Error fetching schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch schema",
+			})
+		}
+
+		lc, err := loadLocales(c.Context(), queries, c.Query("locale"))
+		if err != nil {
+			if errors.Is(err, errUnknownLocale) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unknown locale",
+				})
+			}
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch locales",
+			})
+		}
+
+		expected, err := expectedVersion(c, content, nil)
+		if err != nil {
+			return preconditionFailed(c, content)
+		}
+
+		// Build the document the patch applies to
+		rows, err := getTranslations(c.Context(), queries, content)
+		if err != nil {
+			logger.Error("Error fetching translations", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch content",
+			})
+		}
+		// Patches apply on top of pending drafts
+		rows = workingRows(rows)
+		data := decodeData(workingData(content))
+		if !lc.isDefault() {
+			data = decodeData(rows[lc.Requested].Data)
+		}
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		target := map[string]interface{}{
+			"data":      data,
+			"published": lc.isPublished(content, rows),
+		}
+
+		var patched interface{}
+		mime, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+		switch strings.TrimSpace(mime) {
+		case mimeJSONPatch:
+			ops, err := jsonpatch.Decode(c.Body())
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			patched, err = jsonpatch.Apply(target, ops)
+			if err != nil {
+				// A failed "test" op is a precondition failure, anything else a bad patch
+				status := fiber.StatusUnprocessableEntity
+				if errors.Is(err, jsonpatch.ErrTestFailed) {
+					status = fiber.StatusConflict
+				}
+				return c.Status(status).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		case mimeMergePatch, fiber.MIMEApplicationJSON:
+			var patch map[string]interface{}
+			if err := json.Unmarshal(c.Body(), &patch); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Merge patch must be a JSON object",
+				})
+			}
+			patched = jsonpatch.MergePatch(target, patch)
+		default:
+			return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+				"error": "Use Content-Type " + mimeMergePatch + " or " + mimeJSONPatch,
+			})
+		}
+
+		// The patched document must keep the entry's shape
+		doc, ok := patched.(map[string]interface{})
+		if !ok {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Patched document must be an object",
+			})
+		}
+		for key := range doc {
+			if key != "data" && key != "published" {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": "Only 'data' and 'published' can be patched, got '" + key + "'",
+				})
+			}
+		}
+		newData, ok := doc["data"].(map[string]interface{})
+		if !ok {
+			newData = map[string]interface{}{}
+			if doc["data"] != nil {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": "'data' must be an object",
+				})
+			}
+		}
+		published, ok := doc["published"].(bool)
+		if !ok {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "'published' must be a boolean",
+			})
+		}
+
+		if !lc.isDefault() {
+			return updateTranslation(c, queries, logger, pool, lc, content, schema, newData, published, expected)
+		}
+		return updateBase(c, queries, logger, lc, content, schema, newData, published, expected)
+	}
+}
@@ -0,0 +1,97 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/listquery"
+)
+
+const localeColumns = "content_id, locale, data, published, created_at, updated_at, draft_data, draft_published"
+
+// readOptions shapes the response of a content read: ?fields picks parts of
+// the data, ?meta=false leaves only the id and data of each entry and
+// ?format=html|markdown renders rich text fields instead of their documents
+type readOptions struct {
+	Fields *listquery.Projection
+	Meta   bool
+	Format string
+}
+
+func parseReadOptions(c *fiber.Ctx) (readOptions, error) {
+	fields, err := listquery.ParseProjection(c.Query("fields"))
+	if err != nil {
+		return readOptions{}, err
+	}
+	format, err := parseFormat(c)
+	if err != nil {
+		return readOptions{}, err
+	}
+	return readOptions{Fields: fields, Meta: c.QueryBool("meta", true), Format: format}, nil
+}
+
+// parseFormat reads ?format, the form rich text is returned in
+func parseFormat(c *fiber.Ctx) (string, error) {
+	format := c.Query("format", "json")
+	if !slices.Contains(utils.RichTextFormats, format) {
+		return "", fmt.Errorf("format must be one of %s", strings.Join(utils.RichTextFormats, ", "))
+	}
+	return format, nil
+}
+
+// format converts the rich text fields of localized data
+func (o readOptions) format(fields []utils.Field, data map[string]interface{}) map[string]interface{} {
+	utils.FormatRichText(fields, data, o.Format)
+	return data
+}
+
+func (o readOptions) shape(item fiber.Map) fiber.Map {
+	if o.Meta {
+		return item
+	}
+	return fiber.Map{"id": item["id"], "data": item["data"]}
+}
+
+// getProjectedContent fetches an entry with its data projected in the query
+func getProjectedContent(ctx context.Context, queries *db.Queries, pool *pgxpool.Pool, id uuid.UUID, fields *listquery.Projection) (db.Content, error) {
+	if fields == nil {
+		return queries.GetContentByID(ctx, id)
+	}
+	args := &listquery.Args{}
+	sql := fmt.Sprintf("SELECT %s FROM contents WHERE id = %s AND deleted_at IS NULL",
+		projectedColumns(contentColumns, fields, args), args.Add(id))
+	return scanContent(pool.QueryRow(ctx, sql, args.Values...))
+}
+
+// getProjectedTranslations loads the content_locales rows of the given entries,
+// projected like their base data
+func getProjectedTranslations(ctx context.Context, queries *db.Queries, pool *pgxpool.Pool, ids []uuid.UUID, fields *listquery.Projection) ([]db.ContentLocale, error) {
+	if fields == nil {
+		return queries.GetContentLocalesByContentIDs(ctx, ids)
+	}
+	args := &listquery.Args{}
+	sql := fmt.Sprintf("SELECT %s FROM content_locales WHERE content_id = ANY(%s::uuid[]) ORDER BY locale",
+		projectedColumns(localeColumns, fields, args), args.Add(ids))
+	rows, err := pool.Query(ctx, sql, args.Values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []db.ContentLocale
+	for rows.Next() {
+		var r db.ContentLocale
+		if err := rows.Scan(&r.ContentID, &r.Locale, &r.Data, &r.Published, &r.CreatedAt, &r.UpdatedAt, &r.DraftData, &r.DraftPublished); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,216 @@
+package content
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+// currentUser is the signed in user, invalid on public routes
+func currentUser(c *fiber.Ctx) pgtype.UUID {
+	claims, ok := c.Locals("claims").(jwt.MapClaims)
+	if !ok {
+		return pgtype.UUID{}
+	}
+	userID, _ := claims["user_id"].(string)
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: id, Valid: true}
+}
+
+func formatRevision(r db.ContentRevision, withData bool) fiber.Map {
+	m := fiber.Map{
+		"version":   r.Version,
+		"published": r.Published,
+		"createdBy": r.CreatedBy,
+		"createdAt": r.CreatedAt,
+	}
+	if withData {
+		m["data"] = r.Data
+	}
+	return m
+}
+
+func ListRevisionsHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid content ID",
+			})
+		}
+
+		revisions, err := queries.ListRevisions(c.Context(), contentID)
+		if err != nil {
+			logger.Error("Error fetching revisions", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching revisions",
+			})
+		}
+
+		result := make([]fiber.Map, 0, len(revisions))
+		for _, r := range revisions {
+			result = append(result, formatRevision(r, false))
+		}
+
+		return c.JSON(fiber.Map{
+			"count": len(result),
+			"data":  result,
+		})
+	}
+}
+
+func GetRevisionHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid content ID",
+			})
+		}
+
+		revision, err := getRevision(c, queries, contentID, c.Params("version"))
+		if err != nil {
+			return revisionError(c, logger, err)
+		}
+
+		return c.JSON(formatRevision(revision, true))
+	}
+}
+
+// DiffRevisionsHandler compares two versions field by field.
+// ?to defaults to the latest version and ?from to the revision before it.
+func DiffRevisionsHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid content ID",
+			})
+		}
+
+		var to db.ContentRevision
+		if v := c.Query("to"); v != "" {
+			to, err = getRevision(c, queries, contentID, v)
+		} else {
+			to, err = queries.GetLatestRevision(c.Context(), contentID)
+		}
+		if err != nil {
+			return revisionError(c, logger, err)
+		}
+
+		// Version bumps of translations and term renames leave gaps in the history
+		var from db.ContentRevision
+		if v := c.Query("from"); v != "" {
+			from, err = getRevision(c, queries, contentID, v)
+		} else {
+			from, err = queries.GetPreviousRevision(c.Context(), db.GetPreviousRevisionParams{ContentID: contentID, Version: to.Version})
+		}
+		if err != nil {
+			return revisionError(c, logger, err)
+		}
+
+		result := fiber.Map{
+			"from":    from.Version,
+			"to":      to.Version,
+			"changes": utils.DiffData(decodeData(from.Data), decodeData(to.Data)),
+		}
+		if from.Published != to.Published {
+			result["published"] = fiber.Map{"from": from.Published, "to": to.Published}
+		}
+
+		return c.JSON(result)
+	}
+}
+
+// RestoreRevisionHandler saves an old version's data as a new version.
+// The entry keeps its current published state.
+func RestoreRevisionHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid content ID",
+			})
+		}
+
+		content, err := queries.GetContentByID(c.Context(), contentID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Content not found",
+				})
+			}
+			logger.Error("Error fetching content by ID", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch content",
+			})
+		}
+
+		revision, err := getRevision(c, queries, contentID, c.Params("version"))
+		if err != nil {
+			return revisionError(c, logger, err)
+		}
+
+		schema, err := queries.GetSchemaByID(c.Context(), uuid.UUID(content.SchemaID.Bytes))
+		if err != nil {
+			logger.Error("Error fetching schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch schema",
+			})
+		}
+
+		lc, err := loadLocales(c.Context(), queries, "")
+		if err != nil {
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch locales",
+			})
+		}
+
+		expected, err := expectedVersion(c, content, nil)
+		if err != nil {
+			return preconditionFailed(c, content)
+		}
+
+		// The schema may have changed since, so the old data is validated again
+		return updateBase(c, queries, logger, lc, content, schema, decodeData(revision.Data), content.Published.Bool, expected)
+	}
+}
+
+var errBadVersion = errors.New("invalid version")
+
+func getRevision(c *fiber.Ctx, queries *db.Queries, contentID uuid.UUID, version string) (db.ContentRevision, error) {
+	v, err := strconv.ParseInt(version, 10, 32)
+	if err != nil || v < 1 {
+		return db.ContentRevision{}, errBadVersion
+	}
+	return queries.GetRevision(c.Context(), db.GetRevisionParams{ContentID: contentID, Version: int32(v)})
+}
+
+func revisionError(c *fiber.Ctx, logger *zap.Logger, err error) error {
+	switch {
+	case errors.Is(err, errBadVersion):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid version",
+		})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Revision not found",
+		})
+	}
+	logger.Error("Error fetching revision", zap.Error(err))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Error fetching revision",
+	})
+}
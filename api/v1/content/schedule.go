@@ -0,0 +1,204 @@
+package content
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// Entries can carry a pending publish_at and unpublish_at. The scheduler job
+// applies and clears them, see jobs.PublishScheduled.
+
+var errScheduleInPast = errors.New("scheduled times must be in the future")
+var errScheduleClash = errors.New("publish_at and unpublish_at must differ")
+
+// scheduleTimes checks requested times and turns them into column values
+func scheduleTimes(publishAt, unpublishAt *time.Time) (pgtype.Timestamptz, pgtype.Timestamptz, error) {
+	var pub, unpub pgtype.Timestamptz
+	now := time.Now()
+	if publishAt != nil {
+		if !publishAt.After(now) {
+			return pub, unpub, errScheduleInPast
+		}
+		pub = pgtype.Timestamptz{Time: *publishAt, Valid: true}
+	}
+	if unpublishAt != nil {
+		if !unpublishAt.After(now) {
+			return pub, unpub, errScheduleInPast
+		}
+		unpub = pgtype.Timestamptz{Time: *unpublishAt, Valid: true}
+	}
+	if pub.Valid && unpub.Valid && pub.Time.Equal(unpub.Time) {
+		return pub, unpub, errScheduleClash
+	}
+	return pub, unpub, nil
+}
+
+func formatSchedule(content db.Content) fiber.Map {
+	return fiber.Map{
+		"id":          content.ID,
+		"schemaID":    content.SchemaID,
+		"published":   content.Published,
+		"publishAt":   content.PublishAt,
+		"unpublishAt": content.UnpublishAt,
+	}
+}
+
+var errBadContentID = errors.New("invalid content id")
+
+func fetchContent(c *fiber.Ctx, queries *db.Queries) (db.Content, error) {
+	contentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return db.Content{}, errBadContentID
+	}
+	return queries.GetContentByID(c.Context(), contentID)
+}
+
+func contentError(c *fiber.Ctx, logger *zap.Logger, err error) error {
+	switch {
+	case errors.Is(err, errBadContentID):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid content ID",
+		})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Content not found",
+		})
+	}
+	logger.Error("Error fetching content by ID", zap.Error(err))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Could not fetch content",
+	})
+}
+
+// ScheduleContentHandler sets when an entry is published or unpublished.
+// A time left out keeps the one already scheduled.
+func ScheduleContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body struct {
+			PublishAt   *time.Time `json:"publish_at"`
+			UnpublishAt *time.Time `json:"unpublish_at"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid body",
+			})
+		}
+		if body.PublishAt == nil && body.UnpublishAt == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "publish_at or unpublish_at is required",
+			})
+		}
+
+		content, err := fetchContent(c, queries)
+		if err != nil {
+			return contentError(c, logger, err)
+		}
+
+		pub, unpub, err := scheduleTimes(body.PublishAt, body.UnpublishAt)
+		if body.PublishAt == nil {
+			pub = content.PublishAt
+		}
+		if body.UnpublishAt == nil {
+			unpub = content.UnpublishAt
+		}
+		if err == nil && pub.Valid && unpub.Valid && pub.Time.Equal(unpub.Time) {
+			err = errScheduleClash
+		}
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return saveSchedule(c, queries, logger, content.ID, pub, unpub)
+	}
+}
+
+// CancelScheduleHandler drops the pending ?action=publish or unpublish of an
+// entry, or both without ?action
+func CancelScheduleHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		content, err := fetchContent(c, queries)
+		if err != nil {
+			return contentError(c, logger, err)
+		}
+
+		pub, unpub := content.PublishAt, content.UnpublishAt
+		switch c.Query("action") {
+		case "":
+			pub, unpub = pgtype.Timestamptz{}, pgtype.Timestamptz{}
+		case "publish":
+			pub = pgtype.Timestamptz{}
+		case "unpublish":
+			unpub = pgtype.Timestamptz{}
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "action must be publish or unpublish",
+			})
+		}
+
+		return saveSchedule(c, queries, logger, content.ID, pub, unpub)
+	}
+}
+
+func saveSchedule(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger, id uuid.UUID, pub, unpub pgtype.Timestamptz) error {
+	content, err := queries.ScheduleContent(c.Context(), db.ScheduleContentParams{
+		ID:          id,
+		PublishAt:   pub,
+		UnpublishAt: unpub,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Content not found",
+		})
+	}
+	if err != nil {
+		logger.Error("Error scheduling content", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not schedule content",
+		})
+	}
+	return c.JSON(formatSchedule(content))
+}
+
+// ListSchedulesHandler lists entries with a pending action, soonest first,
+// optionally for the schema named in ?schema=
+func ListSchedulesHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var schemaID pgtype.UUID
+		if name := c.Query("schema"); name != "" {
+			schema, err := queries.GetSchemaByName(c.Context(), name)
+			if err != nil {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Schema not found",
+				})
+			}
+			schemaID = pgtype.UUID{Bytes: schema.ID, Valid: true}
+		}
+
+		contents, err := queries.ListScheduledContents(c.Context(), schemaID)
+		if err != nil {
+			logger.Error("Error fetching scheduled contents", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching scheduled contents",
+			})
+		}
+
+		result := make([]fiber.Map, 0, len(contents))
+		for _, content := range contents {
+			result = append(result, formatSchedule(content))
+		}
+
+		return c.JSON(fiber.Map{
+			"count": len(result),
+			"data":  result,
+		})
+	}
+}
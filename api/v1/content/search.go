@@ -0,0 +1,241 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/manthan307/nota-cms/api/v1/auth"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/listquery"
+	"go.uber.org/zap"
+)
+
+const (
+	maxSearchTerms  = 32
+	headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+var searchTerm = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// prefixQuery turns free text into a tsquery that matches every word as a prefix
+func prefixQuery(q string) string {
+	terms := searchTerm.FindAllString(q, maxSearchTerms)
+	for i, t := range terms {
+		terms[i] = t + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+type searchHit struct {
+	Content db.Content
+	Schema  string
+	Locale  string
+	Rank    float32
+	Snippet string
+}
+
+// searchContents ranks documents of the requested locale, falling back to the
+// default locale's document for entries without a translation
+func searchContents(ctx context.Context, pool *pgxpool.Pool, scope listScope, q string, prefix bool, limit, offset int) ([]searchHit, int64, error) {
+	args := &listquery.Args{}
+
+	tsquery := "websearch_to_tsquery"
+	if prefix {
+		tsquery = "to_tsquery"
+		q = prefixQuery(q)
+	}
+	query := args.Add(q)
+	requested := args.Add(scope.Locale.Requested)
+	def := args.Add(scope.Locale.Default)
+
+	inner := fmt.Sprintf(`SELECT contents.*, schemas.name AS schema_name, s.locale, s.body, m.config, m.query,
+			ts_rank_cd(s.document, m.query) AS rank, COUNT(*) OVER() AS total
+		FROM content_search s
+		JOIN (
+			SELECT code, search_config::regconfig AS config, %[1]s(search_config::regconfig, %[2]s) AS query
+			FROM locales WHERE code IN (%[3]s, %[4]s)
+		) m ON m.code = s.locale
+		JOIN contents ON contents.id = s.content_id
+		JOIN schemas ON schemas.id = contents.schema_id AND schemas.deleted_at IS NULL
+		WHERE s.document @@ m.query
+		AND (s.locale = %[3]s OR NOT EXISTS (
+			SELECT 1 FROM content_search x WHERE x.content_id = s.content_id AND x.locale = %[3]s
+		))
+		AND %[5]s
+		ORDER BY rank DESC, contents.id
+		LIMIT %[6]s OFFSET %[7]s`,
+		tsquery, query, requested, def, scope.where(args), args.Add(limit), args.Add(offset))
+
+	// Snippets are only built for the returned page
+	sql := fmt.Sprintf(`SELECT %s, schema_name, locale, rank, ts_headline(config, body, query, %s), total
+		FROM (%s) hits
+		ORDER BY rank DESC, id`, contentColumns, args.Add(headlineOptions), inner)
+
+	rows, err := pool.Query(ctx, sql, args.Values...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var hits []searchHit
+	var total int64
+	for rows.Next() {
+		var h searchHit
+		h.Content, err = scanContent(rows, &h.Schema, &h.Locale, &h.Rank, &h.Snippet, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, total, rows.Err()
+}
+
+// SearchContentHandler runs a full-text search over searchable fields, across
+// every schema or the one named in the path or ?schema=
+func SearchContentHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		q := strings.TrimSpace(c.Query("q"))
+		prefix := c.QueryBool("prefix")
+		if q == "" || (prefix && prefixQuery(q) == "") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Search query 'q' is required",
+			})
+		}
+
+		limit := c.QueryInt("limit", listquery.DefaultLimit)
+		offset := c.QueryInt("offset")
+		if limit <= 0 || limit > listquery.MaxLimit || offset < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("limit must be between 1 and %d and offset not negative", listquery.MaxLimit),
+			})
+		}
+
+		format, err := parseFormat(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		lc, err := loadLocales(c.Context(), queries, c.Query("locale"))
+		if err != nil {
+			if errors.Is(err, errUnknownLocale) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unknown locale",
+				})
+			}
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching locales",
+			})
+		}
+
+		// Anonymous search only covers published entries. Viewers can pick
+		// with ?published=true|false|all and read working data with ?preview=true
+		p := "true"
+		preview := false
+		if auth.HasRole(c, "viewer") {
+			p = c.Query("published", "true")
+			preview = c.QueryBool("preview")
+		}
+		scope := listScope{Published: p, Locale: lc, Preview: p != "true"}
+
+		schemaFields := map[uuid.UUID][]utils.Field{}
+		if name := c.Params("schema_name", c.Query("schema")); name != "" {
+			schema, err := queries.GetSchemaByName(c.Context(), name)
+			if err != nil {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Schema not found",
+				})
+			}
+			scope.SchemaID = schema.ID
+			schemaFields[schema.ID], _ = utils.ParseFields(schema.Definition)
+		}
+
+		hits, total, err := searchContents(c.Context(), pool, scope, q, prefix, limit, offset)
+		if err != nil {
+			logger.Error("Error searching contents", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error searching contents",
+			})
+		}
+
+		ids := make([]uuid.UUID, len(hits))
+		for i, h := range hits {
+			ids[i] = h.Content.ID
+		}
+		allRows, err := queries.GetContentLocalesByContentIDs(c.Context(), ids)
+		if err != nil {
+			logger.Error("Error fetching translations", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error searching contents",
+			})
+		}
+		rowsByContent := map[uuid.UUID][]db.ContentLocale{}
+		for _, r := range allRows {
+			rowsByContent[r.ContentID] = append(rowsByContent[r.ContentID], r)
+		}
+
+		result := []map[string]interface{}{}
+		for _, h := range hits {
+			schemaID := uuid.UUID(h.Content.SchemaID.Bytes)
+			fields, ok := schemaFields[schemaID]
+			if !ok {
+				schema, err := queries.GetSchemaByID(c.Context(), schemaID)
+				if err != nil {
+					logger.Error("Error fetching schema", zap.Error(err))
+					continue
+				}
+				fields, _ = utils.ParseFields(schema.Definition)
+				schemaFields[schemaID] = fields
+			}
+
+			raw := h.Content.Data
+			if preview {
+				raw = workingData(h.Content)
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal(raw, &data); err != nil {
+				logger.Warn("Invalid JSON in content.Data", zap.Error(err))
+				continue
+			}
+			rows := translations(rowsByContent[h.Content.ID])
+			localized, resolved := lc.localize(data, fields, rows, !preview)
+			utils.FormatRichText(fields, localized, format)
+
+			result = append(result, map[string]interface{}{
+				"id":        h.Content.ID,
+				"schemaID":  h.Content.SchemaID,
+				"schema":    h.Schema,
+				"rank":      h.Rank,
+				"snippet":   h.Snippet,
+				"data":      localized,
+				"locale":    lc.Requested,
+				"fallbacks": lc.fallbacks(resolved),
+				"published": lc.isPublished(h.Content, rows),
+				"version":   h.Content.Version,
+				"createdAt": h.Content.CreatedAt,
+				"updatedAt": h.Content.UpdatedAt,
+			})
+		}
+
+		if p != "true" || preview {
+			c.Set(fiber.HeaderCacheControl, "private, no-store")
+		}
+		return c.JSON(fiber.Map{
+			"count":  len(result),
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"data":   result,
+		})
+	}
+}
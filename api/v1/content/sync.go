@@ -0,0 +1,218 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/listquery"
+	"go.uber.org/zap"
+)
+
+// SyncContentsHandler returns the published entries of a schema that changed
+// since ?token, oldest change first. Entries deleted or unpublished since come
+// back as tombstones. The first sync, without a token, returns every published
+// entry; a client pages with nextToken while hasMore and keeps the last one.
+func SyncContentsHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		schema, err := queries.GetSchemaByName(c.Context(), c.Params("schema_name"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Schema not found",
+			})
+		}
+
+		limit := c.QueryInt("limit", listquery.DefaultLimit)
+		if limit < 1 || limit > listquery.MaxLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid limit",
+			})
+		}
+
+		// Tombstones last as long as the trash, an older token could miss purged entries
+		after := utils.SyncToken{Schema: schema.ID}
+		initial := c.Query("token") == ""
+		if !initial {
+			if after, err = utils.ParseSyncToken(c.Query("token"), schema.ID); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			if time.Since(after.Issued) > utils.Retention("CONTENT_RETENTION_DAYS", 30) {
+				return c.Status(fiber.StatusGone).JSON(fiber.Map{
+					"error": "Sync token expired, sync again without a token",
+				})
+			}
+		}
+
+		lc, err := loadLocales(c.Context(), queries, c.Query("locale"))
+		if err != nil {
+			if errors.Is(err, errUnknownLocale) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unknown locale",
+				})
+			}
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching locales",
+			})
+		}
+
+		fields, _ := utils.ParseFields(schema.Definition)
+		opts, err := parseReadOptions(c)
+		if err == nil {
+			err = opts.Fields.Check(fields)
+		}
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		fields = opts.Fields.Fields(fields)
+
+		changes, until, err := listChanges(c.Context(), pool, after, limit+1, opts.Fields)
+		if err != nil {
+			logger.Error("Error fetching content changes", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching contents",
+			})
+		}
+		hasMore := len(changes) > limit
+		if hasMore {
+			changes = changes[:limit]
+		}
+
+		// The next sync starts after the last change of this page, or at the
+		// watermark when nothing changed
+		next := utils.SyncToken{Schema: schema.ID, Xid: until, Issued: time.Now()}
+		if len(changes) > 0 {
+			last := changes[len(changes)-1]
+			next.Xid, next.ID = last.ChangeXid, last.ID
+		}
+
+		ids := make([]uuid.UUID, 0, len(changes))
+		for _, content := range changes {
+			if content.DeletedAt.Valid || !content.Published.Bool {
+				continue
+			}
+			ids = append(ids, content.ID)
+		}
+		allRows, err := getProjectedTranslations(c.Context(), queries, pool, ids, opts.Fields)
+		if err != nil {
+			logger.Error("Error fetching translations", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching contents",
+			})
+		}
+		rowsByContent := map[uuid.UUID][]db.ContentLocale{}
+		for _, r := range allRows {
+			rowsByContent[r.ContentID] = append(rowsByContent[r.ContentID], r)
+		}
+
+		entries := []fiber.Map{}
+		deleted := []fiber.Map{}
+		for _, content := range changes {
+			if content.DeletedAt.Valid || !content.Published.Bool {
+				// a first sync has nothing to remove, and no client
+				// holds an entry that never went live
+				if !initial && content.WasPublished {
+					deleted = append(deleted, tombstone(content))
+				}
+				continue
+			}
+
+			var data map[string]interface{}
+			if err := json.Unmarshal(content.Data, &data); err != nil {
+				logger.Warn("Invalid JSON in content.Data", zap.Error(err))
+				continue
+			}
+			rows := translations(rowsByContent[content.ID])
+			localized, resolved := lc.localize(data, fields, rows, true)
+
+			entries = append(entries, opts.shape(fiber.Map{
+				"id":              content.ID,
+				"schemaID":        content.SchemaID,
+				"data":            opts.format(fields, localized),
+				"locale":          lc.Requested,
+				"fallbacks":       lc.fallbacks(resolved),
+				"completeLocales": lc.completeLocales(data, fields, rows),
+				"published":       lc.isPublished(content, rows),
+				"version":         content.Version,
+				"createdAt":       content.CreatedAt,
+				"updatedAt":       content.UpdatedAt,
+			}))
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"count":     len(entries),
+			"data":      entries,
+			"deleted":   deleted,
+			"hasMore":   hasMore,
+			"nextToken": next.Encode(),
+		})
+	}
+}
+
+// listChanges reads the entries of a schema changed after the token,
+// trashed and unpublished ones included, and the watermark it read up to.
+//
+// Changes are ordered by the id of the transaction that wrote them. Only
+// transactions below the xmin of a fresh snapshot are read: all of them have
+// committed or rolled back, and any later one gets a higher id, so nothing can
+// commit behind a token. A transaction left open holds the watermark back
+// until it ends.
+func listChanges(ctx context.Context, pool *pgxpool.Pool, after utils.SyncToken, limit int, fields *listquery.Projection) ([]db.Content, uint64, error) {
+	var until uint64
+	if err := pool.QueryRow(ctx, "SELECT pg_snapshot_xmin(pg_current_snapshot())").Scan(&until); err != nil {
+		return nil, 0, err
+	}
+
+	args := &listquery.Args{}
+	columns := projectedColumns(contentColumns, fields, args)
+	sql := fmt.Sprintf(`SELECT %s FROM contents
+		WHERE schema_id = %s AND (change_xid, id) > (%s::xid8, %s) AND change_xid < %s::xid8
+		ORDER BY change_xid, id LIMIT %d`,
+		columns,
+		args.Add(pgtype.UUID{Bytes: after.Schema, Valid: true}),
+		args.Add(after.Xid),
+		args.Add(after.ID),
+		args.Add(until),
+		limit)
+
+	rows, err := pool.Query(ctx, sql, args.Values...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var contents []db.Content
+	for rows.Next() {
+		content, err := scanContent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		contents = append(contents, content)
+	}
+	return contents, until, rows.Err()
+}
+
+func tombstone(content db.Content) fiber.Map {
+	reason, at := "unpublished", content.UpdatedAt
+	if content.DeletedAt.Valid {
+		reason, at = "deleted", content.DeletedAt
+	}
+	return fiber.Map{
+		"id":        content.ID,
+		"reason":    reason,
+		"removedAt": at,
+	}
+}
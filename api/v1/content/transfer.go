@@ -0,0 +1,496 @@
+package content
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/transfer"
+	"go.uber.org/zap"
+)
+
+// Exports stream a schema's entries as CSV, NDJSON or JSON. Imports read the
+// same files back and run them as bulk creates and updates, keeping the
+// rejected rows in a report that can be downloaded afterwards.
+
+const exportPageSize = 500
+
+var errBadImportKey = errors.New("key must be id or a non-localized text, number, enum or date field")
+
+// ExportContents writes every entry of a schema with its working data.
+// In another locale the localized fields are resolved along its fallbacks.
+func ExportContents(ctx context.Context, queries *db.Queries, schema db.Schema, format, locale string, w io.Writer) error {
+	lc, err := loadLocales(ctx, queries, locale)
+	if err != nil {
+		return err
+	}
+	fields, err := utils.ParseFields(schema.Definition)
+	if err != nil {
+		return err
+	}
+	out, err := transfer.NewWriter(format, w, fields)
+	if err != nil {
+		return err
+	}
+
+	schemaID := pgtype.UUID{Bytes: schema.ID, Valid: true}
+	var after pgtype.UUID
+	for {
+		page, err := queries.ListSchemaContentsAfter(ctx, db.ListSchemaContentsAfterParams{
+			SchemaID: schemaID,
+			AfterID:  after,
+			PageSize: exportPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, content := range page {
+			data := decodeData(workingData(content))
+			published := content.Published.Bool
+			if !lc.isDefault() {
+				rows, err := getTranslations(ctx, queries, content)
+				if err != nil {
+					return err
+				}
+				published = lc.isPublished(content, rows)
+				data, _ = lc.localize(data, fields, workingRows(rows), false)
+			}
+			if err := out.Write(transfer.Record{
+				ID:        content.ID.String(),
+				Published: &published,
+				Data:      data,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < exportPageSize {
+			return out.Close()
+		}
+		after = pgtype.UUID{Bytes: page[len(page)-1].ID, Valid: true}
+	}
+}
+
+// ImportOptions control how ImportContents matches rows to entries
+type ImportOptions struct {
+	Format string
+	Key    string // "id" (default) or a field whose value identifies the entry
+	Locale string // rows of another locale update translations of existing entries
+	User   pgtype.UUID
+}
+
+// ImportResult counts the rows of an import. Report lists the rejected ones.
+type ImportResult struct {
+	Total      int
+	Created    int
+	Updated    int
+	Rejected   int
+	Report     []byte
+	ReportType string
+}
+
+// ImportContents validates every row and creates or updates its entry.
+// Errors are returned for files that cannot be read at all, single rows are
+// rejected into the report instead.
+func ImportContents(ctx context.Context, queries *db.Queries, logger *zap.Logger, schema db.Schema, opts ImportOptions, r io.Reader) (*ImportResult, error) {
+	fields, err := utils.ParseFields(schema.Definition)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Key == "" {
+		opts.Key = "id"
+	}
+	if opts.Key != "id" && !importKeyField(fields, opts.Key) {
+		return nil, errBadImportKey
+	}
+	lc, err := loadLocales(ctx, queries, opts.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := transfer.NewReader(opts.Format, r, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &importRun{
+		queries:   queries,
+		logger:    logger,
+		schema:    schema,
+		opts:      opts,
+		lc:        lc,
+		seen:      map[string]int{},
+		localized: map[string]bool{},
+		result:    &ImportResult{},
+	}
+	for _, name := range localizedFields(fields) {
+		imp.localized[name] = true
+	}
+	imp.report = transfer.NewReport(opts.Format, &imp.reportBuf, reader.Header())
+
+	var batch []transfer.Row
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		imp.result.Total++
+		if row.Err != nil {
+			imp.reject(row, row.Err.Error())
+			continue
+		}
+		batch = append(batch, row)
+		if len(batch) == bulkBatchSize {
+			if err := imp.apply(ctx, batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := imp.apply(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	if imp.result.Rejected > 0 {
+		if err := imp.report.Close(); err != nil {
+			return nil, err
+		}
+		imp.result.Report = imp.reportBuf.Bytes()
+		imp.result.ReportType = imp.report.ContentType()
+	}
+	return imp.result, nil
+}
+
+// importKeyField reports whether a field can identify entries on import
+func importKeyField(fields []utils.Field, name string) bool {
+	for _, f := range fields {
+		if f.Name != name {
+			continue
+		}
+		elem, isArray := f.ElemType()
+		return !isArray && !f.Localized && (elem == "text" || elem == "number" || elem == "enum" || elem == "date")
+	}
+	return false
+}
+
+type importRun struct {
+	queries   *db.Queries
+	logger    *zap.Logger
+	schema    db.Schema
+	opts      ImportOptions
+	lc        *localeContext
+	seen      map[string]int // key value to the row that used it
+	localized map[string]bool
+	result    *ImportResult
+	report    *transfer.Report
+	reportBuf bytes.Buffer
+}
+
+func (imp *importRun) reject(row transfer.Row, message string) {
+	imp.result.Rejected++
+	if err := imp.report.Add(row, message); err != nil {
+		imp.logger.Error("Error writing import report", zap.Error(err))
+	}
+}
+
+// apply resolves the rows of a batch to entries and writes them as one bulk job
+func (imp *importRun) apply(ctx context.Context, rows []transfer.Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		if imp.opts.Key == "id" {
+			keys[i] = row.Record.ID
+		} else if v, ok := row.Record.Data[imp.opts.Key]; ok {
+			keys[i] = keyText(v)
+		}
+	}
+
+	// Entries matched by a field value, more than one match is ambiguous.
+	// Ids that do not exist yet are created with that id.
+	matches := map[string][]uuid.UUID{}
+	if imp.opts.Key == "id" {
+		var ids []uuid.UUID
+		for _, key := range keys {
+			if id, err := uuid.Parse(key); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		found, err := imp.queries.GetContentsByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, content := range found {
+			matches[content.ID.String()] = []uuid.UUID{content.ID}
+		}
+	} else {
+		found, err := imp.queries.FindContentsByField(ctx, db.FindContentsByFieldParams{
+			Field:     imp.opts.Key,
+			SchemaID:  pgtype.UUID{Bytes: imp.schema.ID, Valid: true},
+			KeyValues: keys,
+		})
+		if err != nil {
+			return err
+		}
+		for _, m := range found {
+			matches[m.KeyValue] = append(matches[m.KeyValue], m.ID)
+		}
+	}
+
+	var ops []bulkOperation
+	var applied []transfer.Row
+	for i, row := range rows {
+		key := keys[i]
+		if imp.opts.Key != "id" && key == "" {
+			imp.reject(row, fmt.Sprintf("missing key field %q", imp.opts.Key))
+			continue
+		}
+		if imp.opts.Key == "id" && key != "" {
+			id, err := uuid.Parse(key)
+			if err != nil {
+				imp.reject(row, "Invalid content ID")
+				continue
+			}
+			key = id.String()
+		}
+		if key != "" {
+			if first, dup := imp.seen[key]; dup {
+				imp.reject(row, fmt.Sprintf("duplicate key %q, already on row %d", key, first))
+				continue
+			}
+			imp.seen[key] = row.Number
+		}
+
+		op := bulkOperation{
+			Op:        "update",
+			SchemaID:  imp.schema.ID.String(),
+			Data:      row.Record.Data,
+			Published: row.Record.Published,
+			Locale:    imp.lc.Requested,
+		}
+		switch ids := matches[key]; len(ids) {
+		case 0:
+			if !imp.lc.isDefault() {
+				imp.reject(row, "Content not found, translations need an existing entry")
+				continue
+			}
+			op.Op = "create"
+			if imp.opts.Key == "id" {
+				op.ID = key
+			}
+		case 1:
+			op.ID = ids[0].String()
+		default:
+			imp.reject(row, fmt.Sprintf("key %q matches %d entries", key, len(ids)))
+			continue
+		}
+		if !imp.lc.isDefault() {
+			// Exports of a locale carry every field, only the localized ones are translated
+			for name := range op.Data {
+				if !imp.localized[name] {
+					delete(op.Data, name)
+				}
+			}
+		}
+		ops = append(ops, op)
+		applied = append(applied, row)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	job := &bulkJob{
+		queries: imp.queries,
+		logger:  imp.logger,
+		user:    imp.opts.User,
+		ops:     ops,
+	}
+	if status, body := job.run(ctx); status != fiber.StatusOK {
+		return fmt.Errorf("import batch failed: %v", body["error"])
+	}
+	for i, res := range job.results {
+		switch {
+		case !res.OK:
+			imp.reject(applied[i], res.Error)
+		case res.Op == "create":
+			imp.result.Created++
+		default:
+			imp.result.Updated++
+		}
+	}
+	return nil
+}
+
+// keyText formats a key value the way Postgres' ->> prints it
+func keyText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// ExportContentsHandler streams a schema's entries as ?format=csv, ndjson
+// (default) or json, optionally in another ?locale
+func ExportContentsHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		format := c.Query("format", transfer.NDJSON)
+		if !transfer.ValidFormat(format) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "format must be csv, ndjson or json",
+			})
+		}
+		schema, err := queries.GetSchemaByName(c.Context(), c.Params("schema_name"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Schema not found",
+			})
+		}
+		locale := c.Query("locale")
+		if _, err := loadLocales(c.Context(), queries, locale); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Unknown locale",
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, transfer.ContentType(format))
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, schema.Name, format))
+		// The writer runs after the handler returned, so it must not touch c
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			if err := ExportContents(context.Background(), queries, schema, format, locale, w); err != nil {
+				logger.Error("Error exporting contents", zap.String("schema", schema.Name), zap.Error(err))
+			}
+			_ = w.Flush()
+		})
+		return nil
+	}
+}
+
+// ImportContentsHandler imports the request body as ?format=csv, ndjson or
+// json, matching entries on ?key=id (default) or a field, in an optional ?locale
+func ImportContentsHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		format := c.Query("format", transfer.NDJSON)
+		if !transfer.ValidFormat(format) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "format must be csv, ndjson or json",
+			})
+		}
+		schema, err := queries.GetSchemaByName(c.Context(), c.Params("schema_name"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Schema not found",
+			})
+		}
+
+		result, err := ImportContents(c.Context(), queries, logger, schema, ImportOptions{
+			Format: format,
+			Key:    c.Query("key"),
+			Locale: c.Query("locale"),
+			User:   currentUser(c),
+		}, bytes.NewReader(c.Body()))
+		switch {
+		case errors.Is(err, errUnknownLocale):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Unknown locale",
+			})
+		case errors.Is(err, errBadImportKey):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case err != nil:
+			// Unreadable files are the client's, everything else is logged
+			logger.Warn("Import failed", zap.String("schema", schema.Name), zap.Error(err))
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Could not import file: " + err.Error(),
+			})
+		}
+
+		imported, err := queries.CreateContentImport(c.Context(), db.CreateContentImportParams{
+			SchemaID:   schema.ID,
+			Format:     format,
+			Total:      int32(result.Total),
+			Created:    int32(result.Created),
+			Updated:    int32(result.Updated),
+			Rejected:   int32(result.Rejected),
+			Report:     result.Report,
+			ReportType: pgtype.Text{String: result.ReportType, Valid: result.ReportType != ""},
+			CreatedBy:  currentUser(c),
+		})
+		if err != nil {
+			logger.Error("Error saving import", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not save import report",
+			})
+		}
+
+		resp := fiber.Map{
+			"id":       imported.ID,
+			"total":    result.Total,
+			"created":  result.Created,
+			"updated":  result.Updated,
+			"rejected": result.Rejected,
+		}
+		if result.Rejected > 0 {
+			resp["report"] = "/api/v1/content/imports/" + imported.ID.String() + "/report"
+		}
+		return c.JSON(resp)
+	}
+}
+
+// ImportReportHandler downloads the rejected rows of an import
+func ImportReportHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid import ID",
+			})
+		}
+		imported, err := queries.GetContentImport(c.Context(), id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Import not found",
+			})
+		}
+		if err != nil {
+			logger.Error("Error fetching import", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch import",
+			})
+		}
+		if imported.Report == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Every row of this import was imported",
+			})
+		}
+
+		ext := transfer.CSV
+		if imported.Format != transfer.CSV {
+			ext = transfer.NDJSON
+		}
+		c.Set(fiber.HeaderContentType, imported.ReportType.String)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="import-%s-errors.%s"`, imported.ID, ext))
+		return c.Send(imported.Report)
+	}
+}
@@ -0,0 +1,195 @@
+package content
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/listquery"
+	"github.com/manthan307/nota-cms/utils/searchindex"
+	"go.uber.org/zap"
+)
+
+// Deleted entries wait in the trash until they are restored, purged by hand
+// or removed by the purge job after CONTENT_RETENTION_DAYS. Entries trashed
+// together with their schema come back with it, see schemas.RestoreSchema.
+
+// ListDeletedContentsHandler lists a schema's trashed entries, latest first
+func ListDeletedContentsHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		schema, err := queries.GetSchemaByName(c.Context(), c.Params("schema_name"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Schema not found",
+			})
+		}
+
+		limit := c.QueryInt("limit", 100)
+		offset := c.QueryInt("offset", 0)
+		if limit < 1 || limit > listquery.MaxLimit || offset < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid limit or offset",
+			})
+		}
+
+		schemaID := pgtype.UUID{Bytes: schema.ID, Valid: true}
+		contents, err := queries.ListDeletedContents(c.Context(), db.ListDeletedContentsParams{
+			SchemaID:   schemaID,
+			PageSize:   int32(limit),
+			PageOffset: int32(offset),
+		})
+		if err != nil {
+			logger.Error("Error fetching deleted contents", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching deleted contents",
+			})
+		}
+		total, err := queries.CountDeletedContents(c.Context(), schemaID)
+		if err != nil {
+			logger.Error("Error counting deleted contents", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error fetching deleted contents",
+			})
+		}
+
+		result := make([]fiber.Map, 0, len(contents))
+		for _, content := range contents {
+			result = append(result, fiber.Map{
+				"id":        content.ID,
+				"data":      workingData(content),
+				"published": content.Published,
+				"version":   content.Version,
+				"updatedAt": content.UpdatedAt,
+				"deletedAt": content.DeletedAt,
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"count":  len(result),
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"data":   result,
+		})
+	}
+}
+
+// RestoreContentHandler takes an entry out of the trash. Its data is validated
+// against the current schema and it always comes back unpublished.
+func RestoreContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid content ID",
+			})
+		}
+
+		content, err := queries.GetDeletedContentByID(c.Context(), contentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Content not found in trash",
+			})
+		}
+		if err != nil {
+			logger.Error("Error fetching deleted content", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch content",
+			})
+		}
+
+		schema, err := queries.GetSchemaByID(c.Context(), uuid.UUID(content.SchemaID.Bytes))
+		if err != nil {
+			logger.Error("Error fetching schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch schema",
+			})
+		}
+
+		// The schema may have changed while the entry was in the trash
+		if err := validateData(c.Context(), queries, schema, decodeData(workingData(content))); err != nil {
+			return validationError(c, err)
+		}
+
+		expected, err := expectedVersion(c, content, nil)
+		if err != nil {
+			return preconditionFailed(c, content)
+		}
+
+		row, err := queries.RestoreContent(c.Context(), db.RestoreContentParams{
+			ID:              content.ID,
+			ExpectedVersion: expected,
+			Author:          currentUser(c),
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": "Content was changed by someone else, reload it and try again",
+			})
+		}
+		if field, ok := uniqueViolation(err); ok {
+			return uniqueConflict(c, field)
+		}
+		if err != nil {
+			logger.Error("Error restoring content", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not restore content",
+			})
+		}
+		restored := db.Content(row)
+
+		// Deleting dropped the entry's search documents
+		def, err := queries.GetDefaultLocale(c.Context())
+		if err == nil {
+			fields, _ := utils.ParseFields(schema.Definition)
+			err = searchindex.Index(c.Context(), queries, restored, fields, def.Code)
+		}
+		if err != nil {
+			logger.Error("Error indexing content for search", zap.Error(err))
+		}
+
+		setVersion(c, restored)
+		return c.JSON(fiber.Map{
+			"id":        restored.ID,
+			"schemaID":  restored.SchemaID,
+			"data":      restored.Data,
+			"published": restored.Published,
+			"version":   restored.Version,
+			"createdAt": restored.CreatedAt,
+			"updatedAt": restored.UpdatedAt,
+		})
+	}
+}
+
+// PurgeContentHandler permanently deletes a trashed entry with its
+// translations and revisions
+func PurgeContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid content ID",
+			})
+		}
+
+		n, err := queries.PurgeContent(c.Context(), contentID)
+		if err != nil {
+			logger.Error("Error purging content", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not delete content",
+			})
+		}
+		if n == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Content not found in trash",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Content permanently deleted",
+		})
+	}
+}
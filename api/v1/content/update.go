@@ -1,22 +1,30 @@
 package content
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	db "github.com/manthan307/nota-cms/db/output"
 	"github.com/manthan307/nota-cms/utils"
+	"github.com/manthan307/nota-cms/utils/searchindex"
 	"go.uber.org/zap"
 )
 
-func UpdateContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+func UpdateContentHandler(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var body struct {
 			ContentID string                 `json:"content_id"`
 			Data      map[string]interface{} `json:"data"`
-			Published bool                   `json:"published"`
+			Published *bool                  `json:"published"` // unchanged when left out
+			Locale    string                 `json:"locale"`
+			Version   *int32                 `json:"version"` // expected version, like If-Match
 		}
 
 		if err := c.BodyParser(&body); err != nil {
@@ -59,46 +67,293 @@ func UpdateContentHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			})
 		}
 
-		// Validate data with schema
-		ok, err := utils.CompareSchemaWithData(schema.Definition, body.Data)
-		if !ok {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Data does not match schema: " + err.Error(),
+		lc, err := loadLocales(c.Context(), queries, body.Locale)
+		if err != nil {
+			if errors.Is(err, errUnknownLocale) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unknown locale",
+				})
+			}
+			logger.Error("Error fetching locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch locales",
 			})
 		}
 
-		// Marshal JSON
-		dataBytes, err := json.Marshal(body.Data)
+		expected, err := expectedVersion(c, content, body.Version)
 		if err != nil {
+			return preconditionFailed(c, content)
+		}
+
+		// Other locales only store their localized fields
+		if !lc.isDefault() {
+			rows, err := getTranslations(c.Context(), queries, content)
+			if err != nil {
+				logger.Error("Error fetching translations", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Could not fetch content",
+				})
+			}
+			published := lc.isPublished(content, rows)
+			if body.Published != nil {
+				published = *body.Published
+			}
+			return updateTranslation(c, queries, logger, pool, lc, content, schema, body.Data, published, expected)
+		}
+
+		published := content.Published.Bool
+		if body.Published != nil {
+			published = *body.Published
+		}
+		return updateBase(c, queries, logger, lc, content, schema, body.Data, published, expected)
+	}
+}
+
+// updateBase validates and stores the default locale's data of an entry.
+// Edits to an entry that stays published are saved as its draft.
+// A valid expected version makes the write fail with 412 if the entry moved on.
+func updateBase(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger, lc *localeContext, content db.Content, schema db.Schema, data map[string]interface{}, published bool, expected pgtype.Int4) error {
+	draft := content.Published.Bool && published
+
+	// Validate data with schema
+	if err := validateData(c.Context(), queries, schema, data); err != nil {
+		return validationError(c, err)
+	}
+
+	// Marshal JSON
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not encode JSON",
+		})
+	}
+	if published && !draft {
+		if reason := workflowBlocks(schema, stateAfter(schema, content, dataBytes)); reason != nil {
+			return c.Status(fiber.StatusConflict).JSON(reason)
+		}
+	}
+
+	// UPDATE Content
+	var updated db.Content
+	if draft {
+		field, checkErr := checkUnique(c.Context(), queries, schema, content.ID, dataBytes)
+		if checkErr != nil {
+			logger.Error("Error checking unique fields", zap.Error(checkErr))
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Could not encode JSON",
+				"error": "Could not update content",
 			})
 		}
-
-		// UPDATE Content
-		updated, err := queries.UpdateContent(c.Context(), db.UpdateContentParams{
+		if field != "" {
+			return uniqueConflict(c, field)
+		}
+		var row db.SaveContentDraftRow
+		row, err = queries.SaveContentDraft(c.Context(), db.SaveContentDraftParams{
+			ID:              content.ID,
+			DraftData:       dataBytes,
+			ExpectedVersion: expected,
+			Author:          currentUser(c),
+		})
+		updated = db.Content(row)
+	} else {
+		var row db.UpdateContentRow
+		row, err = queries.UpdateContent(c.Context(), db.UpdateContentParams{
 			ID:   content.ID,
 			Data: dataBytes,
 			Published: pgtype.Bool{
-				Bool:  body.Published,
+				Bool:  published,
 				Valid: true,
 			},
+			ExpectedVersion: expected,
+			Author:          currentUser(c),
 		})
+		updated = db.Content(row)
+	}
 
-		if err != nil {
-			logger.Error("Error updating content", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Could not update content",
+	if errors.Is(err, pgx.ErrNoRows) {
+		return versionConflict(c, queries, logger, content.ID)
+	}
+	if field, ok := uniqueViolation(err); ok {
+		return uniqueConflict(c, field)
+	}
+	if err != nil {
+		logger.Error("Error updating content", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not update content",
+		})
+	}
+
+	// Translations reuse non-localized base values, so every locale is reindexed.
+	// Search only covers live data.
+	if !draft {
+		fields, _ := utils.ParseFields(schema.Definition)
+		if err := searchindex.Index(c.Context(), queries, updated, fields, lc.Default); err != nil {
+			logger.Error("Error indexing content for search", zap.Error(err))
+		}
+	}
+
+	setVersion(c, updated)
+	return c.Status(200).JSON(fiber.Map{
+		"id":        updated.ID,
+		"schemaID":  updated.SchemaID,
+		"data":      workingData(updated),
+		"published": updated.Published,
+		"hasDraft":  draft,
+		"version":   updated.Version,
+		"locale":    lc.Requested,
+		"createdAt": updated.CreatedAt,
+		"updatedAt": updated.UpdatedAt,
+	})
+}
+
+// updateTranslation stores the localized fields of an entry for a non-default locale,
+// as a draft while the entry is published
+func updateTranslation(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool, lc *localeContext, content db.Content, schema db.Schema, data map[string]interface{}, published bool, expected pgtype.Int4) error {
+	fields, err := utils.ParseFields(schema.Definition)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Invalid schema definition",
+		})
+	}
+
+	localized := map[string]bool{}
+	for _, name := range localizedFields(fields) {
+		localized[name] = true
+	}
+	for key := range data {
+		if !localized[key] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("field %q is not localized and can only be set in the default locale (%s)", key, lc.Default),
 			})
 		}
+	}
+
+	// The translation must still satisfy the schema once merged with the base entry
+	base := decodeData(workingData(content))
+	merged := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	if err := validateData(c.Context(), queries, schema, merged); err != nil {
+		return validationError(c, err)
+	}
+	// keep the rich text parsed along the way
+	for k := range data {
+		data[k] = merged[k]
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not encode JSON",
+		})
+	}
+
+	draft := content.Published.Bool
+	bumped, row, err := saveTranslation(c.Context(), pool, queries, content, lc.Requested, dataBytes, published, expected)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return versionConflict(c, queries, logger, content.ID)
+	}
+	if err != nil {
+		logger.Error("Error updating translation", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not update content",
+		})
+	}
+
+	rows, err := getTranslations(c.Context(), queries, content)
+	if err != nil {
+		logger.Error("Error fetching translations", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not fetch content",
+		})
+	}
+	rows = workingRows(rows)
+	view, resolved := lc.localize(base, fields, rows, false)
+
+	if !draft {
+		if err := searchindex.Index(c.Context(), queries, content, fields, lc.Default); err != nil {
+			logger.Error("Error indexing content for search", zap.Error(err))
+		}
+	}
+
+	setVersion(c, bumped)
+	return c.Status(200).JSON(fiber.Map{
+		"id":              content.ID,
+		"schemaID":        content.SchemaID,
+		"data":            view,
+		"published":       row.Published,
+		"hasDraft":        hasDraft(bumped, rows),
+		"version":         bumped.Version,
+		"locale":          lc.Requested,
+		"fallbacks":       lc.fallbacks(resolved),
+		"completeLocales": lc.completeLocales(base, fields, rows),
+		"createdAt":       content.CreatedAt,
+		"updatedAt":       row.UpdatedAt,
+	})
+}
 
-		return c.Status(200).JSON(fiber.Map{
-			"id":        updated.ID,
-			"schemaID":  updated.SchemaID,
-			"data":      updated.Data,
-			"published": updated.Published,
-			"createdAt": updated.CreatedAt,
-			"updatedAt": updated.UpdatedAt,
+// versionConflict answers a failed compare-and-swap, the entry either moved on or is gone
+func versionConflict(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger, id uuid.UUID) error {
+	current, err := queries.GetContentByID(c.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Content not found",
+		})
+	}
+	if err != nil {
+		logger.Error("Error fetching content by ID", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not fetch content",
+		})
+	}
+	return preconditionFailed(c, current)
+}
+
+// saveTranslation bumps the entry's version, which translations share, and
+// stores the translation in the same transaction, see writeTranslation
+func saveTranslation(ctx context.Context, pool *pgxpool.Pool, queries *db.Queries, content db.Content, locale string, data []byte, published bool, expected pgtype.Int4) (db.Content, db.ContentLocale, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return content, db.ContentLocale{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	bumped, row, err := writeTranslation(ctx, queries.WithTx(tx), content, locale, data, published, expected)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	return bumped, row, err
+}
+
+// writeTranslation bumps the entry's version and stores the translation with
+// q, which must run in a transaction. While the entry is published the
+// translation is saved as a draft. A version conflict returns pgx.ErrNoRows.
+func writeTranslation(ctx context.Context, q *db.Queries, content db.Content, locale string, data []byte, published bool, expected pgtype.Int4) (db.Content, db.ContentLocale, error) {
+	var row db.ContentLocale
+	bumped, err := q.BumpContentVersion(ctx, db.BumpContentVersionParams{
+		ID:              content.ID,
+		ExpectedVersion: expected,
+	})
+	if err != nil {
+		return content, row, err
+	}
+	if content.Published.Bool {
+		row, err = q.UpsertContentLocaleDraft(ctx, db.UpsertContentLocaleDraftParams{
+			ContentID:      content.ID,
+			Locale:         locale,
+			DraftData:      data,
+			DraftPublished: published,
+		})
+	} else {
+		row, err = q.UpsertContentLocale(ctx, db.UpsertContentLocaleParams{
+			ContentID: content.ID,
+			Locale:    locale,
+			Data:      data,
+			Published: published,
 		})
 	}
+	return bumped, row, err
 }
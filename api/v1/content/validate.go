@@ -0,0 +1,107 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+)
+
+// validateData checks data against the schema definition, the terms of its
+// taxonomies and the schema's rules. Rich text given as Markdown or HTML is
+// replaced with its document on the way.
+func validateData(ctx context.Context, queries *db.Queries, schema db.Schema, data map[string]interface{}) error {
+	fields, err := utils.ParseFields(schema.Definition)
+	if err != nil {
+		return err
+	}
+	if err := utils.NormalizeRichText(fields, data); err != nil {
+		return err
+	}
+	if ok, err := utils.CompareSchemaWithData(schema.Definition, data); !ok {
+		return err
+	}
+	if err := checkTerms(ctx, queries, fields, data); err != nil {
+		return err
+	}
+	return utils.ValidateRules(schema.Rules, data)
+}
+
+// checkTerms makes sure taxonomy fields only hold terms of their taxonomy
+func checkTerms(ctx context.Context, queries *db.Queries, fields []utils.Field, data map[string]interface{}) error {
+	terms := utils.TaxonomyTerms(fields, data)
+	for _, taxonomy := range slices.Sorted(maps.Keys(terms)) {
+		missing, err := queries.FindMissingTerms(ctx, db.FindMissingTermsParams{
+			Slugs:    terms[taxonomy],
+			Taxonomy: taxonomy,
+		})
+		if err != nil {
+			return fmt.Errorf("could not check the terms of taxonomy %q: %w", taxonomy, err)
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%q is not a term of taxonomy %q", missing[0], taxonomy)
+		}
+	}
+	return nil
+}
+
+// validationError renders a failed validateData, naming the failed rule and fields when there is one
+func validationError(c *fiber.Ctx, err error) error {
+	resp := fiber.Map{"error": "Data does not match schema: " + err.Error()}
+
+	var ruleErr *utils.RuleError
+	if errors.As(err, &ruleErr) {
+		resp["rule"] = ruleErr.Rule
+		resp["fields"] = ruleErr.Fields
+	}
+
+	return c.Status(fiber.StatusBadRequest).JSON(resp)
+}
+
+// uniqueViolation reports the unique field a failed write reused a value of,
+// as raised by the content_unique_values triggers
+func uniqueViolation(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.TableName == "content_unique_values" {
+		return pgErr.ColumnName, true
+	}
+	return "", false
+}
+
+func uniqueMessage(field string) string {
+	return fmt.Sprintf("Value of unique field %q is already in use", field)
+}
+
+func uniqueConflict(c *fiber.Ctx, field string) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error": uniqueMessage(field),
+		"field": field,
+	})
+}
+
+// checkUnique catches unique values that only fail once a draft goes live
+func checkUnique(ctx context.Context, queries *db.Queries, schema db.Schema, contentID uuid.UUID, data []byte) (string, error) {
+	fields, _ := utils.ParseFields(schema.Definition)
+	names := utils.UniqueFields(fields)
+	if len(names) == 0 {
+		return "", nil
+	}
+	field, err := queries.FindUniqueConflict(ctx, db.FindUniqueConflictParams{
+		Fields:    names,
+		SchemaID:  schema.ID,
+		Data:      data,
+		ContentID: contentID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return field, err
+}
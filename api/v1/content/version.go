@@ -0,0 +1,62 @@
+package content
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+)
+
+// Entries carry a version that every write bumps. Clients send it back through
+// If-Match (the ETag of a read) or a "version" field, and writes fail with 412
+// when the entry changed in the meantime.
+
+var errPreconditionFailed = errors.New("precondition failed")
+
+// etag is the strong validator of an entry version
+func etag(version int32) string {
+	return `"` + strconv.Itoa(int(version)) + `"`
+}
+
+func setVersion(c *fiber.Ctx, content db.Content) {
+	c.Set(fiber.HeaderETag, etag(content.Version))
+}
+
+// expectedVersion checks If-Match and the optional body version against the
+// entry. It returns the version to compare-and-swap on, or an invalid value
+// when the client sent no precondition.
+func expectedVersion(c *fiber.Ctx, content db.Content, bodyVersion *int32) (pgtype.Int4, error) {
+	var tags []string
+	if h := c.Get(fiber.HeaderIfMatch); h != "" {
+		tags = strings.Split(h, ",")
+	}
+	if bodyVersion != nil {
+		tags = append(tags, etag(*bodyVersion))
+	}
+	if len(tags) == 0 {
+		return pgtype.Int4{}, nil
+	}
+
+	// If-Match compares strongly, a weak tag never matches
+	current := etag(content.Version)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return pgtype.Int4{Int32: content.Version, Valid: true}, nil
+		}
+	}
+	return pgtype.Int4{}, errPreconditionFailed
+}
+
+// preconditionFailed reports the version the server has now
+func preconditionFailed(c *fiber.Ctx, content db.Content) error {
+	setVersion(c, content)
+	return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+		"error":     "Content was changed by someone else, reload it and try again",
+		"version":   content.Version,
+		"updatedAt": content.UpdatedAt,
+	})
+}
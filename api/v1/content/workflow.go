@@ -0,0 +1,300 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/manthan307/nota-cms/api/v1/auth"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+// Schemas with settings.workflow move their entries through review states.
+// Entries cannot go live, by publishing their draft or by writing an
+// unpublished entry as published, until they reach a publishFrom state.
+
+func schemaWorkflow(schema db.Schema) *utils.WorkflowPolicy {
+	settings, _ := utils.ParseSettings(schema.Settings)
+	return settings.Workflow
+}
+
+// workflowBlocks returns why an entry in state may not be published, or nil
+func workflowBlocks(schema db.Schema, state pgtype.Text) fiber.Map {
+	w := schemaWorkflow(schema)
+	if w == nil || w.CanPublish(w.State(state.String)) {
+		return nil
+	}
+	return fiber.Map{
+		"error":       "Content must pass review before it is published",
+		"state":       w.State(state.String),
+		"publishFrom": w.PublishFrom,
+	}
+}
+
+// stateAfter is the workflow state a write of data leaves an entry in. Edits
+// reset it, in the same statement that stores them, see UpdateContent.
+func stateAfter(schema db.Schema, content db.Content, data []byte) pgtype.Text {
+	w := schemaWorkflow(schema)
+	if w == nil {
+		return content.WorkflowState
+	}
+	state := w.Edited(content.WorkflowState.String, workingData(content), data)
+	if state == w.State(content.WorkflowState.String) {
+		return content.WorkflowState
+	}
+	return pgtype.Text{}
+}
+
+// loadWorkflow fetches the entry of the :id param and its schema's workflow.
+// A nil policy means the error response was already sent.
+func loadWorkflow(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger) (db.Content, *utils.WorkflowPolicy, error) {
+	content, err := fetchContent(c, queries)
+	if err != nil {
+		return content, nil, contentError(c, logger, err)
+	}
+	schema, err := queries.GetSchemaByID(c.Context(), uuid.UUID(content.SchemaID.Bytes))
+	if err != nil {
+		logger.Error("Error fetching schema", zap.Error(err))
+		return content, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Could not fetch schema",
+		})
+	}
+	w := schemaWorkflow(schema)
+	if w == nil {
+		return content, nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Schema has no workflow",
+		})
+	}
+	return content, w, nil
+}
+
+// GetWorkflowHandler shows an entry's state, the transitions open to the
+// signed in user, its assignees and the transition history
+func GetWorkflowHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		content, w, err := loadWorkflow(c, queries, logger)
+		if w == nil {
+			return err
+		}
+
+		state := w.State(content.WorkflowState.String)
+		transitions := []utils.WorkflowTransition{}
+		for _, t := range w.Transitions {
+			if t.From == state && auth.HasRole(c, t.Role) {
+				transitions = append(transitions, t)
+			}
+		}
+
+		assignees, err := queries.ListContentAssignees(c.Context(), content.ID)
+		if err != nil {
+			logger.Error("Error fetching assignees", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch workflow",
+			})
+		}
+		events, err := queries.ListWorkflowEvents(c.Context(), content.ID)
+		if err != nil {
+			logger.Error("Error fetching workflow events", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not fetch workflow",
+			})
+		}
+
+		history := make([]fiber.Map, 0, len(events))
+		for _, e := range events {
+			history = append(history, fiber.Map{
+				"from":      e.FromState,
+				"to":        e.ToState,
+				"comment":   e.Comment,
+				"createdBy": e.CreatedBy,
+				"createdAt": e.CreatedAt,
+			})
+		}
+		if assignees == nil {
+			assignees = []uuid.UUID{}
+		}
+
+		return c.JSON(fiber.Map{
+			"id":          content.ID,
+			"state":       state,
+			"canPublish":  w.CanPublish(state),
+			"published":   content.Published,
+			"transitions": transitions,
+			"assignees":   assignees,
+			"history":     history,
+		})
+	}
+}
+
+// TransitionWorkflowHandler moves an entry to another state, if the schema
+// allows it for the user's role, and notifies the entry's assignees
+func TransitionWorkflowHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body struct {
+			To      string `json:"to"`
+			Comment string `json:"comment"`
+		}
+		if err := c.BodyParser(&body); err != nil || body.To == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Target state 'to' is required",
+			})
+		}
+
+		content, w, err := loadWorkflow(c, queries, logger)
+		if w == nil {
+			return err
+		}
+
+		from := w.State(content.WorkflowState.String)
+		t, ok := w.Transition(from, body.To)
+		if !ok {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": fmt.Sprintf("Cannot move from %q to %q", from, body.To),
+				"state": from,
+			})
+		}
+		if !auth.HasRole(c, t.Role) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": fmt.Sprintf("Moving to %q needs the %s role", body.To, t.Role),
+			})
+		}
+
+		// The stored state is compared so concurrent transitions cannot both apply
+		_, err = queries.SetWorkflowState(c.Context(), db.SetWorkflowStateParams{
+			ToState:   body.To,
+			ID:        content.ID,
+			FromState: content.WorkflowState,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Workflow state was changed by someone else, reload it and try again",
+			})
+		}
+		if err != nil {
+			logger.Error("Error updating workflow state", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not update workflow state",
+			})
+		}
+
+		event, err := queries.CreateWorkflowEvent(c.Context(), db.CreateWorkflowEventParams{
+			ContentID: content.ID,
+			FromState: from,
+			ToState:   body.To,
+			Comment:   body.Comment,
+			CreatedBy: currentUser(c),
+		})
+		if err != nil {
+			logger.Error("Error saving workflow event", zap.Error(err))
+		}
+
+		message := fmt.Sprintf("Entry %s moved from %s to %s", content.ID, from, body.To)
+		if body.Comment != "" {
+			message += ": " + body.Comment
+		}
+		if err := queries.NotifyAssignees(c.Context(), db.NotifyAssigneesParams{
+			Message:   message,
+			ContentID: content.ID,
+			ActorID:   currentUser(c),
+		}); err != nil {
+			logger.Error("Error notifying assignees", zap.Error(err))
+		}
+
+		return c.JSON(fiber.Map{
+			"id":         content.ID,
+			"state":      body.To,
+			"previous":   from,
+			"comment":    body.Comment,
+			"canPublish": w.CanPublish(body.To),
+			"createdAt":  event.CreatedAt,
+		})
+	}
+}
+
+// SetAssigneesHandler replaces the users notified about an entry's transitions
+func SetAssigneesHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body struct {
+			UserIDs []uuid.UUID `json:"user_ids"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid body",
+			})
+		}
+
+		content, w, err := loadWorkflow(c, queries, logger)
+		if w == nil {
+			return err
+		}
+
+		for _, id := range body.UserIDs {
+			exists, err := queries.UserExists(c.Context(), id)
+			if err != nil {
+				logger.Error("Error checking user", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Could not update assignees",
+				})
+			}
+			if !exists {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("User %s does not exist", id),
+				})
+			}
+		}
+
+		previous, err := queries.ListContentAssignees(c.Context(), content.ID)
+		if err != nil {
+			logger.Error("Error fetching assignees", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not update assignees",
+			})
+		}
+
+		if err := queries.DeleteContentAssignees(c.Context(), content.ID); err != nil {
+			logger.Error("Error updating assignees", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not update assignees",
+			})
+		}
+		if err := queries.AddContentAssignees(c.Context(), db.AddContentAssigneesParams{
+			ContentID: content.ID,
+			UserIds:   body.UserIDs,
+		}); err != nil {
+			logger.Error("Error updating assignees", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Could not update assignees",
+			})
+		}
+
+		// Only newly added users are told
+		actor := currentUser(c)
+		message := fmt.Sprintf("You were assigned to entry %s (%s)", content.ID, w.State(content.WorkflowState.String))
+		for _, id := range body.UserIDs {
+			if slices.Contains(previous, id) || (actor.Valid && actor.Bytes == id) {
+				continue
+			}
+			if err := queries.CreateNotification(c.Context(), db.CreateNotificationParams{
+				UserID:    id,
+				ContentID: pgtype.UUID{Bytes: content.ID, Valid: true},
+				Message:   message,
+			}); err != nil {
+				logger.Error("Error notifying assignee", zap.Error(err))
+			}
+		}
+
+		if body.UserIDs == nil {
+			body.UserIDs = []uuid.UUID{}
+		}
+		return c.JSON(fiber.Map{
+			"id":        content.ID,
+			"assignees": body.UserIDs,
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	channel = "nota_events"
+	// notifications arriving this close together are loaded in one query
+	batchWindow = 20 * time.Millisecond
+	batchSize   = 200
+	// how often events waiting on a running transaction are checked again
+	settleInterval = time.Second
+	// a subscriber this far behind is dropped, it resumes with Last-Event-ID
+	subscriberBuffer = 256
+)
+
+// Hub listens on the nota_events channel and fans the events out to the
+// streams of this replica. Every replica runs its own, so each sees the
+// changes made through any of them. Events go out in stream order, once no
+// running transaction can still commit one before them.
+type Hub struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+	logger  *zap.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan db.Event]struct{}
+	closed      bool
+}
+
+func NewHub(lc fx.Lifecycle, pool *pgxpool.Pool, queries *db.Queries, logger *zap.Logger) *Hub {
+	h := &Hub{queries: queries, pool: pool, logger: logger, subscribers: map[chan db.Event]struct{}{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				h.run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			// ends the open streams, the server waits for them on shutdown
+			h.mu.Lock()
+			h.closed = true
+			for ch := range h.subscribers {
+				delete(h.subscribers, ch)
+				close(ch)
+			}
+			h.mu.Unlock()
+			return nil
+		},
+	})
+	return h
+}
+
+// Subscribe returns a channel of all events from now on. It is closed when
+// the subscriber falls behind or the app stops.
+func (h *Hub) Subscribe() chan db.Event {
+	ch := make(chan db.Event, subscriberBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+	} else {
+		h.subscribers[ch] = struct{}{}
+	}
+	return ch
+}
+
+func (h *Hub) Unsubscribe(ch chan db.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (h *Hub) broadcast(events []db.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		for _, e := range events {
+			select {
+			case ch <- e:
+				continue
+			default:
+			}
+			delete(h.subscribers, ch)
+			close(ch)
+			break
+		}
+	}
+}
+
+// run keeps a connection listening until ctx is done, reconnecting after errors
+func (h *Hub) run(ctx context.Context) {
+	var last position
+	latest, err := h.queries.GetLatestEventPosition(ctx)
+	if err == nil {
+		last = position{xid: latest.Xid, id: latest.ID}
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+	for ctx.Err() == nil {
+		if err == nil {
+			last, err = h.listen(ctx, last)
+		}
+		if err != nil && ctx.Err() == nil {
+			h.logger.Error("event listener failed", zap.Error(err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			err = nil
+		}
+	}
+}
+
+// listen forwards events until the connection fails. Notifications only say
+// that something committed: the log is read after the last position each
+// time, which also catches up on events raised while no connection was
+// listening.
+func (h *Hub) listen(ctx context.Context, last position) (position, error) {
+	pooled, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return last, err
+	}
+	// the listening connection never goes back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return last, err
+	}
+	for {
+		pending, err := h.catchUp(ctx, &last)
+		if err != nil {
+			return last, err
+		}
+
+		// events held back by a running transaction are checked again
+		// shortly, its commit may not raise a notification
+		if pending {
+			waitCtx, cancel := context.WithTimeout(ctx, settleInterval)
+			_, err = conn.WaitForNotification(waitCtx)
+			timedOut := waitCtx.Err() != nil
+			cancel()
+			if err != nil && timedOut && ctx.Err() == nil {
+				continue
+			}
+		} else {
+			_, err = conn.WaitForNotification(ctx)
+		}
+		if err != nil {
+			return last, err
+		}
+
+		// collect the rest of a burst, like the rows of a bulk write
+		for i := 0; i < batchSize; i++ {
+			waitCtx, cancel := context.WithTimeout(ctx, batchWindow)
+			_, err := conn.WaitForNotification(waitCtx)
+			cancel()
+			if err != nil {
+				if ctx.Err() != nil {
+					return last, err
+				}
+				break
+			}
+		}
+	}
+}
+
+// catchUp forwards the settled events after last and reports whether newer
+// ones are still waiting to settle
+func (h *Hub) catchUp(ctx context.Context, last *position) (bool, error) {
+	for {
+		rows, err := h.queries.ListEventsAfter(ctx, db.ListEventsAfterParams{AfterXid: last.xid, AfterID: last.id, PageSize: batchSize})
+		if err != nil {
+			return false, err
+		}
+		events, pending := settled(rows)
+		if len(events) > 0 {
+			h.broadcast(events)
+			*last = positionOf(events[len(events)-1])
+		}
+		if pending || len(rows) < batchSize {
+			return pending, nil
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Open a Server-Sent Events stream on /api/v1/events, for example
+// new EventSource("/api/v1/events?schemas=blog&events=content.published", { withCredentials: true })
+// Each message has the event's stream position as id, its name and a JSON body like
+// {"id": 42, "event": "content.published", "schemaId": "...", "createdAt": "...", "data": {...}}
+
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/manthan307/nota-cms/api/v1/auth"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	heartbeat = 20 * time.Second
+	// a stream resumes at most this many events, older gaps get a reset event
+	replayLimit = 1000
+)
+
+// Without the viewer role only changes to the published content are sent,
+// and for entries leaving it just their ids. Entries that were never live
+// leave nothing, so their removals are not sent at all.
+var publicEvents = map[string]bool{
+	"content.published":   true,
+	"content.unpublished": true,
+	"content.deleted":     true,
+}
+
+type filter struct {
+	events  []string
+	schemas []uuid.UUID
+	viewer  bool
+}
+
+func (f filter) match(e db.Event) bool {
+	if !f.viewer && (!publicEvents[e.Event] || e.Event != "content.published" && !wasPublished(e.Data)) {
+		return false
+	}
+	if len(f.events) > 0 && !slices.Contains(f.events, e.Event) {
+		return false
+	}
+	return len(f.schemas) == 0 || (e.SchemaID.Valid && slices.Contains(f.schemas, uuid.UUID(e.SchemaID.Bytes)))
+}
+
+// StreamHandler sends change events as they happen. Reconnecting clients send
+// the Last-Event-ID header (or ?lastEventId=) and first get what they missed.
+func StreamHandler(queries *db.Queries, logger *zap.Logger, hub *Hub) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		f := filter{viewer: auth.HasRole(c, "viewer")}
+		for _, e := range split(c.Query("events")) {
+			if !slices.Contains(utils.WebhookEvents, e) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("unknown event %q", e)})
+			}
+			f.events = append(f.events, e)
+		}
+		for _, name := range split(c.Query("schemas")) {
+			schema, err := queries.GetSchemaByName(c.Context(), name)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("unknown schema %q", name)})
+			}
+			f.schemas = append(f.schemas, schema.ID)
+		}
+
+		lastID := c.Get("Last-Event-ID", c.Query("lastEventId"))
+		var after position
+		if lastID != "" {
+			var err error
+			if after, err = parsePosition(lastID); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Last-Event-ID"})
+			}
+		}
+
+		// subscribe before replaying so nothing falls in between
+		events := hub.Subscribe()
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer hub.Unsubscribe(events)
+
+			fmt.Fprint(w, "retry: 3000\n\n")
+			sent := after
+			if lastID != "" {
+				var err error
+				if sent, err = replay(queries, w, f, after); err != nil {
+					logger.Error("Error replaying events", zap.Error(err))
+					return
+				}
+			}
+			if w.Flush() != nil {
+				return
+			}
+
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case e, ok := <-events:
+					if !ok {
+						return
+					}
+					// the hub sends events in stream order, the replay may
+					// already have covered them
+					if !sent.before(positionOf(e)) {
+						continue
+					}
+					sent = positionOf(e)
+					if !f.match(e) {
+						continue
+					}
+					write(w, e, f.viewer)
+				case <-ticker.C:
+					fmt.Fprint(w, ": ping\n\n")
+				}
+				// a failed flush means the client went away
+				if w.Flush() != nil {
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+// replay sends the settled events after the given position and returns the
+// position it got to. Later events arrive from the hub once they settle.
+func replay(queries *db.Queries, w *bufio.Writer, f filter, after position) (position, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rows, err := queries.ListEventsAfter(ctx, db.ListEventsAfterParams{AfterXid: after.xid, AfterID: after.id, PageSize: replayLimit + 1})
+	if err != nil {
+		return after, err
+	}
+	missed, _ := settled(rows)
+	if len(missed) > replayLimit {
+		// too far behind, the client should reload instead
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		return after, nil
+	}
+
+	for _, e := range missed {
+		after = positionOf(e)
+		if f.match(e) {
+			write(w, e, f.viewer)
+		}
+	}
+	return after, nil
+}
+
+func write(w *bufio.Writer, e db.Event, viewer bool) {
+	data := e.Data
+	if !viewer && e.Event != "content.published" {
+		data = idsOnly(data)
+	}
+	var schemaID interface{}
+	if e.SchemaID.Valid {
+		schemaID = uuid.UUID(e.SchemaID.Bytes)
+	}
+	body, _ := json.Marshal(fiber.Map{
+		"id":        e.ID,
+		"event":     e.Event,
+		"schemaId":  schemaID,
+		"createdAt": e.CreatedAt.Time,
+		"data":      data,
+	})
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", positionOf(e), e.Event, body)
+}
+
+// idsOnly keeps what identifies an entry and drops its content
+func idsOnly(data json.RawMessage) json.RawMessage {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return json.RawMessage("{}")
+	}
+	kept, _ := json.Marshal(fiber.Map{"id": entry["id"], "schemaId": entry["schemaId"], "schema": entry["schema"]})
+	return kept
+}
+
+// wasPublished reports whether the entry of an event was live before the change
+func wasPublished(data json.RawMessage) bool {
+	var entry struct {
+		WasPublished bool `json:"wasPublished"`
+	}
+	return json.Unmarshal(data, &entry) == nil && entry.WasPublished
+}
+
+// position orders the event log for streams: by the transaction that raised
+// an event, then by its id. Ids alone are taken on insert, so an event with a
+// lower id can commit after a client resumed past it.
+type position struct {
+	xid uint64
+	id  int64
+}
+
+func positionOf(e db.Event) position {
+	return position{xid: e.Xid, id: e.ID}
+}
+
+func (p position) before(q position) bool {
+	return p.xid < q.xid || p.xid == q.xid && p.id < q.id
+}
+
+// String is the SSE id, "xid-id"
+func (p position) String() string {
+	return strconv.FormatUint(p.xid, 10) + "-" + strconv.FormatInt(p.id, 10)
+}
+
+func parsePosition(s string) (position, error) {
+	xid, id, ok := strings.Cut(s, "-")
+	if !ok {
+		return position{}, fmt.Errorf("invalid event id %q", s)
+	}
+	var p position
+	var err error
+	if p.xid, err = strconv.ParseUint(xid, 10, 64); err != nil {
+		return position{}, fmt.Errorf("invalid event id %q", s)
+	}
+	if p.id, err = strconv.ParseInt(id, 10, 64); err != nil || p.id < 0 {
+		return position{}, fmt.Errorf("invalid event id %q", s)
+	}
+	return p, nil
+}
+
+// settled returns the events up to the first one a still running transaction
+// could commit an event before, and whether any were left out
+func settled(rows []db.ListEventsAfterRow) ([]db.Event, bool) {
+	events := make([]db.Event, 0, len(rows))
+	for _, row := range rows {
+		if !row.Settled {
+			return events, true
+		}
+		events = append(events, db.Event{
+			ID:        row.ID,
+			Event:     row.Event,
+			SchemaID:  row.SchemaID,
+			Data:      row.Data,
+			CreatedAt: row.CreatedAt,
+			Xid:       row.Xid,
+		})
+	}
+	return events, false
+}
+
+func split(s string) []string {
+	var parts []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
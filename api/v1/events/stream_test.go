@@ -0,0 +1,94 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+)
+
+func TestPosition(t *testing.T) {
+	p := position{xid: 1 << 40, id: 42}
+	got, err := parsePosition(p.String())
+	if err != nil || got != p {
+		t.Fatalf("round trip gave %v, %v, want %v", got, err, p)
+	}
+	for _, bad := range []string{"", "42", "-1", "1-", "x-1", "1-x", "1--1"} {
+		if _, err := parsePosition(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+
+	// an event with a lower id that commits later still comes after
+	early := position{xid: 100, id: 7}
+	late := position{xid: 101, id: 5}
+	if !early.before(late) || late.before(early) || early.before(early) {
+		t.Error("positions should order by transaction first")
+	}
+	if !(position{xid: 100, id: 6}).before(early) {
+		t.Error("positions of one transaction should order by id")
+	}
+}
+
+func TestSettled(t *testing.T) {
+	rows := []db.ListEventsAfterRow{
+		{ID: 3, Xid: 10, Settled: true},
+		{ID: 1, Xid: 11, Settled: true},
+		{ID: 2, Xid: 12},
+		{ID: 4, Xid: 13},
+	}
+	events, pending := settled(rows)
+	if !pending || len(events) != 2 || positionOf(events[1]) != (position{xid: 11, id: 1}) {
+		t.Errorf("expected the first two events and pending, got %+v, %v", events, pending)
+	}
+	if events, pending := settled(rows[:2]); pending || len(events) != 2 {
+		t.Errorf("expected both events and nothing pending, got %+v, %v", events, pending)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	blog, pages := uuid.New(), uuid.New()
+	event := func(name string, schema uuid.UUID, wasPublished bool) db.Event {
+		data, _ := json.Marshal(map[string]interface{}{"id": uuid.New(), "wasPublished": wasPublished})
+		return db.Event{Event: name, SchemaID: pgtype.UUID{Bytes: schema, Valid: true}, Data: data}
+	}
+
+	cases := []struct {
+		name string
+		f    filter
+		e    db.Event
+		want bool
+	}{
+		{"public publish", filter{}, event("content.published", blog, false), true},
+		{"public unpublish of a live entry", filter{}, event("content.unpublished", blog, true), true},
+		{"public delete of a draft", filter{}, event("content.deleted", blog, false), false},
+		{"public update", filter{}, event("content.updated", blog, true), false},
+		{"viewer update", filter{viewer: true}, event("content.updated", blog, false), true},
+		{"viewer delete of a draft", filter{viewer: true}, event("content.deleted", blog, false), true},
+		{"event filter", filter{viewer: true, events: []string{"content.published"}}, event("content.updated", blog, false), false},
+		{"schema filter", filter{schemas: []uuid.UUID{pages}}, event("content.published", blog, false), false},
+		{"schema filter match", filter{schemas: []uuid.UUID{blog, pages}}, event("content.published", blog, false), true},
+		{"schema filter without schema", filter{viewer: true, schemas: []uuid.UUID{blog}}, db.Event{Event: "content.updated", Data: json.RawMessage(`{}`)}, false},
+	}
+	for _, c := range cases {
+		if got := c.f.match(c.e); got != c.want {
+			t.Errorf("%s: match = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestIdsOnly(t *testing.T) {
+	data := json.RawMessage(`{"id":"a","schemaId":"b","schema":"blog","data":{"title":"Secret"},"published":false}`)
+	var got map[string]interface{}
+	if err := json.Unmarshal(idsOnly(data), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got["id"] != "a" || got["schemaId"] != "b" || got["schema"] != "blog" {
+		t.Errorf("expected only the ids, got %v", got)
+	}
+	if string(idsOnly(json.RawMessage(`not json`))) != "{}" {
+		t.Error("expected invalid data to give an empty object")
+	}
+}
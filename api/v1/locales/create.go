@@ -0,0 +1,176 @@
+// Send post request on the url /api/v1/locales/create with body like below:
+// {
+// 	"code": "de-AT",
+// 	"name": "German (Austria)",
+// 	"fallback": "de",
+// 	"searchConfig": "german",
+// 	"isDefault": false
+// }
+
+package locales
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+var localeCode = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+type localeBody struct {
+	Code         string  `json:"code"`
+	Name         string  `json:"name"`
+	Fallback     *string `json:"fallback"`
+	SearchConfig string  `json:"searchConfig"`
+	IsDefault    bool    `json:"isDefault"`
+}
+
+func CreateLocale(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body localeBody
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+
+		if !localeCode.MatchString(body.Code) || body.Name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "a valid code (like en or de-AT) and name are required"})
+		}
+
+		fallback := ""
+		if body.Fallback != nil {
+			fallback = *body.Fallback
+		}
+		if err := checkFallback(c.Context(), queries, body.Code, fallback); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if body.SearchConfig == "" {
+			body.SearchConfig = "simple"
+		}
+		if err := checkSearchConfig(c.Context(), queries, body.SearchConfig); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if body.IsDefault {
+			if err := checkDefaultSwitch(c.Context(), queries); err != nil {
+				return defaultSwitchError(c, logger, err)
+			}
+		}
+
+		locale, err := queries.CreateLocale(c.Context(), db.CreateLocaleParams{
+			Code:         body.Code,
+			Name:         body.Name,
+			Fallback:     pgtype.Text{String: fallback, Valid: fallback != ""},
+			SearchConfig: body.SearchConfig,
+		})
+		if err != nil {
+			logger.Error("could not create locale", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create locale"})
+		}
+
+		if body.IsDefault {
+			if err := setDefault(c.Context(), pool, queries, locale.Code); err != nil {
+				logger.Error("could not set default locale", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not set default locale"})
+			}
+			locale.IsDefault = true
+		}
+
+		return c.Status(fiber.StatusOK).JSON(formatLocale(locale))
+	}
+}
+
+// checkFallback makes sure the fallback exists and does not lead back to code
+func checkFallback(ctx context.Context, queries *db.Queries, code, fallback string) error {
+	seen := map[string]bool{code: true}
+	for next := fallback; next != ""; {
+		if seen[next] {
+			return fmt.Errorf("fallback chain of %q loops back to %q", code, next)
+		}
+		seen[next] = true
+
+		l, err := queries.GetLocale(ctx, next)
+		if err != nil {
+			return fmt.Errorf("unknown fallback locale %q", next)
+		}
+		next = l.Fallback.String
+	}
+	return nil
+}
+
+// checkSearchConfig makes sure Postgres knows the text search configuration
+func checkSearchConfig(ctx context.Context, queries *db.Queries, config string) error {
+	ok, err := queries.SearchConfigExists(ctx, config)
+	if err != nil {
+		return fmt.Errorf("could not check search config: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("unknown text search config %q", config)
+	}
+	return nil
+}
+
+var errLocalizedContent = errors.New("the default locale cannot change while entries hold localized values")
+
+// checkDefaultSwitch refuses a new default while entries of schemas with
+// localized fields exist. Their contents.data holds the default locale's
+// values and switching would hand them to the new default.
+func checkDefaultSwitch(ctx context.Context, queries *db.Queries) error {
+	exists, err := queries.HasLocalizedContents(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errLocalizedContent
+	}
+	return nil
+}
+
+func defaultSwitchError(c *fiber.Ctx, logger *zap.Logger, err error) error {
+	if errors.Is(err, errLocalizedContent) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	logger.Error("failed to check localized content", zap.Error(err))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not check content"})
+}
+
+// setDefault makes code the default locale. Entries keep their base values in
+// contents.data, so their search documents are relabelled to the new default;
+// checkDefaultSwitch makes sure none of those values are localized. The switch
+// and the relabelling happen in one transaction.
+func setDefault(ctx context.Context, pool *pgxpool.Pool, queries *db.Queries, code string) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := queries.WithTx(tx)
+
+	prev, err := qtx.GetDefaultLocale(ctx)
+	if err != nil {
+		return err
+	}
+	if err := qtx.SetDefaultLocale(ctx, code); err != nil {
+		return err
+	}
+	if prev.Code != code {
+		// Translations stored for the new default are shadowed by the base values now
+		if err := qtx.DeleteSearchDocumentsByLocale(ctx, code); err != nil {
+			return err
+		}
+		if err := qtx.MoveSearchDocuments(ctx, db.MoveSearchDocumentsParams{ToLocale: code, FromLocale: prev.Code}); err != nil {
+			return err
+		}
+		if err := qtx.ReindexSearchLocale(ctx, code); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
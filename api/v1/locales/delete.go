@@ -0,0 +1,38 @@
+package locales
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// DeleteLocale removes a locale and all of its translations.
+// The default locale cannot be deleted.
+func DeleteLocale(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		code := c.Params("code")
+
+		locale, err := queries.GetLocale(c.Context(), code)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "locale not found"})
+			}
+			logger.Error("failed to fetch locale", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not fetch locale"})
+		}
+
+		if locale.IsDefault {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "the default locale cannot be deleted"})
+		}
+
+		if err := queries.DeleteLocale(c.Context(), code); err != nil {
+			logger.Error("could not delete locale", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete locale"})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Locale deleted successfully"})
+	}
+}
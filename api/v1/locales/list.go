@@ -0,0 +1,45 @@
+package locales
+
+import (
+	"github.com/gofiber/fiber/v2"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+func ListLocales(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locales, err := queries.ListLocales(c.Context())
+		if err != nil {
+			logger.Error("Failed to fetch locales", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch locales",
+			})
+		}
+
+		result := make([]fiber.Map, 0, len(locales))
+		for _, l := range locales {
+			result = append(result, formatLocale(l))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"count": len(result),
+			"data":  result,
+		})
+	}
+}
+
+func formatLocale(l db.Locale) fiber.Map {
+	var fallback interface{}
+	if l.Fallback.Valid {
+		fallback = l.Fallback.String
+	}
+	return fiber.Map{
+		"code":         l.Code,
+		"name":         l.Name,
+		"isDefault":    l.IsDefault,
+		"fallback":     fallback,
+		"searchConfig": l.SearchConfig,
+		"createdAt":    l.CreatedAt,
+		"updatedAt":    l.UpdatedAt,
+	}
+}
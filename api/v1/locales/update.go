@@ -0,0 +1,88 @@
+package locales
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// UpdateLocale changes the name, fallback, search config or default flag of a locale.
+// The default locale's values are stored in contents.data, so the default can
+// only change while no entry holds localized values, see checkDefaultSwitch.
+func UpdateLocale(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body localeBody
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+
+		current, err := queries.GetLocale(c.Context(), body.Code)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "locale not found"})
+			}
+			logger.Error("failed to fetch locale", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not fetch locale"})
+		}
+
+		if body.Name == "" {
+			body.Name = current.Name
+		}
+
+		// An omitted fallback keeps the current one, "" clears it
+		fallback := current.Fallback.String
+		if body.Fallback != nil {
+			fallback = *body.Fallback
+		}
+		if err := checkFallback(c.Context(), queries, body.Code, fallback); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if body.SearchConfig == "" {
+			body.SearchConfig = current.SearchConfig
+		}
+		if err := checkSearchConfig(c.Context(), queries, body.SearchConfig); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if body.IsDefault && !current.IsDefault {
+			if err := checkDefaultSwitch(c.Context(), queries); err != nil {
+				return defaultSwitchError(c, logger, err)
+			}
+		}
+
+		locale, err := queries.UpdateLocale(c.Context(), db.UpdateLocaleParams{
+			Code:         body.Code,
+			Name:         body.Name,
+			Fallback:     pgtype.Text{String: fallback, Valid: fallback != ""},
+			SearchConfig: body.SearchConfig,
+		})
+		if err != nil {
+			logger.Error("could not update locale", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update locale"})
+		}
+
+		// Documents are stemmed with the locale's config, rebuild them from the stored text
+		if locale.SearchConfig != current.SearchConfig {
+			if err := queries.ReindexSearchLocale(c.Context(), locale.Code); err != nil {
+				logger.Error("could not reindex locale", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not reindex locale"})
+			}
+		}
+
+		if body.IsDefault && !locale.IsDefault {
+			if err := setDefault(c.Context(), pool, queries, locale.Code); err != nil {
+				logger.Error("could not set default locale", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not set default locale"})
+			}
+			locale.IsDefault = true
+		}
+
+		return c.Status(fiber.StatusOK).JSON(formatLocale(locale))
+	}
+}
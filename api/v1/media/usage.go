@@ -0,0 +1,57 @@
+package media
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// MediaUsageHandler lists the entries whose rich text links to or shows a
+// media file, so it isn't deleted while still in use
+func MediaUsageHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid media id"})
+		}
+		media, err := queries.GetMediaByID(c.Context(), id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "media not found"})
+		}
+		if err != nil {
+			logger.Error("Error fetching media", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch media"})
+		}
+
+		rows, err := queries.ListLinksToURL(c.Context(), pgtype.Text{String: media.Url, Valid: true})
+		if err != nil {
+			logger.Error("Error fetching media usage", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch media usage"})
+		}
+		// links in the default locale's data have no locale
+		defaultLocale, err := queries.GetDefaultLocale(c.Context())
+		if err != nil {
+			logger.Error("Error fetching default locale", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch media usage"})
+		}
+		usage := make([]fiber.Map, 0, len(rows))
+		for _, r := range rows {
+			entry := fiber.Map{
+				"id":        r.ContentID,
+				"schema_id": uuid.UUID(r.SchemaID.Bytes),
+				"locale":    defaultLocale.Code,
+				"field":     r.Field,
+			}
+			if r.Locale.Valid {
+				entry["locale"] = r.Locale.String
+			}
+			usage = append(usage, entry)
+		}
+		return c.JSON(fiber.Map{"url": media.Url, "usedBy": usage})
+	}
+}
@@ -0,0 +1,50 @@
+package notifications
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// userID is the signed in user, set by auth.ProtectedRoute
+func userID(c *fiber.Ctx) uuid.UUID {
+	claims, _ := c.Locals("claims").(jwt.MapClaims)
+	id, _ := claims["user_id"].(string)
+	parsed, _ := uuid.Parse(id)
+	return parsed
+}
+
+// ListNotifications returns the latest notifications of the signed in user,
+// only unread ones with ?unread=true
+func ListNotifications(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		notifications, err := queries.ListNotifications(c.Context(), db.ListNotificationsParams{
+			UserID:     userID(c),
+			UnreadOnly: c.QueryBool("unread"),
+		})
+		if err != nil {
+			logger.Error("Failed to fetch notifications", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch notifications",
+			})
+		}
+
+		result := make([]fiber.Map, 0, len(notifications))
+		for _, n := range notifications {
+			result = append(result, fiber.Map{
+				"id":        n.ID,
+				"contentID": n.ContentID,
+				"message":   n.Message,
+				"read":      n.ReadAt.Valid,
+				"createdAt": n.CreatedAt,
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"count": len(result),
+			"data":  result,
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package notifications
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// MarkNotificationRead marks one of the signed in user's notifications as read
+func MarkNotificationRead(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid notification ID",
+			})
+		}
+
+		n, err := queries.MarkNotificationRead(c.Context(), db.MarkNotificationReadParams{
+			ID:     id,
+			UserID: userID(c),
+		})
+		if err != nil {
+			logger.Error("Failed to update notification", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update notification",
+			})
+		}
+		if n == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Unread notification not found",
+			})
+		}
+
+		return c.JSON(fiber.Map{"message": "Notification marked as read"})
+	}
+}
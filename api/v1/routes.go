@@ -2,16 +2,22 @@ package v1
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/manthan307/nota-cms/api/v1/auth"
 	"github.com/manthan307/nota-cms/api/v1/content"
+	"github.com/manthan307/nota-cms/api/v1/events"
+	"github.com/manthan307/nota-cms/api/v1/locales"
 	"github.com/manthan307/nota-cms/api/v1/media"
+	"github.com/manthan307/nota-cms/api/v1/notifications"
 	schemasRoutes "github.com/manthan307/nota-cms/api/v1/schemas"
+	"github.com/manthan307/nota-cms/api/v1/taxonomies"
+	"github.com/manthan307/nota-cms/api/v1/webhooks"
 	db "github.com/manthan307/nota-cms/db/output"
 	"github.com/minio/minio-go/v7"
 	"go.uber.org/zap"
 )
 
-func RegisterRoutes(app *fiber.App, queries *db.Queries, logger *zap.Logger, minioClient *minio.Client) {
+func RegisterRoutes(app *fiber.App, queries *db.Queries, logger *zap.Logger, minioClient *minio.Client, pool *pgxpool.Pool, hub *events.Hub) {
 	api := app.Group("/api")
 	v1 := api.Group("/v1")
 
@@ -26,18 +32,90 @@ func RegisterRoutes(app *fiber.App, queries *db.Queries, logger *zap.Logger, min
 	schemas.Get("/get_by_id/:id", auth.ProtectedRoute(logger, queries, "viewer"), schemasRoutes.GetSchemaByID(queries, logger))
 	schemas.Get("/get_by_name/:name", auth.ProtectedRoute(logger, queries, "viewer"), schemasRoutes.GetSchemaByName(queries, logger))
 	schemas.Get("/list", auth.ProtectedRoute(logger, queries, "viewer"), schemasRoutes.ListSchemas(queries, logger))
-	schemas.Delete("/delete/:id", auth.ProtectedRoute(logger, queries, "editor"), schemasRoutes.DeleteSchema(queries, logger))
+	schemas.Get("/types", auth.ProtectedRoute(logger, queries, "viewer"), schemasRoutes.GenerateTypes(queries, logger))
+	schemas.Delete("/delete/:id", auth.ProtectedRoute(logger, queries, "editor"), schemasRoutes.DeleteSchema(queries, logger, pool))
+	schemas.Post("/settings/:id", auth.ProtectedRoute(logger, queries, "editor"), schemasRoutes.UpdateSchemaSettings(queries, logger))
+	schemas.Get("/trash", auth.ProtectedRoute(logger, queries, "editor"), schemasRoutes.ListDeletedSchemas(queries, logger))
+	schemas.Post("/restore/:id", auth.ProtectedRoute(logger, queries, "editor"), schemasRoutes.RestoreSchema(queries, logger, pool))
+
+	//locales
+	localeRoute := v1.Group("/locales")
+	localeRoute.Get("/list", locales.ListLocales(queries, logger))
+	localeRoute.Post("/create", auth.ProtectedRoute(logger, queries, "admin"), locales.CreateLocale(queries, logger, pool))
+	localeRoute.Post("/update", auth.ProtectedRoute(logger, queries, "admin"), locales.UpdateLocale(queries, logger, pool))
+	localeRoute.Delete("/delete/:code", auth.ProtectedRoute(logger, queries, "admin"), locales.DeleteLocale(queries, logger))
+
+	//taxonomies
+	taxonomyRoute := v1.Group("/taxonomies")
+	taxonomyRoute.Get("/list", taxonomies.ListTaxonomies(queries, logger))
+	taxonomyRoute.Get("/get/:name", taxonomies.GetTaxonomy(queries, logger))
+	taxonomyRoute.Post("/create", auth.ProtectedRoute(logger, queries, "editor"), taxonomies.CreateTaxonomy(queries, logger))
+	taxonomyRoute.Delete("/delete/:name", auth.ProtectedRoute(logger, queries, "editor"), taxonomies.DeleteTaxonomy(queries, logger))
+	taxonomyRoute.Post("/:name/terms", auth.ProtectedRoute(logger, queries, "editor"), taxonomies.CreateTerm(queries, logger))
+	taxonomyRoute.Patch("/:name/terms/:slug", auth.ProtectedRoute(logger, queries, "editor"), taxonomies.UpdateTerm(queries, logger, pool))
+	taxonomyRoute.Delete("/:name/terms/:slug", auth.ProtectedRoute(logger, queries, "editor"), taxonomies.DeleteTerm(queries, logger, pool))
 
 	//content
 	contentRoute := v1.Group("/content")
 	contentRoute.Post("/create", auth.ProtectedRoute(logger, queries, "editor"), content.CreateContentHandler(queries, logger))
+	contentRoute.Post("/bulk", auth.ProtectedRoute(logger, queries, "editor"), content.BulkContentHandler(queries, logger, pool))
+	contentRoute.Get("/export/:schema_name", auth.ProtectedRoute(logger, queries, "viewer"), content.ExportContentsHandler(queries, logger))
+	contentRoute.Post("/import/:schema_name", auth.ProtectedRoute(logger, queries, "editor"), content.ImportContentsHandler(queries, logger))
+	contentRoute.Get("/imports/:id/report", auth.ProtectedRoute(logger, queries, "editor"), content.ImportReportHandler(queries, logger))
 	contentRoute.Delete("/delete/:id", auth.ProtectedRoute(logger, queries, "editor"), content.DeleteContentHandler(queries, logger))
-	contentRoute.Get("/get/:id", content.GetContentHandler(queries, logger))
-	contentRoute.Get("/get_all/:schema_name", content.GetAllContentsBySchemaHandler(queries, logger))
-	contentRoute.Post("/update", auth.ProtectedRoute(logger, queries, "editor"), content.UpdateContentHandler(queries, logger))
+	contentRoute.Get("/trash/:schema_name", auth.ProtectedRoute(logger, queries, "editor"), content.ListDeletedContentsHandler(queries, logger))
+	contentRoute.Post("/trash/:id/restore", auth.ProtectedRoute(logger, queries, "editor"), content.RestoreContentHandler(queries, logger))
+	contentRoute.Delete("/trash/:id", auth.ProtectedRoute(logger, queries, "admin"), content.PurgeContentHandler(queries, logger))
+	contentRoute.Get("/get/:id", content.GetContentHandler(queries, logger, pool))
+	contentRoute.Get("/:schema_name/by/:field/:value", content.GetContentByFieldHandler(queries, logger, pool))
+	contentRoute.Get("/get_all/:schema_name", content.GetAllContentsBySchemaHandler(queries, logger, pool))
+	contentRoute.Get("/sync/:schema_name", content.SyncContentsHandler(queries, logger, pool))
+	contentRoute.Get("/preview/:id", auth.ProtectedRoute(logger, queries, "viewer"), content.PreviewContentHandler(queries, logger, pool))
+	contentRoute.Get("/preview/:schema_name/by/:field/:value", auth.ProtectedRoute(logger, queries, "viewer"), content.PreviewContentByFieldHandler(queries, logger, pool))
+	contentRoute.Get("/preview_all/:schema_name", auth.ProtectedRoute(logger, queries, "viewer"), content.PreviewAllContentsHandler(queries, logger, pool))
+	contentRoute.Post("/publish/:id", auth.ProtectedRoute(logger, queries, "editor"), content.PublishContentHandler(queries, logger))
+	contentRoute.Delete("/draft/:id", auth.ProtectedRoute(logger, queries, "editor"), content.DiscardDraftHandler(queries, logger))
+	contentRoute.Get("/search", auth.OptionalAuth(logger, queries), content.SearchContentHandler(queries, logger, pool))
+	contentRoute.Get("/search/:schema_name", auth.OptionalAuth(logger, queries), content.SearchContentHandler(queries, logger, pool))
+	contentRoute.Post("/update", auth.ProtectedRoute(logger, queries, "editor"), content.UpdateContentHandler(queries, logger, pool))
+	contentRoute.Patch("/:id", auth.ProtectedRoute(logger, queries, "editor"), content.PatchContentHandler(queries, logger, pool))
+	contentRoute.Get("/revisions/:id", auth.ProtectedRoute(logger, queries, "viewer"), content.ListRevisionsHandler(queries, logger))
+	contentRoute.Get("/revisions/:id/diff", auth.ProtectedRoute(logger, queries, "viewer"), content.DiffRevisionsHandler(queries, logger))
+	contentRoute.Get("/revisions/:id/:version", auth.ProtectedRoute(logger, queries, "viewer"), content.GetRevisionHandler(queries, logger))
+	contentRoute.Post("/revisions/:id/restore/:version", auth.ProtectedRoute(logger, queries, "editor"), content.RestoreRevisionHandler(queries, logger))
+	contentRoute.Get("/schedules", auth.ProtectedRoute(logger, queries, "viewer"), content.ListSchedulesHandler(queries, logger))
+	contentRoute.Post("/schedule/:id", auth.ProtectedRoute(logger, queries, "editor"), content.ScheduleContentHandler(queries, logger))
+	contentRoute.Delete("/schedule/:id", auth.ProtectedRoute(logger, queries, "editor"), content.CancelScheduleHandler(queries, logger))
+	contentRoute.Get("/links/:id", auth.ProtectedRoute(logger, queries, "viewer"), content.ContentLinksHandler(queries, logger))
+	contentRoute.Get("/workflow/:id", auth.ProtectedRoute(logger, queries, "viewer"), content.GetWorkflowHandler(queries, logger))
+	contentRoute.Post("/workflow/:id", auth.ProtectedRoute(logger, queries, "viewer"), content.TransitionWorkflowHandler(queries, logger))
+	contentRoute.Post("/workflow/:id/assignees", auth.ProtectedRoute(logger, queries, "editor"), content.SetAssigneesHandler(queries, logger))
+
+	//graphql, one handler so both methods share the generated schema
+	graphqlHandler := content.GraphQLHandler(queries, logger, pool)
+	v1.Get("/graphql", auth.OptionalAuth(logger, queries), graphqlHandler)
+	v1.Post("/graphql", auth.OptionalAuth(logger, queries), graphqlHandler)
+
+	//events, one stream per client fed by every replica through LISTEN/NOTIFY
+	v1.Get("/events", auth.OptionalAuth(logger, queries), events.StreamHandler(queries, logger, hub))
+
+	//notifications
+	notificationRoute := v1.Group("/notifications")
+	notificationRoute.Get("/list", auth.ProtectedRoute(logger, queries, "viewer"), notifications.ListNotifications(queries, logger))
+	notificationRoute.Post("/read/:id", auth.ProtectedRoute(logger, queries, "viewer"), notifications.MarkNotificationRead(queries, logger))
+
+	//webhooks
+	webhookRoute := v1.Group("/webhooks")
+	webhookRoute.Get("/list", auth.ProtectedRoute(logger, queries, "admin"), webhooks.ListWebhooks(queries, logger))
+	webhookRoute.Post("/create", auth.ProtectedRoute(logger, queries, "admin"), webhooks.CreateWebhook(queries, logger))
+	webhookRoute.Post("/update/:id", auth.ProtectedRoute(logger, queries, "admin"), webhooks.UpdateWebhook(queries, logger))
+	webhookRoute.Delete("/delete/:id", auth.ProtectedRoute(logger, queries, "admin"), webhooks.DeleteWebhook(queries, logger))
+	webhookRoute.Get("/deliveries/:id", auth.ProtectedRoute(logger, queries, "admin"), webhooks.ListDeliveries(queries, logger))
+	webhookRoute.Post("/redeliver/:delivery_id", auth.ProtectedRoute(logger, queries, "admin"), webhooks.Redeliver(queries, logger))
 
 	//media
 	mediaRoute := v1.Group("/media")
 	mediaRoute.Post("/upload", auth.ProtectedRoute(logger, queries, "editor"), media.UploadMediaHandler(queries, logger, minioClient))
 	mediaRoute.Delete("/delete", auth.ProtectedRoute(logger, queries, "editor"), media.DeleteMediaHandler(queries, logger, minioClient))
+	mediaRoute.Get("/:id/usage", auth.ProtectedRoute(logger, queries, "viewer"), media.MediaUsageHandler(queries, logger))
 }
@@ -2,10 +2,20 @@
 // {
 // 	"name":"schemaName",
 // 	"definition":[
-//   { "name": "title", "type": "text", "isRequired": true },
+//   { "name": "title", "type": "text", "isRequired": true,
+//     "ui": { "label": "Title", "placeholder": "My first post", "tab": "Content" } },
 //   { "name": "views", "type": "number" },
-//   { "name": "thumbnail", "type": "image" }
-// ]
+//   { "name": "thumbnail", "type": "image" },
+//   { "name": "author", "type": "reference", "ref": "authors" },
+//   { "name": "categories", "type": ["taxonomy"], "taxonomy": "categories" },
+//   { "name": "status", "type": "enum", "options": ["draft", "live"],
+//     "ui": { "widget": "select", "tab": "Settings", "fieldset": "Publishing" } }
+// ],
+// "rules":[
+//   { "name": "live_needs_thumbnail", "when": "status == 'live'", "require": ["thumbnail"] },
+//   { "name": "positive_views", "assert": "views >= 0", "message": "views cannot be negative" }
+// ],
+// "settings": { "revisions": { "maxCount": 50, "maxAgeDays": 90 } }
 // }
 
 package schemasRoutes
@@ -27,6 +37,8 @@ func SchemasCreateHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 		var body struct {
 			Name       string          `json:"name"`
 			Defination json.RawMessage `json:"definition"`
+			Rules      json.RawMessage `json:"rules"`
+			Settings   json.RawMessage `json:"settings"`
 		}
 		if err := c.BodyParser(&body); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
@@ -36,12 +48,42 @@ func SchemasCreateHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name and definition are required"})
 		}
 
-		ok, err := utils.CheckTypes(body.Defination)
+		if len(body.Rules) == 0 {
+			body.Rules = json.RawMessage("[]")
+		}
+
+		if len(body.Settings) == 0 {
+			body.Settings = json.RawMessage("{}")
+		}
+		if _, err := utils.ParseSettings(body.Settings); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		ok, err := utils.CheckTypes(body.Defination, body.Rules)
 		if !ok {
 			logger.Error("invalid definition", zap.Error(err))
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid definition: " + err.Error()})
 		}
 
+		// Referenced schemas must exist (self references are allowed)
+		fields, _ := utils.ParseFields(body.Defination)
+		for _, f := range fields {
+			if f.Ref == "" || f.Ref == body.Name {
+				continue
+			}
+			if _, err := queries.GetSchemaByName(c.Context(), f.Ref); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid definition: field " + f.Name + " references unknown schema " + f.Ref})
+			}
+		}
+		for _, f := range fields {
+			if f.Taxonomy == "" {
+				continue
+			}
+			if _, err := queries.GetTaxonomyByName(c.Context(), f.Taxonomy); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid definition: field " + f.Name + " uses unknown taxonomy " + f.Taxonomy})
+			}
+		}
+
 		claims := c.Locals("claims").(jwt.MapClaims)
 		userIDStr := claims["user_id"].(string)
 
@@ -56,6 +98,8 @@ func SchemasCreateHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			CreatedBy:  userID,
 			Name:       body.Name,
 			Definition: body.Defination,
+			Rules:      body.Rules,
+			Settings:   body.Settings,
 		})
 
 		if err != nil {
@@ -68,6 +112,8 @@ func SchemasCreateHandler(queries *db.Queries, logger *zap.Logger) fiber.Handler
 			"name":       schema.Name,
 			"createdBy":  schema.CreatedBy,
 			"definition": schema.Definition,
+			"rules":      schema.Rules,
+			"settings":   schema.Settings,
 		})
 	}
 }
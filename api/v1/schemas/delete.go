@@ -1,31 +1,122 @@
 package schemasRoutes
 
 import (
+	"slices"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
 	"go.uber.org/zap"
 )
 
-func DeleteSchema(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+// DeleteSchema moves a schema to the trash. It refuses while the schema still has
+// content or is referenced by other schemas, unless called with ?cascade=true,
+// in which case its content is trashed along with it.
+func DeleteSchema(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		id := c.Params("id")
 		uuidID, err := uuid.Parse(id)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": "Invalid UUID",
 			})
 		}
 		// Use the request context (helps with cancellation, tracing, etc.)
 		ctx := c.Context()
 
-		err = queries.DeleteSchema(ctx, uuidID)
+		// The dependencies are checked in the transaction of the delete with the
+		// schemas locked, so no reference or content is added before it commits.
+		// Trash the schema and its content with the same timestamp so a restore
+		// brings back exactly what this delete removed.
+		tx, err := pool.Begin(ctx)
 		if err != nil {
+			logger.Error("Failed to begin transaction", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to delete schema",
+			})
+		}
+		defer tx.Rollback(ctx)
+
+		qtx := queries.WithTx(tx)
+		schemas, err := qtx.LockSchemas(ctx)
+		if err != nil {
+			logger.Error("Failed to fetch schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch schema",
+			})
+		}
+		i := slices.IndexFunc(schemas, func(s db.Schema) bool { return s.ID == uuidID })
+		if i < 0 {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "Failed to find schemas",
 			})
 		}
+		schema := schemas[i]
+
+		pgID := pgtype.UUID{Bytes: schema.ID, Valid: true}
+		count, err := qtx.CountContentsBySchema(ctx, pgID)
+		if err != nil {
+			logger.Error("Failed to count contents", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check schema dependencies",
+			})
+		}
 
-		return c.Status(fiber.StatusOK).Send([]byte("Ok"))
+		referencedBy := referencingSchemas(schemas, schema.Name)
+		cascade := c.QueryBool("cascade")
+		if !cascade && (count > 0 || len(referencedBy) > 0) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":        "Schema has dependencies, pass cascade=true to delete anyway",
+				"contentCount": count,
+				"referencedBy": referencedBy,
+			})
+		}
+
+		deleted, err := qtx.DeleteSchema(ctx, schema.ID)
+		if err == nil {
+			err = qtx.DeleteContentsBySchema(ctx, db.DeleteContentsBySchemaParams{
+				SchemaID:  pgID,
+				DeletedAt: deleted.DeletedAt,
+			})
+		}
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			logger.Error("Failed to delete schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to delete schema",
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message":        "Schema moved to trash",
+			"deletedAt":      deleted.DeletedAt,
+			"trashedContent": count,
+		})
+	}
+}
+
+// referencingSchemas lists the other schemas with reference fields pointing at name
+func referencingSchemas(schemas []db.Schema, name string) []string {
+	referencedBy := []string{}
+	for _, s := range schemas {
+		if s.Name == name {
+			continue
+		}
+		fields, err := utils.ParseFields(s.Definition)
+		if err != nil {
+			continue
+		}
+		for _, f := range fields {
+			if f.Ref == name {
+				referencedBy = append(referencedBy, s.Name)
+				break
+			}
+		}
 	}
+	return referencedBy
 }
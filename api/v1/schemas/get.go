@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
 	"go.uber.org/zap"
 )
 
@@ -33,6 +34,9 @@ func GetSchemaByID(queries *db.Queries, logger *zap.Logger) fiber.Handler {
 			"name":       schema.Name,
 			"createdBy":  schema.CreatedBy,
 			"definition": schema.Definition,
+			"rules":      schema.Rules,
+			"settings":   schema.Settings,
+			"layout":     schemaLayout(schema),
 			"createdAt":  schema.CreatedAt,
 			"updatedAt":  schema.UpdatedAt,
 		})
@@ -56,8 +60,20 @@ func GetSchemaByName(queries *db.Queries, logger *zap.Logger) fiber.Handler {
 			"name":       schema.Name,
 			"createdBy":  schema.CreatedBy,
 			"definition": schema.Definition,
+			"rules":      schema.Rules,
+			"settings":   schema.Settings,
+			"layout":     schemaLayout(schema),
 			"createdAt":  schema.CreatedAt,
 			"updatedAt":  schema.UpdatedAt,
 		})
 	}
 }
+
+// schemaLayout groups the schema's fields into tabs and fieldsets for the admin UI
+func schemaLayout(schema db.Schema) []utils.LayoutGroup {
+	fields, err := utils.ParseFields(schema.Definition)
+	if err != nil {
+		return nil
+	}
+	return utils.Layout(fields)
+}
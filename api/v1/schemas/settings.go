@@ -0,0 +1,51 @@
+// Send post request on the url /api/v1/schemas/settings/:id with the full settings object:
+// { "revisions": { "maxCount": 50, "maxAgeDays": 90 }, "cache": { "cacheControl": "public, max-age=60", "surrogateKeys": ["blog"] } }
+
+package schemasRoutes
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+// UpdateSchemaSettings replaces the settings of a schema
+func UpdateSchemaSettings(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+		}
+
+		if _, err := utils.ParseSettings(c.Body()); err != nil || len(c.Body()) == 0 {
+			msg := "settings object is required"
+			if err != nil {
+				msg = err.Error()
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+		}
+
+		schema, err := queries.UpdateSchemaSettings(c.Context(), db.UpdateSchemaSettingsParams{
+			ID:       id,
+			Settings: c.Body(),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "schema not found"})
+			}
+			logger.Error("could not update schema settings", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update schema settings"})
+		}
+
+		return c.JSON(fiber.Map{
+			"id":       schema.ID,
+			"name":     schema.Name,
+			"settings": schema.Settings,
+		})
+	}
+}
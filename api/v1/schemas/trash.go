@@ -0,0 +1,101 @@
+package schemasRoutes
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// ListDeletedSchemas lists schemas waiting in the trash for the purge job
+func ListDeletedSchemas(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		schemas, err := queries.ListDeletedSchemas(c.Context())
+		if err != nil {
+			logger.Error("Failed to fetch deleted schemas", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch schemas",
+			})
+		}
+
+		if len(schemas) == 0 {
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Trash is empty",
+				"data":    []interface{}{},
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"count": len(schemas),
+			"data":  schemas,
+		})
+	}
+}
+
+// RestoreSchema brings a trashed schema back together with the content
+// that was trashed by the same delete
+func RestoreSchema(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+		}
+		ctx := c.Context()
+
+		trashed, err := queries.GetDeletedSchemaByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "schema not found in trash"})
+			}
+			logger.Error("failed to fetch schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not fetch schema"})
+		}
+
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			logger.Error("failed to begin transaction", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not restore schema"})
+		}
+		defer tx.Rollback(ctx)
+
+		qtx := queries.WithTx(tx)
+		schema, err := qtx.RestoreSchema(ctx, id)
+		if err == nil {
+			err = qtx.RestoreContentsBySchema(ctx, db.RestoreContentsBySchemaParams{
+				SchemaID:  pgtype.UUID{Bytes: id, Valid: true},
+				DeletedAt: trashed.DeletedAt,
+			})
+		}
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.TableName == "content_unique_values" {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "restored entries reuse values of unique field " + pgErr.ColumnName})
+			}
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "a schema named " + trashed.Name + " already exists"})
+			}
+			logger.Error("failed to restore schema", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not restore schema"})
+		}
+
+		return c.JSON(fiber.Map{
+			"id":         schema.ID,
+			"name":       schema.Name,
+			"createdBy":  schema.CreatedBy,
+			"definition": schema.Definition,
+			"rules":      schema.Rules,
+			"settings":   schema.Settings,
+			"createdAt":  schema.CreatedAt,
+			"updatedAt":  schema.UpdatedAt,
+		})
+	}
+}
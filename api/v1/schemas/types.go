@@ -0,0 +1,44 @@
+package schemasRoutes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils/codegen"
+	"go.uber.org/zap"
+)
+
+// GenerateTypes renders every schema as TypeScript interfaces or Go structs.
+// Query params: lang=ts|go (default ts), package=<go package name>
+func GenerateTypes(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		schemas, err := queries.ListSchemas(c.Context())
+		if err != nil {
+			logger.Error("Failed to fetch schemas", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch schemas",
+			})
+		}
+
+		var out string
+		switch c.Query("lang", "ts") {
+		case "ts", "typescript":
+			out, err = codegen.TypeScript(schemas)
+		case "go":
+			out, err = codegen.Go(schemas, c.Query("package", "models"))
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "lang must be ts or go",
+			})
+		}
+
+		if err != nil {
+			logger.Error("Failed to generate types", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to generate types",
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(out)
+	}
+}
@@ -0,0 +1,64 @@
+// Send post request on the url /api/v1/taxonomies/create with body like below:
+// {
+// 	"name": "categories",
+// 	"hierarchical": true
+// }
+
+package taxonomies
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+var taxonomyName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// CreateTaxonomy adds a flat tag set, or a tree of categories when hierarchical
+func CreateTaxonomy(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body struct {
+			Name         string `json:"name"`
+			Hierarchical bool   `json:"hierarchical"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+		if !taxonomyName.MatchString(body.Name) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "a name of lowercase letters, digits, - and _ is required"})
+		}
+
+		_, err := queries.GetTaxonomyByName(c.Context(), body.Name)
+		if err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "a taxonomy with this name already exists"})
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("failed to fetch taxonomy", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create taxonomy"})
+		}
+
+		userID, err := uuid.Parse(c.Locals("claims").(jwt.MapClaims)["user_id"].(string))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+		}
+
+		taxonomy, err := queries.CreateTaxonomy(c.Context(), db.CreateTaxonomyParams{
+			Name:         body.Name,
+			Hierarchical: body.Hierarchical,
+			CreatedBy:    pgtype.UUID{Bytes: userID, Valid: true},
+		})
+		if err != nil {
+			logger.Error("could not create taxonomy", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create taxonomy"})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(formatTaxonomy(taxonomy))
+	}
+}
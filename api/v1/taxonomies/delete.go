@@ -0,0 +1,68 @@
+package taxonomies
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+// DeleteTaxonomy removes a taxonomy and its terms. It refuses while fields of
+// a schema, trashed ones included, pick terms from it.
+func DeleteTaxonomy(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		taxonomy, err := fetchTaxonomy(c, queries, logger)
+		if err != nil {
+			return err
+		}
+
+		usedBy, err := usingSchemas(c.Context(), queries, taxonomy.Name)
+		if err != nil {
+			logger.Error("failed to check taxonomy usage", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete taxonomy"})
+		}
+		if len(usedBy) > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":  "taxonomy is used by schemas",
+				"usedBy": usedBy,
+			})
+		}
+
+		if err := queries.DeleteTaxonomy(c.Context(), taxonomy.ID); err != nil {
+			logger.Error("could not delete taxonomy", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete taxonomy"})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Taxonomy deleted successfully"})
+	}
+}
+
+// usingSchemas lists the schemas with taxonomy fields of name
+func usingSchemas(ctx context.Context, queries *db.Queries, name string) ([]string, error) {
+	live, err := queries.ListSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := queries.ListDeletedSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usedBy := []string{}
+	for _, s := range slices.Concat(live, trashed) {
+		fields, err := utils.ParseFields(s.Definition)
+		if err != nil {
+			continue
+		}
+		for _, f := range fields {
+			if f.Taxonomy == name {
+				usedBy = append(usedBy, s.Name)
+				break
+			}
+		}
+	}
+	return usedBy, nil
+}
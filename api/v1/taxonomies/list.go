@@ -0,0 +1,122 @@
+package taxonomies
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+func ListTaxonomies(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		taxonomies, err := queries.ListTaxonomies(c.Context())
+		if err != nil {
+			logger.Error("Failed to fetch taxonomies", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch taxonomies",
+			})
+		}
+
+		result := make([]fiber.Map, 0, len(taxonomies))
+		for _, t := range taxonomies {
+			result = append(result, formatTaxonomy(t))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"count": len(result),
+			"data":  result,
+		})
+	}
+}
+
+// GetTaxonomy returns a taxonomy with its terms, nested under their parents
+// in a hierarchical one
+func GetTaxonomy(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		taxonomy, err := fetchTaxonomy(c, queries, logger)
+		if err != nil {
+			return err
+		}
+
+		terms, err := queries.ListTaxonomyTerms(c.Context(), taxonomy.ID)
+		if err != nil {
+			logger.Error("Failed to fetch terms", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch terms",
+			})
+		}
+
+		result := formatTaxonomy(taxonomy)
+		result["terms"] = termTree(terms, taxonomy.Hierarchical)
+		return c.Status(fiber.StatusOK).JSON(result)
+	}
+}
+
+// fetchTaxonomy loads the taxonomy of the :name param. On error the response was already sent.
+func fetchTaxonomy(c *fiber.Ctx, queries *db.Queries, logger *zap.Logger) (db.Taxonomy, error) {
+	taxonomy, err := queries.GetTaxonomyByName(c.Context(), c.Params("name"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return taxonomy, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "taxonomy not found"})
+	}
+	if err != nil {
+		logger.Error("failed to fetch taxonomy", zap.Error(err))
+		return taxonomy, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not fetch taxonomy"})
+	}
+	return taxonomy, nil
+}
+
+// termTree lists terms in order, with each term's children under it when nested
+func termTree(terms []db.TaxonomyTerm, nested bool) []fiber.Map {
+	slugs := make(map[uuid.UUID]string, len(terms))
+	for _, t := range terms {
+		slugs[t.ID] = t.Slug
+	}
+
+	byID := make(map[uuid.UUID]fiber.Map, len(terms))
+	for _, t := range terms {
+		byID[t.ID] = formatTerm(t, slugs[t.ParentID.Bytes])
+		if nested {
+			byID[t.ID]["children"] = []fiber.Map{}
+		}
+	}
+
+	roots := []fiber.Map{}
+	for _, t := range terms {
+		term := byID[t.ID]
+		if parent, ok := byID[t.ParentID.Bytes]; nested && t.ParentID.Valid && ok {
+			parent["children"] = append(parent["children"].([]fiber.Map), term)
+			continue
+		}
+		roots = append(roots, term)
+	}
+	return roots
+}
+
+func formatTaxonomy(t db.Taxonomy) fiber.Map {
+	return fiber.Map{
+		"id":           t.ID,
+		"name":         t.Name,
+		"hierarchical": t.Hierarchical,
+		"createdAt":    t.CreatedAt,
+		"updatedAt":    t.UpdatedAt,
+	}
+}
+
+func formatTerm(t db.TaxonomyTerm, parent string) fiber.Map {
+	var p interface{}
+	if parent != "" {
+		p = parent
+	}
+	return fiber.Map{
+		"id":        t.ID,
+		"slug":      t.Slug,
+		"name":      t.Name,
+		"parent":    p,
+		"position":  t.Position,
+		"createdAt": t.CreatedAt,
+		"updatedAt": t.UpdatedAt,
+	}
+}
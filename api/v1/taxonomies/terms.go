@@ -0,0 +1,281 @@
+// Send post request on the url /api/v1/taxonomies/categories/terms with body like below:
+// {
+// 	"name": "Web Development",
+// 	"slug": "web-development",
+// 	"parent": "programming",
+// 	"position": 2
+// }
+
+package taxonomies
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify derives a slug from a term's name, empty when nothing is left
+func slugify(name string) string {
+	return strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// CreateTerm adds a term to a taxonomy. The slug defaults to one made from the name.
+func CreateTerm(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		taxonomy, err := fetchTaxonomy(c, queries, logger)
+		if err != nil {
+			return err
+		}
+
+		var body struct {
+			Slug     string `json:"slug"`
+			Name     string `json:"name"`
+			Parent   string `json:"parent"`
+			Position int32  `json:"position"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+		if body.Name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
+		}
+		if body.Slug == "" {
+			body.Slug = slugify(body.Name)
+		}
+		if !utils.Slug.MatchString(body.Slug) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "slug must be lowercase letters and digits separated by -"})
+		}
+
+		if _, err := queries.GetTaxonomyTerm(c.Context(), db.GetTaxonomyTermParams{TaxonomyID: taxonomy.ID, Slug: body.Slug}); err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "a term with this slug already exists"})
+		}
+
+		var parent db.TaxonomyTerm
+		if body.Parent != "" {
+			if !taxonomy.Hierarchical {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "terms of a flat taxonomy have no parent"})
+			}
+			parent, err = queries.GetTaxonomyTerm(c.Context(), db.GetTaxonomyTermParams{TaxonomyID: taxonomy.ID, Slug: body.Parent})
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unknown parent term " + body.Parent})
+			}
+		}
+
+		term, err := queries.CreateTaxonomyTerm(c.Context(), db.CreateTaxonomyTermParams{
+			TaxonomyID: taxonomy.ID,
+			ParentID:   pgtype.UUID{Bytes: parent.ID, Valid: body.Parent != ""},
+			Slug:       body.Slug,
+			Name:       body.Name,
+			Position:   body.Position,
+		})
+		if err != nil {
+			logger.Error("could not create term", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create term"})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(formatTerm(term, body.Parent))
+	}
+}
+
+// UpdateTerm renames, re-slugs, moves or reorders a term; fields left out
+// stay as they are and "parent": "" moves a term to the top. A new slug is
+// written into every entry holding the old one, trashed ones included.
+func UpdateTerm(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		taxonomy, err := fetchTaxonomy(c, queries, logger)
+		if err != nil {
+			return err
+		}
+
+		var body struct {
+			Slug     *string `json:"slug"`
+			Name     *string `json:"name"`
+			Parent   *string `json:"parent"`
+			Position *int32  `json:"position"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+
+		terms, err := queries.ListTaxonomyTerms(c.Context(), taxonomy.ID)
+		if err != nil {
+			logger.Error("failed to fetch terms", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update term"})
+		}
+		bySlug := make(map[string]db.TaxonomyTerm, len(terms))
+		byID := make(map[uuid.UUID]db.TaxonomyTerm, len(terms))
+		for _, t := range terms {
+			bySlug[t.Slug] = t
+			byID[t.ID] = t
+		}
+		term, ok := bySlug[c.Params("slug")]
+		if !ok {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "term not found"})
+		}
+
+		params := db.UpdateTaxonomyTermParams{
+			ID:       term.ID,
+			ParentID: term.ParentID,
+			Slug:     term.Slug,
+			Name:     term.Name,
+			Position: term.Position,
+		}
+		if body.Name != nil {
+			if *body.Name == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name must not be empty"})
+			}
+			params.Name = *body.Name
+		}
+		if body.Position != nil {
+			params.Position = *body.Position
+		}
+		if body.Slug != nil && *body.Slug != term.Slug {
+			if !utils.Slug.MatchString(*body.Slug) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "slug must be lowercase letters and digits separated by -"})
+			}
+			if _, taken := bySlug[*body.Slug]; taken {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "a term with this slug already exists"})
+			}
+			params.Slug = *body.Slug
+		}
+		if body.Parent != nil {
+			params.ParentID = pgtype.UUID{}
+			if *body.Parent != "" {
+				if !taxonomy.Hierarchical {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "terms of a flat taxonomy have no parent"})
+				}
+				parent, ok := bySlug[*body.Parent]
+				if !ok {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unknown parent term " + *body.Parent})
+				}
+				// Walk up from the new parent, the term must not be on the way
+				for p := parent; ; p = byID[p.ParentID.Bytes] {
+					if p.ID == term.ID {
+						return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "a term cannot move below itself"})
+					}
+					if !p.ParentID.Valid {
+						break
+					}
+				}
+				params.ParentID = pgtype.UUID{Bytes: parent.ID, Valid: true}
+			}
+		}
+
+		ctx := c.Context()
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			logger.Error("Failed to begin transaction", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update term"})
+		}
+		defer tx.Rollback(ctx)
+
+		qtx := queries.WithTx(tx)
+		updated, err := qtx.UpdateTaxonomyTerm(ctx, params)
+		var renamed int64
+		if err == nil && updated.Slug != term.Slug {
+			renamed, err = qtx.RenameTermInContents(ctx, db.RenameTermInContentsParams{
+				Author:   userID(c),
+				Taxonomy: taxonomy.Name,
+				OldSlug:  term.Slug,
+				NewSlug:  updated.Slug,
+			})
+		}
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			logger.Error("could not update term", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update term"})
+		}
+
+		result := formatTerm(updated, byID[updated.ParentID.Bytes].Slug)
+		result["contentsUpdated"] = renamed
+		return c.Status(fiber.StatusOK).JSON(result)
+	}
+}
+
+// DeleteTerm removes a term that no entry holds, live or in a draft.
+// Its children move up to its parent.
+func DeleteTerm(queries *db.Queries, logger *zap.Logger, pool *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		taxonomy, err := fetchTaxonomy(c, queries, logger)
+		if err != nil {
+			return err
+		}
+
+		term, err := queries.GetTaxonomyTerm(c.Context(), db.GetTaxonomyTermParams{TaxonomyID: taxonomy.ID, Slug: c.Params("slug")})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "term not found"})
+		}
+		if err != nil {
+			logger.Error("failed to fetch term", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete term"})
+		}
+
+		ctx := c.Context()
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			logger.Error("Failed to begin transaction", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete term"})
+		}
+		defer tx.Rollback(ctx)
+
+		// Count with the term and its entries locked, so no rename or
+		// edit can slip in between the check and the delete
+		qtx := queries.WithTx(tx)
+		if _, err := qtx.LockTaxonomyTerm(ctx, term.ID); errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "term not found"})
+		} else if err != nil {
+			logger.Error("failed to lock term", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete term"})
+		}
+		count, err := qtx.CountTermUsage(ctx, db.CountTermUsageParams{Taxonomy: taxonomy.Name, Slug: term.Slug})
+		if err != nil {
+			logger.Error("failed to count term usage", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete term"})
+		}
+		if count > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":        "term is used by entries",
+				"contentCount": count,
+			})
+		}
+
+		err = qtx.MoveTaxonomyTermChildren(ctx, db.MoveTaxonomyTermChildrenParams{
+			NewParentID: term.ParentID,
+			ParentID:    pgtype.UUID{Bytes: term.ID, Valid: true},
+		})
+		if err == nil {
+			err = qtx.DeleteTaxonomyTerm(ctx, term.ID)
+		}
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			logger.Error("could not delete term", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete term"})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Term deleted successfully"})
+	}
+}
+
+// userID is the signed in user, set by auth.ProtectedRoute
+func userID(c *fiber.Ctx) pgtype.UUID {
+	claims, _ := c.Locals("claims").(jwt.MapClaims)
+	id, _ := claims["user_id"].(string)
+	parsed, err := uuid.Parse(id)
+	return pgtype.UUID{Bytes: parsed, Valid: err == nil}
+}
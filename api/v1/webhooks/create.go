@@ -0,0 +1,132 @@
+// Send post request on the url /api/v1/webhooks/create with body like below:
+// {
+// 	"name": "Rebuild site",
+// 	"url": "https://example.com/hooks/nota",
+// 	"events": ["content.published", "content.unpublished"],
+// 	"schemas": ["blog"],
+// 	"active": true,
+// 	"secret": "optional, generated when left out"
+// }
+// Empty events or schemas subscribe to all of them.
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+type webhookBody struct {
+	Name    string   `json:"name"`
+	Url     string   `json:"url"`
+	Events  []string `json:"events"`
+	Schemas []string `json:"schemas"`
+	Active  *bool    `json:"active"`
+	Secret  string   `json:"secret"`
+}
+
+func CreateWebhook(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body webhookBody
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+
+		if body.Name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
+		}
+		if err := checkWebhook(body.Url, body.Events); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		schemaIDs, err := resolveSchemas(c.Context(), queries, body.Schemas)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if body.Secret == "" {
+			if body.Secret, err = utils.NewWebhookSecret(); err != nil {
+				logger.Error("could not generate webhook secret", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create webhook"})
+			}
+		}
+
+		webhook, err := queries.CreateWebhook(c.Context(), db.CreateWebhookParams{
+			Name:      body.Name,
+			Url:       body.Url,
+			Secret:    body.Secret,
+			Events:    orEmpty(body.Events),
+			SchemaIds: schemaIDs,
+			Active:    body.Active == nil || *body.Active,
+			CreatedBy: userID(c),
+		})
+		if err != nil {
+			logger.Error("could not create webhook", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create webhook"})
+		}
+
+		// the secret is only ever shown here, so receivers can verify signatures
+		result := formatWebhook(webhook, namesOf(schemaIDs, body.Schemas))
+		result["secret"] = webhook.Secret
+		return c.Status(fiber.StatusOK).JSON(result)
+	}
+}
+
+// checkWebhook validates the target url and the subscribed events
+func checkWebhook(target string, events []string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("a valid http(s) url is required")
+	}
+	for _, e := range events {
+		if !slices.Contains(utils.WebhookEvents, e) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// resolveSchemas turns schema names into IDs
+func resolveSchemas(ctx context.Context, queries *db.Queries, names []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(names))
+	for _, name := range names {
+		s, err := queries.GetSchemaByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown schema %q", name)
+		}
+		ids = append(ids, s.ID)
+	}
+	return ids, nil
+}
+
+func namesOf(ids []uuid.UUID, names []string) map[uuid.UUID]string {
+	m := make(map[uuid.UUID]string, len(ids))
+	for i, id := range ids {
+		m[id] = names[i]
+	}
+	return m
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// userID is the signed in user, set by auth.ProtectedRoute
+func userID(c *fiber.Ctx) pgtype.UUID {
+	claims, _ := c.Locals("claims").(jwt.MapClaims)
+	id, _ := claims["user_id"].(string)
+	parsed, err := uuid.Parse(id)
+	return pgtype.UUID{Bytes: parsed, Valid: err == nil}
+}
@@ -0,0 +1,29 @@
+package webhooks
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// DeleteWebhook removes a webhook together with its delivery log
+func DeleteWebhook(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid webhook id"})
+		}
+
+		n, err := queries.DeleteWebhook(c.Context(), id)
+		if err != nil {
+			logger.Error("could not delete webhook", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete webhook"})
+		}
+		if n == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Webhook deleted successfully"})
+	}
+}
@@ -0,0 +1,120 @@
+package webhooks
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils/listquery"
+	"go.uber.org/zap"
+)
+
+// ListDeliveries returns the delivery log of a webhook, latest first,
+// only those with a given status with ?status=pending|delivered|dead
+func ListDeliveries(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid webhook id"})
+		}
+
+		limit := c.QueryInt("limit", 50)
+		offset := c.QueryInt("offset", 0)
+		if limit < 1 || limit > listquery.MaxLimit || offset < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid limit or offset"})
+		}
+
+		status := c.Query("status")
+		switch status {
+		case "", "pending", "delivered", "dead":
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be pending, delivered or dead"})
+		}
+		statusFilter := pgtype.Text{String: status, Valid: status != ""}
+
+		deliveries, err := queries.ListWebhookDeliveries(c.Context(), db.ListWebhookDeliveriesParams{
+			WebhookID:  id,
+			Status:     statusFilter,
+			PageSize:   int32(limit),
+			PageOffset: int32(offset),
+		})
+		if err != nil {
+			logger.Error("Error fetching webhook deliveries", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching webhook deliveries"})
+		}
+		total, err := queries.CountWebhookDeliveries(c.Context(), db.CountWebhookDeliveriesParams{
+			WebhookID: id,
+			Status:    statusFilter,
+		})
+		if err != nil {
+			logger.Error("Error counting webhook deliveries", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching webhook deliveries"})
+		}
+
+		result := make([]fiber.Map, 0, len(deliveries))
+		for _, d := range deliveries {
+			result = append(result, formatDelivery(d))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"count": len(result),
+			"total": total,
+			"data":  result,
+		})
+	}
+}
+
+// Redeliver queues a copy of a delivery, whatever its status. The copy gets
+// a fresh set of attempts; the original stays in the log as it was.
+func Redeliver(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("delivery_id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid delivery id"})
+		}
+
+		delivery, err := queries.RedeliverWebhook(c.Context(), id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "delivery not found"})
+			}
+			logger.Error("could not redeliver webhook", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not redeliver webhook"})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(formatDelivery(delivery))
+	}
+}
+
+func formatDelivery(d db.WebhookDelivery) fiber.Map {
+	result := fiber.Map{
+		"id":             d.ID,
+		"webhookID":      d.WebhookID,
+		"event":          d.Event,
+		"payload":        d.Payload,
+		"status":         d.Status,
+		"attempts":       d.Attempts,
+		"responseStatus": nil,
+		"responseBody":   nil,
+		"error":          nil,
+		"nextAttemptAt":  nil,
+		"createdAt":      d.CreatedAt,
+		"deliveredAt":    d.DeliveredAt,
+	}
+	if d.ResponseStatus.Valid {
+		result["responseStatus"] = d.ResponseStatus.Int32
+	}
+	if d.ResponseBody.Valid {
+		result["responseBody"] = d.ResponseBody.String
+	}
+	if d.Error.Valid {
+		result["error"] = d.Error.String
+	}
+	if d.Status == "pending" {
+		result["nextAttemptAt"] = d.NextAttemptAt
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// ListWebhooks returns every webhook, without their secrets
+func ListWebhooks(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		webhooks, err := queries.ListWebhooks(c.Context())
+		if err != nil {
+			logger.Error("Failed to fetch webhooks", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch webhooks",
+			})
+		}
+
+		names, err := schemaNames(c.Context(), queries)
+		if err != nil {
+			logger.Error("Failed to fetch schemas", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch schemas",
+			})
+		}
+
+		result := make([]fiber.Map, 0, len(webhooks))
+		for _, w := range webhooks {
+			result = append(result, formatWebhook(w, names))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"count": len(result),
+			"data":  result,
+		})
+	}
+}
+
+func formatWebhook(w db.Webhook, names map[uuid.UUID]string) fiber.Map {
+	schemas := make([]string, 0, len(w.SchemaIds))
+	for _, id := range w.SchemaIds {
+		if name, ok := names[id]; ok {
+			schemas = append(schemas, name)
+		}
+	}
+	return fiber.Map{
+		"id":        w.ID,
+		"name":      w.Name,
+		"url":       w.Url,
+		"events":    w.Events,
+		"schemas":   schemas,
+		"active":    w.Active,
+		"createdAt": w.CreatedAt,
+		"updatedAt": w.UpdatedAt,
+	}
+}
+
+// schemaNames maps schema IDs to names, webhooks store IDs so renames keep working
+func schemaNames(ctx context.Context, queries *db.Queries) (map[uuid.UUID]string, error) {
+	schemas, err := queries.ListSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(schemas))
+	for _, s := range schemas {
+		names[s.ID] = s.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/zap"
+)
+
+// UpdateWebhook changes a webhook, fields left out of the body keep their
+// value. A new secret replaces the old one right away.
+func UpdateWebhook(queries *db.Queries, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid webhook id"})
+		}
+
+		var body webhookBody
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+
+		current, err := queries.GetWebhook(c.Context(), id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+			}
+			logger.Error("failed to fetch webhook", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not fetch webhook"})
+		}
+
+		if body.Name == "" {
+			body.Name = current.Name
+		}
+		if body.Url == "" {
+			body.Url = current.Url
+		}
+		if body.Events == nil {
+			body.Events = current.Events
+		}
+		if err := checkWebhook(body.Url, body.Events); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		schemaIDs := current.SchemaIds
+		if body.Schemas != nil {
+			if schemaIDs, err = resolveSchemas(c.Context(), queries, body.Schemas); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		active := current.Active
+		if body.Active != nil {
+			active = *body.Active
+		}
+
+		webhook, err := queries.UpdateWebhook(c.Context(), db.UpdateWebhookParams{
+			Name:      body.Name,
+			Url:       body.Url,
+			Events:    orEmpty(body.Events),
+			SchemaIds: schemaIDs,
+			Active:    active,
+			Secret:    pgtype.Text{String: body.Secret, Valid: body.Secret != ""},
+			ID:        id,
+		})
+		if err != nil {
+			logger.Error("could not update webhook", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update webhook"})
+		}
+
+		names, err := schemaNames(c.Context(), queries)
+		if err != nil {
+			logger.Error("Failed to fetch schemas", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch schemas"})
+		}
+		return c.Status(fiber.StatusOK).JSON(formatWebhook(webhook, names))
+	}
+}
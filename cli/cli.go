@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	postgres "github.com/manthan307/nota-cms/db"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/logger"
+	"go.uber.org/zap"
+)
+
+type command func(queries *db.Queries, logger *zap.Logger, args []string) error
+
+var commands = map[string]command{
+	"typegen": typegen,
+	"export":  export,
+	"import":  importFile,
+}
+
+// IsCommand reports whether name is a known CLI subcommand
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes a subcommand like `nota-cms typegen -lang ts` and returns the exit code
+func Run(args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		return 2
+	}
+
+	log := logger.InitLogger()
+	defer log.Sync()
+
+	pool := postgres.Connect(log)
+	defer pool.Close()
+
+	if err := cmd(db.New(pool), log, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
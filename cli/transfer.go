@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	contentRoutes "github.com/manthan307/nota-cms/api/v1/content"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils/transfer"
+	"go.uber.org/zap"
+)
+
+// export writes a schema's entries as CSV, NDJSON or JSON.
+//
+//	nota-cms export -schema products -format csv -out products.csv
+//	nota-cms export -schema products -format csv -locale de -out products-de.csv
+func export(queries *db.Queries, logger *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	schemaName := fs.String("schema", "", "schema to export")
+	format := fs.String("format", "", "csv, ndjson or json (default from -out, else ndjson)")
+	locale := fs.String("locale", "", "locale of the localized fields (default locale if empty)")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *schemaName == "" {
+		return errors.New("-schema is required")
+	}
+	if *format == "" {
+		*format = formatOf(*out)
+	}
+
+	ctx := context.Background()
+	schema, err := queries.GetSchemaByName(ctx, *schemaName)
+	if err != nil {
+		return fmt.Errorf("fetching schema %q: %w", *schemaName, err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+	if err := contentRoutes.ExportContents(ctx, queries, schema, *format, *locale, buf); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if *out != "" {
+		logger.Info("content exported", zap.String("schema", schema.Name), zap.String("file", *out))
+	}
+	return nil
+}
+
+// importFile creates or updates entries from a CSV, NDJSON or JSON file.
+// Rejected rows are written to -report.
+//
+//	nota-cms import -schema products -key sku -report errors.csv products.csv
+//	nota-cms import -schema products -locale de products-de.csv
+func importFile(queries *db.Queries, logger *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	schemaName := fs.String("schema", "", "schema to import into")
+	format := fs.String("format", "", "csv, ndjson or json (default from the file name)")
+	key := fs.String("key", "id", "id or the field that identifies existing entries")
+	locale := fs.String("locale", "", "import translations of this locale")
+	report := fs.String("report", "", "file for rejected rows (default import-errors.<ext>)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *schemaName == "" || fs.NArg() != 1 {
+		return errors.New("usage: import -schema name [-format f] [-key field] [-locale code] [-report file] file")
+	}
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = formatOf(path)
+	}
+
+	ctx := context.Background()
+	schema, err := queries.GetSchemaByName(ctx, *schemaName)
+	if err != nil {
+		return fmt.Errorf("fetching schema %q: %w", *schemaName, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := contentRoutes.ImportContents(ctx, queries, logger, schema, contentRoutes.ImportOptions{
+		Format: *format,
+		Key:    *key,
+		Locale: *locale,
+		User:   pgtype.UUID{},
+	}, bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+
+	fields := []zap.Field{
+		zap.String("schema", schema.Name),
+		zap.Int("total", result.Total),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("rejected", result.Rejected),
+	}
+	if result.Rejected > 0 {
+		if *report == "" {
+			ext := transfer.NDJSON
+			if *format == transfer.CSV {
+				ext = transfer.CSV
+			}
+			*report = "import-errors." + ext
+		}
+		if err := os.WriteFile(*report, result.Report, 0o644); err != nil {
+			return err
+		}
+		fields = append(fields, zap.String("report", *report))
+	}
+	logger.Info("content imported", fields...)
+	return nil
+}
+
+// formatOf guesses a file's format from its extension
+func formatOf(path string) string {
+	switch ext := strings.TrimPrefix(filepath.Ext(path), "."); ext {
+	case transfer.CSV, transfer.JSON:
+		return ext
+	}
+	return transfer.NDJSON
+}
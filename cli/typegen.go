@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils/codegen"
+	"go.uber.org/zap"
+)
+
+// typegen writes TypeScript or Go types for every schema.
+//
+//	nota-cms typegen -lang ts -out www/lib/content-types.ts
+//	nota-cms typegen -lang go -package models -out models/content.go
+func typegen(queries *db.Queries, logger *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("typegen", flag.ContinueOnError)
+	lang := fs.String("lang", "ts", "output language: ts or go")
+	pkg := fs.String("package", "models", "package name for Go output")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	schemas, err := queries.ListSchemas(context.Background())
+	if err != nil {
+		return fmt.Errorf("fetching schemas: %w", err)
+	}
+
+	var src string
+	switch *lang {
+	case "ts", "typescript":
+		src, err = codegen.TypeScript(schemas)
+	case "go":
+		src, err = codegen.Go(schemas, *pkg)
+	default:
+		return fmt.Errorf("unknown lang %q", *lang)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = fmt.Print(src)
+		return err
+	}
+	if err := os.WriteFile(*out, []byte(src), 0o644); err != nil {
+		return err
+	}
+	logger.Info("types written", zap.String("file", *out), zap.Int("schemas", len(schemas)))
+	return nil
+}
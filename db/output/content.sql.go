@@ -13,27 +13,123 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const bumpContentVersion = `-- name: BumpContentVersion :one
+UPDATE contents
+SET version = version + 1, workflow_state = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+AND ($2::int IS NULL OR version = $2::int)
+RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+`
+
+type BumpContentVersionParams struct {
+	ID              uuid.UUID
+	ExpectedVersion pgtype.Int4
+}
+
+// Marks a translation edit, which sends the entry back for review like any edit
+func (q *Queries) BumpContentVersion(ctx context.Context, arg BumpContentVersionParams) (Content, error) {
+	row := q.db.QueryRow(ctx, bumpContentVersion, arg.ID, arg.ExpectedVersion)
+	var i Content
+	err := row.Scan(
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	)
+	return i, err
+}
+
+const countContentsBySchema = `-- name: CountContentsBySchema :one
+SELECT COUNT(*) FROM contents
+WHERE schema_id = $1
+AND deleted_at IS NULL
+`
+
+func (q *Queries) CountContentsBySchema(ctx context.Context, schemaID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countContentsBySchema, schemaID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countDeletedContents = `-- name: CountDeletedContents :one
+SELECT COUNT(*) FROM contents
+WHERE schema_id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountDeletedContents(ctx context.Context, schemaID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedContents, schemaID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createContent = `-- name: CreateContent :one
-INSERT INTO contents (schema_id, data, created_by,published)
-VALUES ($1, $2, $3,$4)
-RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at
+WITH created AS (
+  INSERT INTO contents (schema_id, data, created_by, published, publish_at, unpublish_at)
+  SELECT s.id, $1, $2::uuid, $3::boolean,
+    $4::timestamptz, $5::timestamptz
+  FROM schemas s
+  WHERE s.id = $6 AND s.deleted_at IS NULL
+  FOR SHARE
+  RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, data, COALESCE(published, FALSE), created_by FROM created
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM created
 `
 
 type CreateContentParams struct {
-	SchemaID  pgtype.UUID
-	Data      json.RawMessage
-	CreatedBy pgtype.UUID
-	Published pgtype.Bool
+	Data        json.RawMessage
+	CreatedBy   pgtype.UUID
+	Published   pgtype.Bool
+	PublishAt   pgtype.Timestamptz
+	UnpublishAt pgtype.Timestamptz
+	SchemaID    uuid.UUID
+}
+
+type CreateContentRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
 }
 
-func (q *Queries) CreateContent(ctx context.Context, arg CreateContentParams) (Content, error) {
+// Inserts nothing when the schema is trashed. The share lock waits for a
+// schema delete in progress, see LockSchemas.
+func (q *Queries) CreateContent(ctx context.Context, arg CreateContentParams) (CreateContentRow, error) {
 	row := q.db.QueryRow(ctx, createContent,
-		arg.SchemaID,
 		arg.Data,
 		arg.CreatedBy,
 		arg.Published,
+		arg.PublishAt,
+		arg.UnpublishAt,
+		arg.SchemaID,
 	)
-	var i Content
+	var i CreateContentRow
 	err := row.Scan(
 		&i.ID,
 		&i.SchemaID,
@@ -43,23 +139,278 @@ func (q *Queries) CreateContent(ctx context.Context, arg CreateContentParams) (C
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
 	)
 	return i, err
 }
 
-const deleteContent = `-- name: DeleteContent :exec
+const createContents = `-- name: CreateContents :many
+WITH created AS (
+  INSERT INTO contents (id, schema_id, data, published, created_by)
+  SELECT n.id, n.schema_id, n.data, n.published, $1::uuid
+  FROM (
+    SELECT
+      unnest($2::uuid[]) AS id,
+      unnest($3::uuid[]) AS schema_id,
+      unnest($4::jsonb[]) AS data,
+      unnest($5::bool[]) AS published
+  ) n
+  -- rows of trashed schemas are left out, like CreateContent
+  WHERE EXISTS (
+    SELECT 1 FROM schemas s WHERE s.id = n.schema_id AND s.deleted_at IS NULL FOR SHARE
+  )
+  ON CONFLICT (id) DO NOTHING
+  RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+), revisions AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, data, published, created_by FROM created
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM created
+`
+
+type CreateContentsParams struct {
+	CreatedBy pgtype.UUID
+	Ids       []uuid.UUID
+	SchemaIds []uuid.UUID
+	Data      []json.RawMessage
+	Published []bool
+}
+
+type CreateContentsRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+func (q *Queries) CreateContents(ctx context.Context, arg CreateContentsParams) ([]CreateContentsRow, error) {
+	rows, err := q.db.Query(ctx, createContents,
+		arg.CreatedBy,
+		arg.Ids,
+		arg.SchemaIds,
+		arg.Data,
+		arg.Published,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreateContentsRow
+	for rows.Next() {
+		var i CreateContentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Published,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteContent = `-- name: DeleteContent :execrows
 UPDATE contents
 SET deleted_at = now()
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
+AND ($2::int IS NULL OR version = $2::int)
+`
+
+type DeleteContentParams struct {
+	ID              uuid.UUID
+	ExpectedVersion pgtype.Int4
+}
+
+func (q *Queries) DeleteContent(ctx context.Context, arg DeleteContentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteContent, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteContentsBySchema = `-- name: DeleteContentsBySchema :exec
+UPDATE contents
+SET deleted_at = $2
+WHERE schema_id = $1 AND deleted_at IS NULL
 `
 
-func (q *Queries) DeleteContent(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteContent, id)
+type DeleteContentsBySchemaParams struct {
+	SchemaID  pgtype.UUID
+	DeletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) DeleteContentsBySchema(ctx context.Context, arg DeleteContentsBySchemaParams) error {
+	_, err := q.db.Exec(ctx, deleteContentsBySchema, arg.SchemaID, arg.DeletedAt)
 	return err
 }
 
+const discardContentDraft = `-- name: DiscardContentDraft :one
+WITH updated AS (
+  UPDATE contents
+  SET draft_data = NULL, version = version + 1, updated_at = NOW()
+  WHERE contents.id = $1 AND deleted_at IS NULL
+  AND ($2::int IS NULL OR version = $2::int)
+  RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+), discarded AS (
+  UPDATE content_locales
+  SET draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT id FROM updated) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), $3::uuid FROM updated
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM updated
+`
+
+type DiscardContentDraftParams struct {
+	ID              uuid.UUID
+	ExpectedVersion pgtype.Int4
+	Author          pgtype.UUID
+}
+
+type DiscardContentDraftRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+func (q *Queries) DiscardContentDraft(ctx context.Context, arg DiscardContentDraftParams) (DiscardContentDraftRow, error) {
+	row := q.db.QueryRow(ctx, discardContentDraft, arg.ID, arg.ExpectedVersion, arg.Author)
+	var i DiscardContentDraftRow
+	err := row.Scan(
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	)
+	return i, err
+}
+
+const findContentsByField = `-- name: FindContentsByField :many
+SELECT id, (COALESCE(draft_data, data)->>$1::text)::text AS key_value FROM contents
+WHERE schema_id = $2 AND deleted_at IS NULL
+AND COALESCE(draft_data, data)->>$1::text = ANY($3::text[])
+`
+
+type FindContentsByFieldParams struct {
+	Field     string
+	SchemaID  pgtype.UUID
+	KeyValues []string
+}
+
+type FindContentsByFieldRow struct {
+	ID       uuid.UUID
+	KeyValue string
+}
+
+// Matches the working data, drafts included, which is what updates write to
+func (q *Queries) FindContentsByField(ctx context.Context, arg FindContentsByFieldParams) ([]FindContentsByFieldRow, error) {
+	rows, err := q.db.Query(ctx, findContentsByField, arg.Field, arg.SchemaID, arg.KeyValues)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindContentsByFieldRow
+	for rows.Next() {
+		var i FindContentsByFieldRow
+		if err := rows.Scan(&i.ID, &i.KeyValue); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findUniqueConflict = `-- name: FindUniqueConflict :one
+SELECT f.name::text AS field
+FROM unnest($1::text[]) AS f(name)
+JOIN content_unique_values u ON u.schema_id = $2 AND u.field = f.name
+  AND u.value = $3::jsonb->>f.name
+WHERE u.content_id <> $4
+LIMIT 1
+`
+
+type FindUniqueConflictParams struct {
+	Fields    []string
+	SchemaID  uuid.UUID
+	Data      json.RawMessage
+	ContentID uuid.UUID
+}
+
+func (q *Queries) FindUniqueConflict(ctx context.Context, arg FindUniqueConflictParams) (string, error) {
+	row := q.db.QueryRow(ctx, findUniqueConflict,
+		arg.Fields,
+		arg.SchemaID,
+		arg.Data,
+		arg.ContentID,
+	)
+	var field string
+	err := row.Scan(&field)
+	return field, err
+}
+
 const getAllContents = `-- name: GetAllContents :many
-SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at FROM contents
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
 WHERE deleted_at IS NULL
 ORDER BY created_at DESC
 `
@@ -82,6 +433,13 @@ func (q *Queries) GetAllContents(ctx context.Context) ([]Content, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
 		); err != nil {
 			return nil, err
 		}
@@ -94,7 +452,7 @@ func (q *Queries) GetAllContents(ctx context.Context) ([]Content, error) {
 }
 
 const getAllContentsBySchema = `-- name: GetAllContentsBySchema :many
-SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at FROM contents
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
 WHERE schema_id = $1
 AND deleted_at IS NULL
 ORDER BY created_at DESC
@@ -118,6 +476,13 @@ func (q *Queries) GetAllContentsBySchema(ctx context.Context, schemaID pgtype.UU
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
 		); err != nil {
 			return nil, err
 		}
@@ -130,7 +495,7 @@ func (q *Queries) GetAllContentsBySchema(ctx context.Context, schemaID pgtype.UU
 }
 
 const getContentByID = `-- name: GetContentByID :one
-SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at FROM contents
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
 WHERE id = $1 AND deleted_at IS NULL
 `
 
@@ -146,12 +511,78 @@ func (q *Queries) GetContentByID(ctx context.Context, id uuid.UUID) (Content, er
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
 	)
 	return i, err
 }
 
+const getContentIDByUniqueValue = `-- name: GetContentIDByUniqueValue :one
+SELECT content_id FROM content_unique_values
+WHERE schema_id = $1 AND field = $2 AND value = $3
+`
+
+type GetContentIDByUniqueValueParams struct {
+	SchemaID uuid.UUID
+	Field    string
+	Value    string
+}
+
+func (q *Queries) GetContentIDByUniqueValue(ctx context.Context, arg GetContentIDByUniqueValueParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, getContentIDByUniqueValue, arg.SchemaID, arg.Field, arg.Value)
+	var content_id uuid.UUID
+	err := row.Scan(&content_id)
+	return content_id, err
+}
+
+const getContentsByIDs = `-- name: GetContentsByIDs :many
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
+WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+`
+
+func (q *Queries) GetContentsByIDs(ctx context.Context, ids []uuid.UUID) ([]Content, error) {
+	rows, err := q.db.Query(ctx, getContentsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Content
+	for rows.Next() {
+		var i Content
+		if err := rows.Scan(
+			&i.ID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Published,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getContentsBySchema = `-- name: GetContentsBySchema :many
-SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at FROM contents
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
 WHERE schema_id = $1
 AND deleted_at IS NULL
 AND published = $2
@@ -181,6 +612,13 @@ func (q *Queries) GetContentsBySchema(ctx context.Context, arg GetContentsBySche
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
 		); err != nil {
 			return nil, err
 		}
@@ -192,24 +630,563 @@ func (q *Queries) GetContentsBySchema(ctx context.Context, arg GetContentsBySche
 	return items, nil
 }
 
-const updateContent = `-- name: UpdateContent :one
+const getDeletedContentByID = `-- name: GetDeletedContentByID :one
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
+WHERE contents.id = $1 AND contents.deleted_at IS NOT NULL
+AND contents.schema_id IN (SELECT s.id FROM schemas s WHERE s.deleted_at IS NULL)
+`
+
+func (q *Queries) GetDeletedContentByID(ctx context.Context, id uuid.UUID) (Content, error) {
+	row := q.db.QueryRow(ctx, getDeletedContentByID, id)
+	var i Content
+	err := row.Scan(
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	)
+	return i, err
+}
+
+const hasContentDraft = `-- name: HasContentDraft :one
+SELECT EXISTS (
+  SELECT 1 FROM contents WHERE id = $1 AND draft_data IS NOT NULL
+  UNION ALL
+  SELECT 1 FROM content_locales WHERE content_id = $1 AND draft_data IS NOT NULL
+)
+`
+
+func (q *Queries) HasContentDraft(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, hasContentDraft, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listDeletedContents = `-- name: ListDeletedContents :many
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
+WHERE schema_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type ListDeletedContentsParams struct {
+	SchemaID   pgtype.UUID
+	PageOffset int32
+	PageSize   int32
+}
+
+func (q *Queries) ListDeletedContents(ctx context.Context, arg ListDeletedContentsParams) ([]Content, error) {
+	rows, err := q.db.Query(ctx, listDeletedContents, arg.SchemaID, arg.PageOffset, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Content
+	for rows.Next() {
+		var i Content
+		if err := rows.Scan(
+			&i.ID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Published,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledContents = `-- name: ListScheduledContents :many
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
+WHERE deleted_at IS NULL
+AND (publish_at IS NOT NULL OR unpublish_at IS NOT NULL)
+AND ($1::uuid IS NULL OR schema_id = $1::uuid)
+ORDER BY LEAST(publish_at, unpublish_at)
+`
+
+func (q *Queries) ListScheduledContents(ctx context.Context, schemaID pgtype.UUID) ([]Content, error) {
+	rows, err := q.db.Query(ctx, listScheduledContents, schemaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Content
+	for rows.Next() {
+		var i Content
+		if err := rows.Scan(
+			&i.ID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Published,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSchemaContentsAfter = `-- name: ListSchemaContentsAfter :many
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM contents
+WHERE schema_id = $1 AND deleted_at IS NULL
+AND ($2::uuid IS NULL OR id > $2::uuid)
+ORDER BY id
+LIMIT $3
+`
+
+type ListSchemaContentsAfterParams struct {
+	SchemaID pgtype.UUID
+	AfterID  pgtype.UUID
+	PageSize int32
+}
+
+func (q *Queries) ListSchemaContentsAfter(ctx context.Context, arg ListSchemaContentsAfterParams) ([]Content, error) {
+	rows, err := q.db.Query(ctx, listSchemaContentsAfter, arg.SchemaID, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Content
+	for rows.Next() {
+		var i Content
+		if err := rows.Scan(
+			&i.ID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Published,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishContent = `-- name: PublishContent :one
+WITH updated AS (
+  UPDATE contents
+  SET
+    data = COALESCE(draft_data, data),
+    draft_data = NULL,
+    published = TRUE,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = $1 AND deleted_at IS NULL
+  AND ($2::int IS NULL OR version = $2::int)
+  RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT id FROM updated) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), $3::uuid FROM updated
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM updated
+`
+
+type PublishContentParams struct {
+	ID              uuid.UUID
+	ExpectedVersion pgtype.Int4
+	Author          pgtype.UUID
+}
+
+type PublishContentRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+func (q *Queries) PublishContent(ctx context.Context, arg PublishContentParams) (PublishContentRow, error) {
+	row := q.db.QueryRow(ctx, publishContent, arg.ID, arg.ExpectedVersion, arg.Author)
+	var i PublishContentRow
+	err := row.Scan(
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	)
+	return i, err
+}
+
+const purgeContent = `-- name: PurgeContent :execrows
+DELETE FROM contents
+WHERE contents.id = $1 AND contents.deleted_at IS NOT NULL
+AND contents.schema_id IN (SELECT s.id FROM schemas s WHERE s.deleted_at IS NULL)
+`
+
+func (q *Queries) PurgeContent(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeContent, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeDeletedContents = `-- name: PurgeDeletedContents :execrows
+DELETE FROM contents
+WHERE contents.deleted_at IS NOT NULL AND contents.deleted_at < $1
+AND contents.schema_id IN (SELECT s.id FROM schemas s WHERE s.deleted_at IS NULL)
+`
+
+func (q *Queries) PurgeDeletedContents(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedContents, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreContent = `-- name: RestoreContent :one
+WITH restored AS (
+  UPDATE contents
+  SET
+    deleted_at = NULL,
+    data = COALESCE(draft_data, data),
+    draft_data = NULL,
+    published = FALSE,
+    publish_at = NULL,
+    unpublish_at = NULL,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = $1 AND deleted_at IS NOT NULL
+  AND ($2::int IS NULL OR version = $2::int)
+  RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT r.id FROM restored r) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), $3::uuid FROM restored
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM restored
+`
+
+type RestoreContentParams struct {
+	ID              uuid.UUID
+	ExpectedVersion pgtype.Int4
+	Author          pgtype.UUID
+}
+
+type RestoreContentRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+func (q *Queries) RestoreContent(ctx context.Context, arg RestoreContentParams) (RestoreContentRow, error) {
+	row := q.db.QueryRow(ctx, restoreContent, arg.ID, arg.ExpectedVersion, arg.Author)
+	var i RestoreContentRow
+	err := row.Scan(
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	)
+	return i, err
+}
+
+const restoreContentsBySchema = `-- name: RestoreContentsBySchema :exec
 UPDATE contents
-SET 
-  data = $2,
-  published = $3,
-  updated_at = NOW()
-WHERE id = $1
-RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at
+SET deleted_at = NULL
+WHERE schema_id = $1 AND deleted_at = $2
 `
 
-type UpdateContentParams struct {
-	ID        uuid.UUID
-	Data      json.RawMessage
-	Published pgtype.Bool
+type RestoreContentsBySchemaParams struct {
+	SchemaID  pgtype.UUID
+	DeletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) RestoreContentsBySchema(ctx context.Context, arg RestoreContentsBySchemaParams) error {
+	_, err := q.db.Exec(ctx, restoreContentsBySchema, arg.SchemaID, arg.DeletedAt)
+	return err
+}
+
+const runDueSchedules = `-- name: RunDueSchedules :many
+WITH applied AS (
+  UPDATE contents
+  SET
+    published = due.live,
+    -- pending drafts go live with the entry
+    data = CASE WHEN due.live THEN COALESCE(draft_data, data) ELSE data END,
+    draft_data = CASE WHEN due.live THEN NULL ELSE draft_data END,
+    publish_at = CASE WHEN due.publish THEN NULL ELSE publish_at END,
+    unpublish_at = CASE WHEN due.unpublish THEN NULL ELSE unpublish_at END,
+    version = version + 1,
+    updated_at = NOW()
+  FROM (
+    SELECT c.id AS content_id, d.publish, d.unpublish,
+      NOT (d.unpublish AND (NOT d.publish OR c.unpublish_at >= c.publish_at)) AS live
+    FROM contents c
+    JOIN schemas s ON s.id = c.schema_id
+    CROSS JOIN LATERAL (
+      SELECT
+        COALESCE(c.publish_at <= NOW() AND (
+          s.settings->'workflow' IS NULL
+          OR s.settings->'workflow'->'publishFrom' @> to_jsonb(COALESCE(c.workflow_state, s.settings->'workflow'->>'initial', s.settings->'workflow'->'states'->>0))
+        ), FALSE) AS publish,
+        COALESCE(c.unpublish_at <= NOW(), FALSE) AS unpublish
+    ) d
+    WHERE c.deleted_at IS NULL AND (d.publish OR d.unpublish)
+    ORDER BY LEAST(c.publish_at, c.unpublish_at)
+    LIMIT $1
+    FOR UPDATE OF c SKIP LOCKED
+  ) due
+  WHERE contents.id = due.content_id
+  RETURNING contents.id, contents.schema_id, contents.data, contents.published, contents.created_by, contents.created_at, contents.updated_at, contents.deleted_at, contents.version, contents.publish_at, contents.unpublish_at, contents.workflow_state, contents.draft_data, contents.change_xid, contents.was_published
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT a.id FROM applied a WHERE a.published) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), NULL FROM applied
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM applied
+`
+
+type RunDueSchedulesRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+func (q *Queries) RunDueSchedules(ctx context.Context, limit int32) ([]RunDueSchedulesRow, error) {
+	rows, err := q.db.Query(ctx, runDueSchedules, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RunDueSchedulesRow
+	for rows.Next() {
+		var i RunDueSchedulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SchemaID,
+			&i.Data,
+			&i.Published,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.PublishAt,
+			&i.UnpublishAt,
+			&i.WorkflowState,
+			&i.DraftData,
+			&i.ChangeXid,
+			&i.WasPublished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const saveContentDraft = `-- name: SaveContentDraft :one
+WITH updated AS (
+  UPDATE contents
+  SET
+    draft_data = $1,
+    workflow_state = CASE WHEN COALESCE(draft_data, data) IS DISTINCT FROM $1 THEN NULL ELSE workflow_state END,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = $2 AND deleted_at IS NULL
+  AND ($3::int IS NULL OR version = $3::int)
+  RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), $4::uuid FROM updated
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM updated
+`
+
+type SaveContentDraftParams struct {
+	DraftData       []byte
+	ID              uuid.UUID
+	ExpectedVersion pgtype.Int4
+	Author          pgtype.UUID
+}
+
+type SaveContentDraftRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+func (q *Queries) SaveContentDraft(ctx context.Context, arg SaveContentDraftParams) (SaveContentDraftRow, error) {
+	row := q.db.QueryRow(ctx, saveContentDraft,
+		arg.DraftData,
+		arg.ID,
+		arg.ExpectedVersion,
+		arg.Author,
+	)
+	var i SaveContentDraftRow
+	err := row.Scan(
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	)
+	return i, err
+}
+
+const scheduleContent = `-- name: ScheduleContent :one
+UPDATE contents
+SET publish_at = $2, unpublish_at = $3
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+`
+
+type ScheduleContentParams struct {
+	ID          uuid.UUID
+	PublishAt   pgtype.Timestamptz
+	UnpublishAt pgtype.Timestamptz
 }
 
-func (q *Queries) UpdateContent(ctx context.Context, arg UpdateContentParams) (Content, error) {
-	row := q.db.QueryRow(ctx, updateContent, arg.ID, arg.Data, arg.Published)
+func (q *Queries) ScheduleContent(ctx context.Context, arg ScheduleContentParams) (Content, error) {
+	row := q.db.QueryRow(ctx, scheduleContent, arg.ID, arg.PublishAt, arg.UnpublishAt)
 	var i Content
 	err := row.Scan(
 		&i.ID,
@@ -220,6 +1197,93 @@ func (q *Queries) UpdateContent(ctx context.Context, arg UpdateContentParams) (C
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
+	)
+	return i, err
+}
+
+const updateContent = `-- name: UpdateContent :one
+WITH updated AS (
+  UPDATE contents
+  SET 
+    data = $1,
+    draft_data = NULL,
+    published = $2,
+    -- changed data goes back to the start of the workflow for review
+    workflow_state = CASE WHEN COALESCE(draft_data, data) IS DISTINCT FROM $1 THEN NULL ELSE workflow_state END,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = $3 AND deleted_at IS NULL
+  AND ($4::int IS NULL OR version = $4::int)
+  RETURNING id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT id FROM updated) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), $5::uuid FROM updated
+)
+SELECT id, schema_id, data, published, created_by, created_at, updated_at, deleted_at, version, publish_at, unpublish_at, workflow_state, draft_data, change_xid, was_published FROM updated
+`
+
+type UpdateContentParams struct {
+	Data            json.RawMessage
+	Published       pgtype.Bool
+	ID              uuid.UUID
+	ExpectedVersion pgtype.Int4
+	Author          pgtype.UUID
+}
+
+type UpdateContentRow struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+func (q *Queries) UpdateContent(ctx context.Context, arg UpdateContentParams) (UpdateContentRow, error) {
+	row := q.db.QueryRow(ctx, updateContent,
+		arg.Data,
+		arg.Published,
+		arg.ID,
+		arg.ExpectedVersion,
+		arg.Author,
+	)
+	var i UpdateContentRow
+	err := row.Scan(
+		&i.ID,
+		&i.SchemaID,
+		&i.Data,
+		&i.Published,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.PublishAt,
+		&i.UnpublishAt,
+		&i.WorkflowState,
+		&i.DraftData,
+		&i.ChangeXid,
+		&i.WasPublished,
 	)
 	return i, err
 }
@@ -12,14 +12,104 @@ import (
 )
 
 type Content struct {
+	ID            uuid.UUID
+	SchemaID      pgtype.UUID
+	Data          json.RawMessage
+	Published     pgtype.Bool
+	CreatedBy     pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+	Version       int32
+	PublishAt     pgtype.Timestamptz
+	UnpublishAt   pgtype.Timestamptz
+	WorkflowState pgtype.Text
+	DraftData     []byte
+	ChangeXid     uint64
+	WasPublished  bool
+}
+
+type ContentAssignee struct {
+	ContentID uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+type ContentImport struct {
+	ID         uuid.UUID
+	SchemaID   uuid.UUID
+	Format     string
+	Total      int32
+	Created    int32
+	Updated    int32
+	Rejected   int32
+	Report     []byte
+	ReportType pgtype.Text
+	CreatedBy  pgtype.UUID
+	CreatedAt  pgtype.Timestamptz
+}
+
+type ContentLink struct {
+	ContentID       uuid.UUID
+	Locale          pgtype.Text
+	Field           string
+	TargetContentID pgtype.UUID
+	Url             pgtype.Text
+}
+
+type ContentLocale struct {
+	ContentID      uuid.UUID
+	Locale         string
+	Data           json.RawMessage
+	Published      bool
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+	DraftData      []byte
+	DraftPublished pgtype.Bool
+}
+
+type ContentRevision struct {
 	ID        uuid.UUID
-	SchemaID  pgtype.UUID
+	ContentID uuid.UUID
+	Version   int32
 	Data      json.RawMessage
-	Published pgtype.Bool
+	Published bool
 	CreatedBy pgtype.UUID
 	CreatedAt pgtype.Timestamptz
+}
+
+type ContentSearch struct {
+	ContentID uuid.UUID
+	Locale    string
+	Body      string
+	Document  interface{}
 	UpdatedAt pgtype.Timestamptz
-	DeletedAt pgtype.Timestamptz
+}
+
+type ContentUniqueValue struct {
+	SchemaID  uuid.UUID
+	Field     string
+	Value     string
+	ContentID uuid.UUID
+}
+
+type Event struct {
+	ID        int64
+	Event     string
+	SchemaID  pgtype.UUID
+	Data      json.RawMessage
+	CreatedAt pgtype.Timestamptz
+	Xid       uint64
+}
+
+type Locale struct {
+	Code         string
+	Name         string
+	IsDefault    bool
+	Fallback     pgtype.Text
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+	SearchConfig string
 }
 
 type Medium struct {
@@ -34,6 +124,15 @@ type Medium struct {
 	DeletedAt  pgtype.Timestamptz
 }
 
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ContentID pgtype.UUID
+	Message   string
+	ReadAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
 type Schema struct {
 	ID         uuid.UUID
 	Name       string
@@ -42,6 +141,28 @@ type Schema struct {
 	CreatedAt  pgtype.Timestamptz
 	UpdatedAt  pgtype.Timestamptz
 	DeletedAt  pgtype.Timestamptz
+	Rules      json.RawMessage
+	Settings   json.RawMessage
+}
+
+type Taxonomy struct {
+	ID           uuid.UUID
+	Name         string
+	Hierarchical bool
+	CreatedBy    pgtype.UUID
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+}
+
+type TaxonomyTerm struct {
+	ID         uuid.UUID
+	TaxonomyID uuid.UUID
+	ParentID   pgtype.UUID
+	Slug       string
+	Name       string
+	Position   int32
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
 }
 
 type User struct {
@@ -53,3 +174,41 @@ type User struct {
 	UpdatedAt    pgtype.Timestamptz
 	DeletedAt    pgtype.Timestamptz
 }
+
+type Webhook struct {
+	ID        uuid.UUID
+	Name      string
+	Url       string
+	Secret    string
+	Events    []string
+	SchemaIds []uuid.UUID
+	Active    bool
+	CreatedBy pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	Event          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	ResponseStatus pgtype.Int4
+	ResponseBody   pgtype.Text
+	Error          pgtype.Text
+	CreatedAt      pgtype.Timestamptz
+	DeliveredAt    pgtype.Timestamptz
+}
+
+type WorkflowEvent struct {
+	ID        uuid.UUID
+	ContentID uuid.UUID
+	FromState string
+	ToState   string
+	Comment   string
+	CreatedBy pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
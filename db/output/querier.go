@@ -12,31 +12,143 @@ import (
 )
 
 type Querier interface {
+	AddContentAssignees(ctx context.Context, arg AddContentAssigneesParams) error
 	AdminExists(ctx context.Context) (bool, error)
-	CreateContent(ctx context.Context, arg CreateContentParams) (Content, error)
+	// Marks a translation edit, which sends the entry back for review like any edit
+	BumpContentVersion(ctx context.Context, arg BumpContentVersionParams) (Content, error)
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	CountContentsBySchema(ctx context.Context, schemaID pgtype.UUID) (int64, error)
+	CountDeletedContents(ctx context.Context, schemaID pgtype.UUID) (int64, error)
+	// Locks the entries using the term until the transaction ends
+	CountTermUsage(ctx context.Context, arg CountTermUsageParams) (int64, error)
+	CountWebhookDeliveries(ctx context.Context, arg CountWebhookDeliveriesParams) (int64, error)
+	// Inserts nothing when the schema is trashed. The share lock waits for a
+	// schema delete in progress, see LockSchemas.
+	CreateContent(ctx context.Context, arg CreateContentParams) (CreateContentRow, error)
+	CreateContentImport(ctx context.Context, arg CreateContentImportParams) (ContentImport, error)
+	CreateContents(ctx context.Context, arg CreateContentsParams) ([]CreateContentsRow, error)
+	CreateLocale(ctx context.Context, arg CreateLocaleParams) (Locale, error)
 	CreateMedia(ctx context.Context, arg CreateMediaParams) (Medium, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) error
 	CreateSchema(ctx context.Context, arg CreateSchemaParams) (Schema, error)
+	CreateTaxonomy(ctx context.Context, arg CreateTaxonomyParams) (Taxonomy, error)
+	CreateTaxonomyTerm(ctx context.Context, arg CreateTaxonomyTermParams) (TaxonomyTerm, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteContent(ctx context.Context, id uuid.UUID) error
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWorkflowEvent(ctx context.Context, arg CreateWorkflowEventParams) (WorkflowEvent, error)
+	DeleteContent(ctx context.Context, arg DeleteContentParams) (int64, error)
+	DeleteContentAssignees(ctx context.Context, contentID uuid.UUID) error
+	DeleteContentImportsBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	DeleteContentsBySchema(ctx context.Context, arg DeleteContentsBySchemaParams) error
+	DeleteLocale(ctx context.Context, code string) error
 	DeleteMedia(ctx context.Context, id uuid.UUID) error
-	DeleteSchema(ctx context.Context, id uuid.UUID) error
+	DeleteSchema(ctx context.Context, id uuid.UUID) (Schema, error)
+	DeleteSearchDocument(ctx context.Context, arg DeleteSearchDocumentParams) error
+	DeleteSearchDocuments(ctx context.Context, contentID uuid.UUID) error
+	DeleteSearchDocumentsByLocale(ctx context.Context, locale string) error
+	DeleteTaxonomy(ctx context.Context, id uuid.UUID) error
+	DeleteTaxonomyTerm(ctx context.Context, id uuid.UUID) error
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error)
+	DiscardContentDraft(ctx context.Context, arg DiscardContentDraftParams) (DiscardContentDraftRow, error)
+	// Matches the working data, drafts included, which is what updates write to
+	FindContentsByField(ctx context.Context, arg FindContentsByFieldParams) ([]FindContentsByFieldRow, error)
+	FindMissingTerms(ctx context.Context, arg FindMissingTermsParams) ([]string, error)
+	FindUniqueConflict(ctx context.Context, arg FindUniqueConflictParams) (string, error)
+	FinishWebhookDelivery(ctx context.Context, arg FinishWebhookDeliveryParams) error
 	GetAllContents(ctx context.Context) ([]Content, error)
 	GetAllContentsBySchema(ctx context.Context, schemaID pgtype.UUID) ([]Content, error)
 	GetContentByID(ctx context.Context, id uuid.UUID) (Content, error)
+	GetContentIDByUniqueValue(ctx context.Context, arg GetContentIDByUniqueValueParams) (uuid.UUID, error)
+	GetContentImport(ctx context.Context, id uuid.UUID) (ContentImport, error)
+	GetContentLocales(ctx context.Context, contentID uuid.UUID) ([]ContentLocale, error)
+	GetContentLocalesByContentIDs(ctx context.Context, contentIds []uuid.UUID) ([]ContentLocale, error)
+	GetContentsByIDs(ctx context.Context, ids []uuid.UUID) ([]Content, error)
 	GetContentsBySchema(ctx context.Context, arg GetContentsBySchemaParams) ([]Content, error)
+	GetDefaultLocale(ctx context.Context) (Locale, error)
+	GetDeletedContentByID(ctx context.Context, id uuid.UUID) (Content, error)
+	GetDeletedSchemaByID(ctx context.Context, id uuid.UUID) (Schema, error)
+	GetLatestEventPosition(ctx context.Context) (GetLatestEventPositionRow, error)
+	GetLatestRevision(ctx context.Context, contentID uuid.UUID) (ContentRevision, error)
+	GetLocale(ctx context.Context, code string) (Locale, error)
 	GetMediaByID(ctx context.Context, id uuid.UUID) (Medium, error)
 	GetMediaByURL(ctx context.Context, url string) (Medium, error)
+	GetPreviousRevision(ctx context.Context, arg GetPreviousRevisionParams) (ContentRevision, error)
+	GetRevision(ctx context.Context, arg GetRevisionParams) (ContentRevision, error)
 	GetSchemaByID(ctx context.Context, id uuid.UUID) (Schema, error)
 	GetSchemaByName(ctx context.Context, name string) (Schema, error)
+	GetSchemasVersion(ctx context.Context) (GetSchemasVersionRow, error)
+	GetTaxonomyByName(ctx context.Context, name string) (Taxonomy, error)
+	GetTaxonomyTerm(ctx context.Context, arg GetTaxonomyTermParams) (TaxonomyTerm, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	GetWebhook(ctx context.Context, id uuid.UUID) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id uuid.UUID) (WebhookDelivery, error)
+	GetWebhooksByIDs(ctx context.Context, ids []uuid.UUID) ([]Webhook, error)
+	HasContentDraft(ctx context.Context, id uuid.UUID) (bool, error)
+	// Entries, trashed ones included, whose base data holds default locale values
+	HasLocalizedContents(ctx context.Context) (bool, error)
+	ListContentAssignees(ctx context.Context, contentID uuid.UUID) ([]uuid.UUID, error)
+	ListContentLinks(ctx context.Context, contentID uuid.UUID) ([]ContentLink, error)
+	ListDeletedContents(ctx context.Context, arg ListDeletedContentsParams) ([]Content, error)
+	ListDeletedSchemas(ctx context.Context) ([]Schema, error)
+	// Events from transactions below the xmin of the snapshot are settled: no
+	// event can still commit before them.
+	ListEventsAfter(ctx context.Context, arg ListEventsAfterParams) ([]ListEventsAfterRow, error)
+	ListLinksToContent(ctx context.Context, targetContentID pgtype.UUID) ([]ListLinksToContentRow, error)
+	ListLinksToURL(ctx context.Context, url pgtype.Text) ([]ListLinksToURLRow, error)
+	ListLocales(ctx context.Context) ([]Locale, error)
 	ListMedia(ctx context.Context) ([]Medium, error)
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
+	ListRevisions(ctx context.Context, contentID uuid.UUID) ([]ContentRevision, error)
+	ListScheduledContents(ctx context.Context, schemaID pgtype.UUID) ([]Content, error)
+	ListSchemaContentsAfter(ctx context.Context, arg ListSchemaContentsAfterParams) ([]Content, error)
 	ListSchemas(ctx context.Context) ([]Schema, error)
+	ListTaxonomies(ctx context.Context) ([]Taxonomy, error)
+	ListTaxonomyTerms(ctx context.Context, taxonomyID uuid.UUID) ([]TaxonomyTerm, error)
 	ListUsers(ctx context.Context) ([]User, error)
-	UpdateContent(ctx context.Context, arg UpdateContentParams) (Content, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	ListWorkflowEvents(ctx context.Context, contentID uuid.UUID) ([]WorkflowEvent, error)
+	// Holds the live schemas while a delete checks its dependencies: edits that
+	// add references and content created in them wait for the delete to finish.
+	LockSchemas(ctx context.Context) ([]Schema, error)
+	LockTaxonomyTerm(ctx context.Context, id uuid.UUID) (TaxonomyTerm, error)
+	MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (int64, error)
+	MoveSearchDocuments(ctx context.Context, arg MoveSearchDocumentsParams) error
+	MoveTaxonomyTermChildren(ctx context.Context, arg MoveTaxonomyTermChildrenParams) error
+	NotifyAssignees(ctx context.Context, arg NotifyAssigneesParams) error
+	PruneRevisions(ctx context.Context, arg PruneRevisionsParams) (int64, error)
+	PublishContent(ctx context.Context, arg PublishContentParams) (PublishContentRow, error)
+	PurgeContent(ctx context.Context, id uuid.UUID) (int64, error)
+	PurgeDeletedContents(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error)
+	PurgeDeletedSchemas(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error)
+	PurgeEvents(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	PurgeWebhookDeliveries(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	RedeliverWebhook(ctx context.Context, id uuid.UUID) (WebhookDelivery, error)
+	ReindexSearchLocale(ctx context.Context, locale string) error
+	// Every renamed entry gets a new version and a revision of it
+	RenameTermInContents(ctx context.Context, arg RenameTermInContentsParams) (int64, error)
+	RestoreContent(ctx context.Context, arg RestoreContentParams) (RestoreContentRow, error)
+	RestoreContentsBySchema(ctx context.Context, arg RestoreContentsBySchemaParams) error
+	RestoreSchema(ctx context.Context, id uuid.UUID) (Schema, error)
+	RunDueSchedules(ctx context.Context, limit int32) ([]RunDueSchedulesRow, error)
+	SaveContentDraft(ctx context.Context, arg SaveContentDraftParams) (SaveContentDraftRow, error)
+	ScheduleContent(ctx context.Context, arg ScheduleContentParams) (Content, error)
+	SearchConfigExists(ctx context.Context, cfgname string) (bool, error)
+	SetDefaultLocale(ctx context.Context, code string) error
+	SetWorkflowState(ctx context.Context, arg SetWorkflowStateParams) (Content, error)
+	UpdateContent(ctx context.Context, arg UpdateContentParams) (UpdateContentRow, error)
+	UpdateLocale(ctx context.Context, arg UpdateLocaleParams) (Locale, error)
 	UpdateMedia(ctx context.Context, arg UpdateMediaParams) (Medium, error)
 	UpdateSchema(ctx context.Context, arg UpdateSchemaParams) (Schema, error)
+	UpdateSchemaSettings(ctx context.Context, arg UpdateSchemaSettingsParams) (Schema, error)
+	UpdateTaxonomyTerm(ctx context.Context, arg UpdateTaxonomyTermParams) (TaxonomyTerm, error)
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error)
+	UpsertContentLocale(ctx context.Context, arg UpsertContentLocaleParams) (ContentLocale, error)
+	// A new translation stays unpublished until its draft is published
+	UpsertContentLocaleDraft(ctx context.Context, arg UpsertContentLocaleDraftParams) (ContentLocale, error)
+	UpsertSearchDocument(ctx context.Context, arg UpsertSearchDocumentParams) error
 	UserExists(ctx context.Context, id uuid.UUID) (bool, error)
 }
 
@@ -14,19 +14,27 @@ import (
 )
 
 const createSchema = `-- name: CreateSchema :one
-INSERT INTO schemas (name, definition, created_by)
-VALUES ($1, $2, $3)
-RETURNING id, name, definition, created_by, created_at, updated_at, deleted_at
+INSERT INTO schemas (name, definition, created_by, rules, settings)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings
 `
 
 type CreateSchemaParams struct {
 	Name       string
 	Definition json.RawMessage
 	CreatedBy  pgtype.UUID
+	Rules      json.RawMessage
+	Settings   json.RawMessage
 }
 
 func (q *Queries) CreateSchema(ctx context.Context, arg CreateSchemaParams) (Schema, error) {
-	row := q.db.QueryRow(ctx, createSchema, arg.Name, arg.Definition, arg.CreatedBy)
+	row := q.db.QueryRow(ctx, createSchema,
+		arg.Name,
+		arg.Definition,
+		arg.CreatedBy,
+		arg.Rules,
+		arg.Settings,
+	)
 	var i Schema
 	err := row.Scan(
 		&i.ID,
@@ -36,22 +44,60 @@ func (q *Queries) CreateSchema(ctx context.Context, arg CreateSchemaParams) (Sch
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
 	)
 	return i, err
 }
 
-const deleteSchema = `-- name: DeleteSchema :exec
-DELETE FROM schemas
-WHERE id = $1
+const deleteSchema = `-- name: DeleteSchema :one
+UPDATE schemas
+SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings
 `
 
-func (q *Queries) DeleteSchema(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteSchema, id)
-	return err
+func (q *Queries) DeleteSchema(ctx context.Context, id uuid.UUID) (Schema, error) {
+	row := q.db.QueryRow(ctx, deleteSchema, id)
+	var i Schema
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Definition,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
+	)
+	return i, err
+}
+
+const getDeletedSchemaByID = `-- name: GetDeletedSchemaByID :one
+SELECT id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings FROM schemas
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetDeletedSchemaByID(ctx context.Context, id uuid.UUID) (Schema, error) {
+	row := q.db.QueryRow(ctx, getDeletedSchemaByID, id)
+	var i Schema
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Definition,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
+	)
+	return i, err
 }
 
 const getSchemaByID = `-- name: GetSchemaByID :one
-SELECT id, name, definition, created_by, created_at, updated_at, deleted_at FROM schemas
+SELECT id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings FROM schemas
 WHERE id = $1 AND deleted_at IS NULL
 `
 
@@ -66,12 +112,14 @@ func (q *Queries) GetSchemaByID(ctx context.Context, id uuid.UUID) (Schema, erro
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
 	)
 	return i, err
 }
 
 const getSchemaByName = `-- name: GetSchemaByName :one
-SELECT id, name, definition, created_by, created_at, updated_at, deleted_at FROM schemas
+SELECT id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings FROM schemas
 WHERE name = $1 AND deleted_at IS NULL
 `
 
@@ -86,12 +134,67 @@ func (q *Queries) GetSchemaByName(ctx context.Context, name string) (Schema, err
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
 	)
 	return i, err
 }
 
+const getSchemasVersion = `-- name: GetSchemasVersion :one
+SELECT COUNT(*)::bigint AS count, COALESCE(MAX(updated_at), 'epoch')::timestamptz AS updated_at
+FROM schemas
+`
+
+type GetSchemasVersionRow struct {
+	Count     int64
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetSchemasVersion(ctx context.Context) (GetSchemasVersionRow, error) {
+	row := q.db.QueryRow(ctx, getSchemasVersion)
+	var i GetSchemasVersionRow
+	err := row.Scan(&i.Count, &i.UpdatedAt)
+	return i, err
+}
+
+const listDeletedSchemas = `-- name: ListDeletedSchemas :many
+SELECT id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings FROM schemas
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) ListDeletedSchemas(ctx context.Context) ([]Schema, error) {
+	rows, err := q.db.Query(ctx, listDeletedSchemas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Schema
+	for rows.Next() {
+		var i Schema
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Definition,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Rules,
+			&i.Settings,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSchemas = `-- name: ListSchemas :many
-SELECT id, name, definition, created_by, created_at, updated_at, deleted_at FROM schemas
+SELECT id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings FROM schemas
 WHERE deleted_at IS NULL
 ORDER BY id
 `
@@ -113,6 +216,8 @@ func (q *Queries) ListSchemas(ctx context.Context) ([]Schema, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.Rules,
+			&i.Settings,
 		); err != nil {
 			return nil, err
 		}
@@ -124,11 +229,87 @@ func (q *Queries) ListSchemas(ctx context.Context) ([]Schema, error) {
 	return items, nil
 }
 
+const lockSchemas = `-- name: LockSchemas :many
+SELECT id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings FROM schemas
+WHERE deleted_at IS NULL
+ORDER BY id
+FOR UPDATE
+`
+
+// Holds the live schemas while a delete checks its dependencies: edits that
+// add references and content created in them wait for the delete to finish.
+func (q *Queries) LockSchemas(ctx context.Context) ([]Schema, error) {
+	rows, err := q.db.Query(ctx, lockSchemas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Schema
+	for rows.Next() {
+		var i Schema
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Definition,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Rules,
+			&i.Settings,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedSchemas = `-- name: PurgeDeletedSchemas :execrows
+DELETE FROM schemas
+WHERE deleted_at IS NOT NULL AND deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedSchemas(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedSchemas, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreSchema = `-- name: RestoreSchema :one
+UPDATE schemas
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings
+`
+
+func (q *Queries) RestoreSchema(ctx context.Context, id uuid.UUID) (Schema, error) {
+	row := q.db.QueryRow(ctx, restoreSchema, id)
+	var i Schema
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Definition,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
+	)
+	return i, err
+}
+
 const updateSchema = `-- name: UpdateSchema :one
 UPDATE schemas
 SET name = $2, definition = $3, updated_at = now()
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, name, definition, created_by, created_at, updated_at, deleted_at
+RETURNING id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings
 `
 
 type UpdateSchemaParams struct {
@@ -148,6 +329,37 @@ func (q *Queries) UpdateSchema(ctx context.Context, arg UpdateSchemaParams) (Sch
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
+	)
+	return i, err
+}
+
+const updateSchemaSettings = `-- name: UpdateSchemaSettings :one
+UPDATE schemas
+SET settings = $2, updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, definition, created_by, created_at, updated_at, deleted_at, rules, settings
+`
+
+type UpdateSchemaSettingsParams struct {
+	ID       uuid.UUID
+	Settings json.RawMessage
+}
+
+func (q *Queries) UpdateSchemaSettings(ctx context.Context, arg UpdateSchemaSettingsParams) (Schema, error) {
+	row := q.db.QueryRow(ctx, updateSchemaSettings, arg.ID, arg.Settings)
+	var i Schema
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Definition,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Rules,
+		&i.Settings,
 	)
 	return i, err
 }
@@ -1,7 +1,19 @@
 -- name: CreateContent :one
-INSERT INTO contents (schema_id, data, created_by,published)
-VALUES ($1, $2, $3,$4)
-RETURNING *;
+-- Inserts nothing when the schema is trashed. The share lock waits for a
+-- schema delete in progress, see LockSchemas.
+WITH created AS (
+  INSERT INTO contents (schema_id, data, created_by, published, publish_at, unpublish_at)
+  SELECT s.id, sqlc.arg(data), sqlc.narg(created_by)::uuid, sqlc.narg(published)::boolean,
+    sqlc.narg(publish_at)::timestamptz, sqlc.narg(unpublish_at)::timestamptz
+  FROM schemas s
+  WHERE s.id = sqlc.arg(schema_id) AND s.deleted_at IS NULL
+  FOR SHARE
+  RETURNING *
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, data, COALESCE(published, FALSE), created_by FROM created
+)
+SELECT * FROM created;
 
 -- name: GetContentsBySchema :many
 SELECT * FROM contents
@@ -16,22 +28,109 @@ WHERE schema_id = $1
 AND deleted_at IS NULL
 ORDER BY created_at DESC;
 
--- name: DeleteContent :exec
+-- name: DeleteContent :execrows
 UPDATE contents
 SET deleted_at = now()
-WHERE id = $1;
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version)::int);
 
 -- name: GetContentByID :one
 SELECT * FROM contents
 WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: UpdateContent :one
+WITH updated AS (
+  UPDATE contents
+  SET 
+    data = sqlc.arg(data),
+    draft_data = NULL,
+    published = sqlc.arg(published),
+    -- changed data goes back to the start of the workflow for review
+    workflow_state = CASE WHEN COALESCE(draft_data, data) IS DISTINCT FROM sqlc.arg(data) THEN NULL ELSE workflow_state END,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version)::int)
+  RETURNING *
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT id FROM updated) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), sqlc.narg(author)::uuid FROM updated
+)
+SELECT * FROM updated;
+
+-- name: SaveContentDraft :one
+WITH updated AS (
+  UPDATE contents
+  SET
+    draft_data = sqlc.arg(draft_data),
+    workflow_state = CASE WHEN COALESCE(draft_data, data) IS DISTINCT FROM sqlc.arg(draft_data) THEN NULL ELSE workflow_state END,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version)::int)
+  RETURNING *
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), sqlc.narg(author)::uuid FROM updated
+)
+SELECT * FROM updated;
+
+-- name: PublishContent :one
+WITH updated AS (
+  UPDATE contents
+  SET
+    data = COALESCE(draft_data, data),
+    draft_data = NULL,
+    published = TRUE,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version)::int)
+  RETURNING *
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT id FROM updated) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), sqlc.narg(author)::uuid FROM updated
+)
+SELECT * FROM updated;
+
+-- name: DiscardContentDraft :one
+WITH updated AS (
+  UPDATE contents
+  SET draft_data = NULL, version = version + 1, updated_at = NOW()
+  WHERE contents.id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version)::int)
+  RETURNING *
+), discarded AS (
+  UPDATE content_locales
+  SET draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT id FROM updated) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), sqlc.narg(author)::uuid FROM updated
+)
+SELECT * FROM updated;
+
+-- name: HasContentDraft :one
+SELECT EXISTS (
+  SELECT 1 FROM contents WHERE id = $1 AND draft_data IS NOT NULL
+  UNION ALL
+  SELECT 1 FROM content_locales WHERE content_id = $1 AND draft_data IS NOT NULL
+);
+
+-- name: BumpContentVersion :one
+-- Marks a translation edit, which sends the entry back for review like any edit
 UPDATE contents
-SET 
-  data = $2,
-  published = $3,
-  updated_at = NOW()
-WHERE id = $1
+SET version = version + 1, workflow_state = NULL, updated_at = NOW()
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version)::int)
 RETURNING *;
 
 
@@ -39,3 +138,175 @@ RETURNING *;
 SELECT * FROM contents
 WHERE deleted_at IS NULL
 ORDER BY created_at DESC;
+
+-- name: CountContentsBySchema :one
+SELECT COUNT(*) FROM contents
+WHERE schema_id = $1
+AND deleted_at IS NULL;
+
+-- name: DeleteContentsBySchema :exec
+UPDATE contents
+SET deleted_at = $2
+WHERE schema_id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreContentsBySchema :exec
+UPDATE contents
+SET deleted_at = NULL
+WHERE schema_id = $1 AND deleted_at = $2;
+
+-- name: ScheduleContent :one
+UPDATE contents
+SET publish_at = $2, unpublish_at = $3
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ListScheduledContents :many
+SELECT * FROM contents
+WHERE deleted_at IS NULL
+AND (publish_at IS NOT NULL OR unpublish_at IS NOT NULL)
+AND (sqlc.narg(schema_id)::uuid IS NULL OR schema_id = sqlc.narg(schema_id)::uuid)
+ORDER BY LEAST(publish_at, unpublish_at);
+
+-- name: RunDueSchedules :many
+WITH applied AS (
+  UPDATE contents
+  SET
+    published = due.live,
+    -- pending drafts go live with the entry
+    data = CASE WHEN due.live THEN COALESCE(draft_data, data) ELSE data END,
+    draft_data = CASE WHEN due.live THEN NULL ELSE draft_data END,
+    publish_at = CASE WHEN due.publish THEN NULL ELSE publish_at END,
+    unpublish_at = CASE WHEN due.unpublish THEN NULL ELSE unpublish_at END,
+    version = version + 1,
+    updated_at = NOW()
+  FROM (
+    SELECT c.id AS content_id, d.publish, d.unpublish,
+      NOT (d.unpublish AND (NOT d.publish OR c.unpublish_at >= c.publish_at)) AS live
+    FROM contents c
+    JOIN schemas s ON s.id = c.schema_id
+    CROSS JOIN LATERAL (
+      SELECT
+        COALESCE(c.publish_at <= NOW() AND (
+          s.settings->'workflow' IS NULL
+          OR s.settings->'workflow'->'publishFrom' @> to_jsonb(COALESCE(c.workflow_state, s.settings->'workflow'->>'initial', s.settings->'workflow'->'states'->>0))
+        ), FALSE) AS publish,
+        COALESCE(c.unpublish_at <= NOW(), FALSE) AS unpublish
+    ) d
+    WHERE c.deleted_at IS NULL AND (d.publish OR d.unpublish)
+    ORDER BY LEAST(c.publish_at, c.unpublish_at)
+    LIMIT $1
+    FOR UPDATE OF c SKIP LOCKED
+  ) due
+  WHERE contents.id = due.content_id
+  RETURNING contents.*
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT a.id FROM applied a WHERE a.published) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), NULL FROM applied
+)
+SELECT * FROM applied;
+
+-- name: GetContentsByIDs :many
+SELECT * FROM contents
+WHERE id = ANY(sqlc.arg(ids)::uuid[]) AND deleted_at IS NULL;
+
+-- name: CreateContents :many
+WITH created AS (
+  INSERT INTO contents (id, schema_id, data, published, created_by)
+  SELECT n.id, n.schema_id, n.data, n.published, sqlc.narg(created_by)::uuid
+  FROM (
+    SELECT
+      unnest(sqlc.arg(ids)::uuid[]) AS id,
+      unnest(sqlc.arg(schema_ids)::uuid[]) AS schema_id,
+      unnest(sqlc.arg(data)::jsonb[]) AS data,
+      unnest(sqlc.arg(published)::bool[]) AS published
+  ) n
+  -- rows of trashed schemas are left out, like CreateContent
+  WHERE EXISTS (
+    SELECT 1 FROM schemas s WHERE s.id = n.schema_id AND s.deleted_at IS NULL FOR SHARE
+  )
+  ON CONFLICT (id) DO NOTHING
+  RETURNING *
+), revisions AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, data, published, created_by FROM created
+)
+SELECT * FROM created;
+
+-- name: ListSchemaContentsAfter :many
+SELECT * FROM contents
+WHERE schema_id = sqlc.arg(schema_id) AND deleted_at IS NULL
+AND (sqlc.narg(after_id)::uuid IS NULL OR id > sqlc.narg(after_id)::uuid)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: FindContentsByField :many
+-- Matches the working data, drafts included, which is what updates write to
+SELECT id, (COALESCE(draft_data, data)->>sqlc.arg(field)::text)::text AS key_value FROM contents
+WHERE schema_id = sqlc.arg(schema_id) AND deleted_at IS NULL
+AND COALESCE(draft_data, data)->>sqlc.arg(field)::text = ANY(sqlc.arg(key_values)::text[]);
+
+-- name: ListDeletedContents :many
+SELECT * FROM contents
+WHERE schema_id = sqlc.arg(schema_id) AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: CountDeletedContents :one
+SELECT COUNT(*) FROM contents
+WHERE schema_id = $1 AND deleted_at IS NOT NULL;
+
+-- name: GetDeletedContentByID :one
+SELECT * FROM contents
+WHERE contents.id = $1 AND contents.deleted_at IS NOT NULL
+AND contents.schema_id IN (SELECT s.id FROM schemas s WHERE s.deleted_at IS NULL);
+
+-- name: RestoreContent :one
+WITH restored AS (
+  UPDATE contents
+  SET
+    deleted_at = NULL,
+    data = COALESCE(draft_data, data),
+    draft_data = NULL,
+    published = FALSE,
+    publish_at = NULL,
+    unpublish_at = NULL,
+    version = version + 1,
+    updated_at = NOW()
+  WHERE contents.id = sqlc.arg(id) AND deleted_at IS NOT NULL
+  AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version)::int)
+  RETURNING *
+), promoted AS (
+  UPDATE content_locales
+  SET data = draft_data, published = COALESCE(draft_published, published), draft_data = NULL, draft_published = NULL, updated_at = NOW()
+  WHERE content_id IN (SELECT r.id FROM restored r) AND draft_data IS NOT NULL
+), revision AS (
+  INSERT INTO content_revisions (content_id, version, data, published, created_by)
+  SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), sqlc.narg(author)::uuid FROM restored
+)
+SELECT * FROM restored;
+
+-- name: PurgeContent :execrows
+DELETE FROM contents
+WHERE contents.id = $1 AND contents.deleted_at IS NOT NULL
+AND contents.schema_id IN (SELECT s.id FROM schemas s WHERE s.deleted_at IS NULL);
+
+-- name: PurgeDeletedContents :execrows
+DELETE FROM contents
+WHERE contents.deleted_at IS NOT NULL AND contents.deleted_at < $1
+AND contents.schema_id IN (SELECT s.id FROM schemas s WHERE s.deleted_at IS NULL);
+
+-- name: GetContentIDByUniqueValue :one
+SELECT content_id FROM content_unique_values
+WHERE schema_id = sqlc.arg(schema_id) AND field = sqlc.arg(field) AND value = sqlc.arg(value);
+
+-- name: FindUniqueConflict :one
+SELECT f.name::text AS field
+FROM unnest(sqlc.arg(fields)::text[]) AS f(name)
+JOIN content_unique_values u ON u.schema_id = sqlc.arg(schema_id) AND u.field = f.name
+  AND u.value = sqlc.arg(data)::jsonb->>f.name
+WHERE u.content_id <> sqlc.arg(content_id)
+LIMIT 1;
@@ -0,0 +1,18 @@
+-- name: ListEventsAfter :many
+-- Events from transactions below the xmin of the snapshot are settled: no
+-- event can still commit before them.
+SELECT events.*, (events.xid < pg_snapshot_xmin(pg_current_snapshot()))::boolean AS settled
+FROM events
+WHERE (events.xid, events.id) > (sqlc.arg(after_xid)::xid8, sqlc.arg(after_id)::bigint)
+ORDER BY events.xid, events.id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetLatestEventPosition :one
+SELECT xid, id FROM events
+WHERE xid < pg_snapshot_xmin(pg_current_snapshot())
+ORDER BY xid DESC, id DESC
+LIMIT 1;
+
+-- name: PurgeEvents :execrows
+DELETE FROM events
+WHERE created_at < $1;
@@ -0,0 +1,12 @@
+-- name: CreateContentImport :one
+INSERT INTO content_imports (schema_id, format, total, created, updated, rejected, report, report_type, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetContentImport :one
+SELECT * FROM content_imports
+WHERE id = $1;
+
+-- name: DeleteContentImportsBefore :execrows
+DELETE FROM content_imports
+WHERE created_at < $1;
@@ -0,0 +1,16 @@
+-- name: ListContentLinks :many
+SELECT * FROM content_links
+WHERE content_id = $1
+ORDER BY locale NULLS FIRST, field, target_content_id, url;
+
+-- name: ListLinksToContent :many
+SELECT l.content_id, l.locale, l.field, c.schema_id FROM content_links l
+JOIN contents c ON c.id = l.content_id
+WHERE l.target_content_id = $1 AND c.deleted_at IS NULL
+ORDER BY c.updated_at DESC, l.locale NULLS FIRST, l.field;
+
+-- name: ListLinksToURL :many
+SELECT l.content_id, l.locale, l.field, c.schema_id FROM content_links l
+JOIN contents c ON c.id = l.content_id
+WHERE l.url = $1 AND c.deleted_at IS NULL
+ORDER BY c.updated_at DESC, l.locale NULLS FIRST, l.field;
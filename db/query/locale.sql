@@ -0,0 +1,67 @@
+-- name: ListLocales :many
+SELECT * FROM locales
+ORDER BY is_default DESC, code;
+
+-- name: GetLocale :one
+SELECT * FROM locales
+WHERE code = $1;
+
+-- name: GetDefaultLocale :one
+SELECT * FROM locales
+WHERE is_default
+LIMIT 1;
+
+-- name: CreateLocale :one
+INSERT INTO locales (code, name, fallback, search_config)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpdateLocale :one
+UPDATE locales
+SET name = $2, fallback = $3, search_config = $4, updated_at = now()
+WHERE code = $1
+RETURNING *;
+
+-- name: SetDefaultLocale :exec
+UPDATE locales
+SET is_default = (code = $1);
+
+-- name: HasLocalizedContents :one
+-- Entries, trashed ones included, whose base data holds default locale values
+SELECT EXISTS (
+  SELECT 1 FROM contents c
+  JOIN schemas s ON s.id = c.schema_id
+  WHERE EXISTS (
+    SELECT 1 FROM jsonb_array_elements(s.definition) d
+    WHERE (d->>'localized')::boolean
+  )
+);
+
+-- name: DeleteLocale :exec
+DELETE FROM locales
+WHERE code = $1 AND NOT is_default;
+
+-- name: UpsertContentLocale :one
+INSERT INTO content_locales (content_id, locale, data, published)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (content_id, locale) DO UPDATE
+SET data = EXCLUDED.data, draft_data = NULL, published = EXCLUDED.published, draft_published = NULL, updated_at = now()
+RETURNING *;
+
+-- name: UpsertContentLocaleDraft :one
+-- A new translation stays unpublished until its draft is published
+INSERT INTO content_locales (content_id, locale, data, published, draft_data, draft_published)
+VALUES (sqlc.arg(content_id), sqlc.arg(locale), '{}', FALSE, sqlc.arg(draft_data), sqlc.arg(draft_published)::boolean)
+ON CONFLICT (content_id, locale) DO UPDATE
+SET draft_data = EXCLUDED.draft_data, draft_published = EXCLUDED.draft_published, updated_at = now()
+RETURNING *;
+
+-- name: GetContentLocales :many
+SELECT * FROM content_locales
+WHERE content_id = $1
+ORDER BY locale;
+
+-- name: GetContentLocalesByContentIDs :many
+SELECT * FROM content_locales
+WHERE content_id = ANY(sqlc.arg(content_ids)::uuid[])
+ORDER BY locale;
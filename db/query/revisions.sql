@@ -0,0 +1,37 @@
+-- name: ListRevisions :many
+SELECT * FROM content_revisions
+WHERE content_id = $1
+ORDER BY version DESC;
+
+-- name: GetRevision :one
+SELECT * FROM content_revisions
+WHERE content_id = $1 AND version = $2;
+
+-- name: GetLatestRevision :one
+SELECT * FROM content_revisions
+WHERE content_id = $1
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: GetPreviousRevision :one
+SELECT * FROM content_revisions
+WHERE content_id = $1 AND version < $2
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: PruneRevisions :execrows
+DELETE FROM content_revisions r
+USING contents c
+WHERE c.id = r.content_id
+AND c.schema_id = sqlc.arg(schema_id)::uuid
+AND EXISTS (
+  SELECT 1 FROM content_revisions n
+  WHERE n.content_id = r.content_id AND n.version > r.version
+)
+AND (
+  (sqlc.arg(max_age_days)::int > 0 AND r.created_at < now() - make_interval(days => sqlc.arg(max_age_days)::int))
+  OR (sqlc.arg(max_count)::int > 0 AND (
+    SELECT COUNT(*) FROM content_revisions n
+    WHERE n.content_id = r.content_id AND n.version > r.version
+  ) >= sqlc.arg(max_count)::int)
+);
@@ -1,6 +1,6 @@
 -- name: CreateSchema :one
-INSERT INTO schemas (name, definition, created_by)
-VALUES ($1, $2, $3)
+INSERT INTO schemas (name, definition, created_by, rules, settings)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetSchemaByID :one
@@ -16,12 +16,51 @@ SELECT * FROM schemas
 WHERE deleted_at IS NULL
 ORDER BY id;
 
--- name: DeleteSchema :exec
+-- name: LockSchemas :many
+-- Holds the live schemas while a delete checks its dependencies: edits that
+-- add references and content created in them wait for the delete to finish.
+SELECT * FROM schemas
+WHERE deleted_at IS NULL
+ORDER BY id
+FOR UPDATE;
+
+-- name: DeleteSchema :one
+UPDATE schemas
+SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetDeletedSchemaByID :one
+SELECT * FROM schemas
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: ListDeletedSchemas :many
+SELECT * FROM schemas
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: RestoreSchema :one
+UPDATE schemas
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedSchemas :execrows
 DELETE FROM schemas
-WHERE id = $1;
+WHERE deleted_at IS NOT NULL AND deleted_at < $1;
 
 -- name: UpdateSchema :one
 UPDATE schemas
 SET name = $2, definition = $3, updated_at = now()
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: UpdateSchemaSettings :one
+UPDATE schemas
+SET settings = $2, updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetSchemasVersion :one
+SELECT COUNT(*)::bigint AS count, COALESCE(MAX(updated_at), 'epoch')::timestamptz AS updated_at
+FROM schemas;
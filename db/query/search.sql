@@ -0,0 +1,35 @@
+-- name: UpsertSearchDocument :exec
+INSERT INTO content_search (content_id, locale, body, document)
+SELECT sqlc.arg(content_id)::uuid, code, sqlc.arg(body)::text, to_tsvector(search_config::regconfig, sqlc.arg(body)::text)
+FROM locales
+WHERE code = sqlc.arg(locale)
+ON CONFLICT (content_id, locale) DO UPDATE
+SET body = EXCLUDED.body, document = EXCLUDED.document, updated_at = now();
+
+-- name: DeleteSearchDocument :exec
+DELETE FROM content_search
+WHERE content_id = $1 AND locale = $2;
+
+-- name: DeleteSearchDocuments :exec
+DELETE FROM content_search
+WHERE content_id = $1;
+
+-- name: DeleteSearchDocumentsByLocale :exec
+DELETE FROM content_search
+WHERE locale = $1;
+
+-- name: MoveSearchDocuments :exec
+UPDATE content_search
+SET locale = sqlc.arg(to_locale)
+WHERE locale = sqlc.arg(from_locale);
+
+-- name: ReindexSearchLocale :exec
+UPDATE content_search s
+SET document = to_tsvector(l.search_config::regconfig, s.body), updated_at = now()
+FROM locales l
+WHERE l.code = s.locale AND s.locale = $1;
+
+-- name: SearchConfigExists :one
+SELECT EXISTS (
+  SELECT 1 FROM pg_catalog.pg_ts_config WHERE cfgname = $1
+);
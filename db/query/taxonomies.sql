@@ -0,0 +1,87 @@
+-- name: CreateTaxonomy :one
+INSERT INTO taxonomies (name, hierarchical, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetTaxonomyByName :one
+SELECT * FROM taxonomies
+WHERE name = $1;
+
+-- name: ListTaxonomies :many
+SELECT * FROM taxonomies
+ORDER BY name;
+
+-- name: DeleteTaxonomy :exec
+DELETE FROM taxonomies
+WHERE id = $1;
+
+-- name: ListTaxonomyTerms :many
+SELECT * FROM taxonomy_terms
+WHERE taxonomy_id = $1
+ORDER BY position, name;
+
+-- name: GetTaxonomyTerm :one
+SELECT * FROM taxonomy_terms
+WHERE taxonomy_id = $1 AND slug = $2;
+
+-- name: CreateTaxonomyTerm :one
+INSERT INTO taxonomy_terms (taxonomy_id, parent_id, slug, name, position)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: UpdateTaxonomyTerm :one
+UPDATE taxonomy_terms
+SET parent_id = $2, slug = $3, name = $4, position = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: MoveTaxonomyTermChildren :exec
+UPDATE taxonomy_terms
+SET parent_id = sqlc.arg(new_parent_id)
+WHERE parent_id = sqlc.arg(parent_id);
+
+-- name: DeleteTaxonomyTerm :exec
+DELETE FROM taxonomy_terms
+WHERE id = $1;
+
+-- name: FindMissingTerms :many
+SELECT s.slug::text FROM unnest(sqlc.arg(slugs)::text[]) s(slug)
+WHERE NOT EXISTS (
+  SELECT 1 FROM taxonomy_terms t
+  JOIN taxonomies x ON x.id = t.taxonomy_id
+  WHERE x.name = sqlc.arg(taxonomy) AND t.slug = s.slug
+);
+
+-- name: LockTaxonomyTerm :one
+SELECT * FROM taxonomy_terms
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountTermUsage :one
+-- Locks the entries using the term until the transaction ends
+SELECT COUNT(*) FROM (
+  SELECT c.id FROM contents c
+  JOIN schemas s ON s.id = c.schema_id
+  WHERE c.deleted_at IS NULL
+  AND (uses_taxonomy_term(s.definition, c.data, sqlc.arg(taxonomy), sqlc.arg(slug))
+    OR uses_taxonomy_term(s.definition, c.draft_data, sqlc.arg(taxonomy), sqlc.arg(slug)))
+  FOR SHARE OF c
+) used;
+
+-- name: RenameTermInContents :execrows
+-- Every renamed entry gets a new version and a revision of it
+WITH renamed AS (
+  UPDATE contents c
+  SET
+    data = rename_taxonomy_term(s.definition, c.data, sqlc.arg(taxonomy), sqlc.arg(old_slug), sqlc.arg(new_slug)),
+    draft_data = rename_taxonomy_term(s.definition, c.draft_data, sqlc.arg(taxonomy), sqlc.arg(old_slug), sqlc.arg(new_slug)),
+    version = c.version + 1,
+    updated_at = NOW()
+  FROM schemas s
+  WHERE s.id = c.schema_id
+  AND (uses_taxonomy_term(s.definition, c.data, sqlc.arg(taxonomy), sqlc.arg(old_slug))
+    OR uses_taxonomy_term(s.definition, c.draft_data, sqlc.arg(taxonomy), sqlc.arg(old_slug)))
+  RETURNING c.*
+)
+INSERT INTO content_revisions (content_id, version, data, published, created_by)
+SELECT id, version, COALESCE(draft_data, data), COALESCE(published, FALSE), sqlc.narg(author)::uuid FROM renamed;
@@ -0,0 +1,79 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (name, url, secret, events, schema_ids, active, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+ORDER BY created_at;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1;
+
+-- name: GetWebhooksByIDs :many
+SELECT * FROM webhooks
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET name = sqlc.arg(name),
+    url = sqlc.arg(url),
+    events = sqlc.arg(events),
+    schema_ids = sqlc.arg(schema_ids),
+    active = sqlc.arg(active),
+    secret = COALESCE(sqlc.narg(secret), secret)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text);
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: RedeliverWebhook :one
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT webhook_id, event, payload FROM webhook_deliveries
+WHERE webhook_deliveries.id = $1
+RETURNING *;
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET attempts = attempts + 1, next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT d.id FROM webhook_deliveries d
+  WHERE d.status = 'pending' AND d.next_attempt_at <= now()
+  ORDER BY d.next_attempt_at
+  LIMIT sqlc.arg(batch_size)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FinishWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = sqlc.arg(status),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    response_status = sqlc.narg(response_status),
+    response_body = sqlc.narg(response_body),
+    error = sqlc.narg(error),
+    delivered_at = CASE WHEN sqlc.arg(status)::text = 'delivered' THEN now() END
+WHERE id = sqlc.arg(id);
+
+-- name: PurgeWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending' AND created_at < $1;
@@ -0,0 +1,53 @@
+-- name: SetWorkflowState :one
+UPDATE contents
+SET workflow_state = sqlc.arg(to_state)::text
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+AND workflow_state IS NOT DISTINCT FROM sqlc.narg(from_state)
+RETURNING *;
+
+-- name: CreateWorkflowEvent :one
+INSERT INTO workflow_events (content_id, from_state, to_state, comment, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListWorkflowEvents :many
+SELECT * FROM workflow_events
+WHERE content_id = $1
+ORDER BY created_at;
+
+-- name: ListContentAssignees :many
+SELECT user_id FROM content_assignees
+WHERE content_id = $1
+ORDER BY created_at;
+
+-- name: DeleteContentAssignees :exec
+DELETE FROM content_assignees
+WHERE content_id = $1;
+
+-- name: AddContentAssignees :exec
+INSERT INTO content_assignees (content_id, user_id)
+SELECT sqlc.arg(content_id)::uuid, unnest(sqlc.arg(user_ids)::uuid[])
+ON CONFLICT DO NOTHING;
+
+-- name: NotifyAssignees :exec
+INSERT INTO notifications (user_id, content_id, message)
+SELECT a.user_id, a.content_id, sqlc.arg(message)
+FROM content_assignees a
+WHERE a.content_id = sqlc.arg(content_id)
+AND a.user_id IS DISTINCT FROM sqlc.narg(actor_id);
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(unread_only)::bool OR read_at IS NULL)
+ORDER BY created_at DESC
+LIMIT 100;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = now()
+WHERE id = $1 AND user_id = $2 AND read_at IS NULL;
+
+-- name: CreateNotification :exec
+INSERT INTO notifications (user_id, content_id, message)
+VALUES ($1, $2, $3);
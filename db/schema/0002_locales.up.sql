@@ -0,0 +1,43 @@
+-- ========================================
+-- 0002_locales.up.sql
+-- Locale configuration and per-locale content values
+-- ========================================
+
+-- ========================================
+-- Locales table
+-- The default locale's values live in contents.data,
+-- every other locale is stored in content_locales.
+-- ========================================
+CREATE TABLE locales (
+    code TEXT PRIMARY KEY,                     -- e.g. en, de, ja, de-AT
+    name TEXT NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    fallback TEXT NULL REFERENCES locales(code) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TRIGGER trg_locales_updated_at
+BEFORE UPDATE ON locales
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO locales (code, name, is_default) VALUES ('en', 'English', TRUE);
+
+-- ========================================
+-- Content locales table (translations of localized fields)
+-- ========================================
+CREATE TABLE content_locales (
+    content_id UUID NOT NULL REFERENCES contents(id) ON DELETE CASCADE,
+    locale TEXT NOT NULL REFERENCES locales(code) ON DELETE CASCADE,
+    data JSONB NOT NULL DEFAULT '{}',
+    published BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (content_id, locale)
+);
+
+CREATE TRIGGER trg_content_locales_updated_at
+BEFORE UPDATE ON content_locales
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+-- ========================================
+-- 0003_schema_rules.up.sql
+-- Schema-level validation rules (see utils.Rule)
+-- ========================================
+
+ALTER TABLE schemas ADD COLUMN rules JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,8 @@
+-- ========================================
+-- 0004_schema_soft_delete.up.sql
+-- Schemas are soft deleted, so names only need to be unique among live schemas
+-- ========================================
+
+ALTER TABLE schemas DROP CONSTRAINT schemas_name_key;
+
+CREATE UNIQUE INDEX idx_schemas_name_active ON schemas(name) WHERE deleted_at IS NULL;
@@ -0,0 +1,25 @@
+-- ========================================
+-- 0005_content_search.up.sql
+-- Full-text search over searchable content fields
+-- ========================================
+
+-- Text search configuration used for each locale, e.g. english, german, simple
+ALTER TABLE locales ADD COLUMN search_config TEXT NOT NULL DEFAULT 'simple';
+
+UPDATE locales SET search_config = 'english' WHERE code = 'en';
+
+-- ========================================
+-- Content search table
+-- One document per entry and locale. body keeps the indexed text for snippets,
+-- the default locale's document is built from contents.data.
+-- ========================================
+CREATE TABLE content_search (
+    content_id UUID NOT NULL REFERENCES contents(id) ON DELETE CASCADE,
+    locale TEXT NOT NULL REFERENCES locales(code) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    document TSVECTOR NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (content_id, locale)
+);
+
+CREATE INDEX idx_content_search_document ON content_search USING GIN (document);
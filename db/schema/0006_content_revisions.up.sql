@@ -0,0 +1,28 @@
+-- ========================================
+-- 0006_content_revisions.up.sql
+-- Version history of content and per-schema settings
+-- ========================================
+
+-- Per-schema settings, e.g. { "revisions": { "maxCount": 50, "maxAgeDays": 90 } }
+ALTER TABLE schemas ADD COLUMN settings JSONB NOT NULL DEFAULT '{}';
+
+-- ========================================
+-- Content revisions table
+-- Every saved version of an entry's default locale data, the highest
+-- version is the current one and is never pruned.
+-- ========================================
+CREATE TABLE content_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    content_id UUID NOT NULL REFERENCES contents(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    data JSONB NOT NULL,
+    published BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    UNIQUE (content_id, version)
+);
+
+-- Existing entries start their history at version 1
+INSERT INTO content_revisions (content_id, version, data, published, created_by, created_at)
+SELECT id, 1, data, COALESCE(published, FALSE), created_by, COALESCE(updated_at, now())
+FROM contents;
@@ -0,0 +1,8 @@
+-- ========================================
+-- 0007_content_version.up.sql
+-- Version counter for optimistic concurrency control
+-- ========================================
+
+-- Bumped on every write to an entry or its translations. Revisions are
+-- numbered with the version they were saved at.
+ALTER TABLE contents ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- ========================================
+-- 0008_content_schedule.up.sql
+-- Scheduled publishing and unpublishing
+-- ========================================
+
+-- Pending actions, cleared once the scheduler has applied them. Times in the
+-- past are applied on the next run, so nothing is lost while the API is down.
+ALTER TABLE contents
+    ADD COLUMN publish_at TIMESTAMPTZ,
+    ADD COLUMN unpublish_at TIMESTAMPTZ;
+
+CREATE INDEX contents_publish_at_idx ON contents (publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX contents_unpublish_at_idx ON contents (unpublish_at) WHERE unpublish_at IS NOT NULL;
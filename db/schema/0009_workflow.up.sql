@@ -0,0 +1,38 @@
+-- ========================================
+-- 0009_workflow.up.sql
+-- Editorial review workflow and notifications
+-- ========================================
+
+-- NULL is the initial state of the schema's workflow
+ALTER TABLE contents ADD COLUMN workflow_state TEXT;
+
+CREATE TABLE content_assignees (
+    content_id UUID NOT NULL REFERENCES contents(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (content_id, user_id)
+);
+
+-- History of workflow transitions
+CREATE TABLE workflow_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    content_id UUID NOT NULL REFERENCES contents(id) ON DELETE CASCADE,
+    from_state TEXT NOT NULL,
+    to_state TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX workflow_events_content_idx ON workflow_events (content_id, created_at);
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content_id UUID REFERENCES contents(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX notifications_user_idx ON notifications (user_id, created_at DESC);
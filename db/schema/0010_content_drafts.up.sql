@@ -0,0 +1,9 @@
+-- ========================================
+-- 0010_content_drafts.up.sql
+-- Draft copies of published entries
+-- ========================================
+
+-- data is what the public sees. Edits to a published entry are kept in
+-- draft_data until they are published or discarded; NULL means no pending edits.
+ALTER TABLE contents ADD COLUMN draft_data JSONB;
+ALTER TABLE content_locales ADD COLUMN draft_data JSONB;
@@ -0,0 +1,22 @@
+-- ========================================
+-- 0011_content_imports.up.sql
+-- Import runs and their error reports
+-- ========================================
+
+-- report holds the rejected rows in the import's format, NULL when every
+-- row was imported. Old runs are purged after IMPORT_RETENTION_DAYS.
+CREATE TABLE content_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schema_id UUID NOT NULL REFERENCES schemas(id) ON DELETE CASCADE,
+    format TEXT NOT NULL,
+    total INTEGER NOT NULL,
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL,
+    rejected INTEGER NOT NULL,
+    report BYTEA,
+    report_type TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX content_imports_created_idx ON content_imports (created_at);
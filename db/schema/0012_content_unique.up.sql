@@ -0,0 +1,77 @@
+-- ========================================
+-- 0012_content_unique.up.sql
+-- Fields declared unique and lookups by their value
+-- ========================================
+
+-- One row per live entry and unique field, kept in sync with contents.data
+-- by the triggers below. The primary key enforces uniqueness within a schema
+-- and serves lookups by value. Drafts are checked when they go live.
+CREATE TABLE content_unique_values (
+    schema_id UUID NOT NULL REFERENCES schemas(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    value TEXT NOT NULL,
+    content_id UUID NOT NULL REFERENCES contents(id) ON DELETE CASCADE,
+    PRIMARY KEY (schema_id, field, value)
+);
+
+CREATE INDEX idx_content_unique_values_content ON content_unique_values (content_id);
+
+-- Function: store the unique values of one entry. A duplicate raises
+-- unique_violation with the field's name as its column.
+CREATE OR REPLACE FUNCTION index_unique_values(entry contents, definition JSONB)
+RETURNS VOID AS $$
+DECLARE
+    f TEXT;
+BEGIN
+    DELETE FROM content_unique_values WHERE content_id = entry.id;
+    IF entry.deleted_at IS NOT NULL OR entry.schema_id IS NULL THEN
+        RETURN;
+    END IF;
+
+    FOR f IN
+        SELECT d->>'name' FROM jsonb_array_elements(definition) d
+        WHERE (d->>'unique')::BOOLEAN IS TRUE AND entry.data->>(d->>'name') IS NOT NULL
+    LOOP
+        BEGIN
+            INSERT INTO content_unique_values (schema_id, field, value, content_id)
+            VALUES (entry.schema_id, f, entry.data->>f, entry.id);
+        EXCEPTION WHEN unique_violation THEN
+            RAISE unique_violation USING MESSAGE = format('value of unique field %s is already in use', f), COLUMN = f, TABLE = 'content_unique_values';
+        END;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION sync_content_unique_values()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM index_unique_values(NEW, (SELECT definition FROM schemas WHERE id = NEW.schema_id));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_contents_unique_values
+AFTER INSERT OR UPDATE OF data, deleted_at, schema_id ON contents
+FOR EACH ROW
+EXECUTE FUNCTION sync_content_unique_values();
+
+-- A changed definition rebuilds the schema's values, failing when existing
+-- entries share a value of a newly unique field
+CREATE OR REPLACE FUNCTION sync_schema_unique_values()
+RETURNS TRIGGER AS $$
+DECLARE
+    entry contents;
+BEGIN
+    DELETE FROM content_unique_values WHERE schema_id = NEW.id;
+    FOR entry IN SELECT * FROM contents WHERE schema_id = NEW.id AND deleted_at IS NULL LOOP
+        PERFORM index_unique_values(entry, NEW.definition);
+    END LOOP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_schemas_unique_values
+AFTER UPDATE OF definition ON schemas
+FOR EACH ROW
+WHEN (OLD.definition IS DISTINCT FROM NEW.definition)
+EXECUTE FUNCTION sync_schema_unique_values();
@@ -0,0 +1,166 @@
+-- ========================================
+-- 0013_webhooks.up.sql
+-- Webhook subscriptions and their delivery queue
+-- ========================================
+
+-- Empty events or schema_ids match everything. Events without a schema,
+-- like media ones, only reach webhooks without a schema filter.
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    schema_ids UUID[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER trg_webhooks_updated_at
+BEFORE UPDATE ON webhooks
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- The queue and delivery log in one. Pending rows are picked up once
+-- next_attempt_at passes; after the last failed attempt a row is dead.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    response_status INTEGER,
+    response_body TEXT,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at DESC);
+
+-- Function: queue an event for every active webhook that subscribes to it.
+-- It runs in the transaction of the write, so only committed changes go out.
+CREATE OR REPLACE FUNCTION emit_event(event TEXT, schema_id UUID, data JSONB)
+RETURNS VOID AS $$
+DECLARE
+    payload JSONB := jsonb_build_object('id', gen_random_uuid(), 'event', event, 'createdAt', now(), 'data', data);
+BEGIN
+    INSERT INTO webhook_deliveries (webhook_id, event, payload)
+    SELECT w.id, event, payload
+    FROM webhooks w
+    WHERE w.active
+      AND (cardinality(w.events) = 0 OR event = ANY(w.events))
+      AND (cardinality(w.schema_ids) = 0 OR schema_id = ANY(w.schema_ids));
+END;
+$$ LANGUAGE plpgsql;
+
+-- content.published fires whenever the live data changes: when an entry is
+-- published and when a published entry's data is replaced. Draft saves and
+-- translations only fire content.updated.
+CREATE OR REPLACE FUNCTION content_events()
+RETURNS TRIGGER AS $$
+DECLARE
+    data JSONB := jsonb_build_object(
+        'id', NEW.id,
+        'schemaId', NEW.schema_id,
+        'schema', (SELECT name FROM schemas WHERE id = NEW.schema_id),
+        'version', NEW.version,
+        'published', NEW.published,
+        'data', NEW.data,
+        'updatedAt', NEW.updated_at
+    );
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM emit_event('content.created', NEW.schema_id, data);
+        IF NEW.published THEN
+            PERFORM emit_event('content.published', NEW.schema_id, data);
+        END IF;
+        RETURN NULL;
+    END IF;
+
+    IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        PERFORM emit_event('content.deleted', NEW.schema_id, data);
+        RETURN NULL;
+    END IF;
+    IF NEW.deleted_at IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+    IF OLD.deleted_at IS NOT NULL THEN
+        PERFORM emit_event('content.restored', NEW.schema_id, data);
+    ELSIF NEW.data IS DISTINCT FROM OLD.data OR NEW.draft_data IS DISTINCT FROM OLD.draft_data OR NEW.version IS DISTINCT FROM OLD.version THEN
+        PERFORM emit_event('content.updated', NEW.schema_id, data);
+    END IF;
+
+    IF NEW.published AND (OLD.published IS NOT TRUE OR OLD.deleted_at IS NOT NULL OR NEW.data IS DISTINCT FROM OLD.data) THEN
+        PERFORM emit_event('content.published', NEW.schema_id, data);
+    ELSIF OLD.published AND NEW.published IS NOT TRUE THEN
+        PERFORM emit_event('content.unpublished', NEW.schema_id, data);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_contents_events
+AFTER INSERT OR UPDATE ON contents
+FOR EACH ROW
+EXECUTE FUNCTION content_events();
+
+CREATE OR REPLACE FUNCTION schema_events()
+RETURNS TRIGGER AS $$
+DECLARE
+    data JSONB := jsonb_build_object(
+        'id', NEW.id,
+        'name', NEW.name,
+        'definition', NEW.definition,
+        'updatedAt', NEW.updated_at
+    );
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM emit_event('schema.created', NEW.id, data);
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        PERFORM emit_event('schema.deleted', NEW.id, data);
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        PERFORM emit_event('schema.restored', NEW.id, data);
+    ELSIF NEW.deleted_at IS NULL AND (NEW.name, NEW.definition, NEW.rules, NEW.settings) IS DISTINCT FROM (OLD.name, OLD.definition, OLD.rules, OLD.settings) THEN
+        PERFORM emit_event('schema.updated', NEW.id, data);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_schemas_events
+AFTER INSERT OR UPDATE ON schemas
+FOR EACH ROW
+EXECUTE FUNCTION schema_events();
+
+CREATE OR REPLACE FUNCTION media_events()
+RETURNS TRIGGER AS $$
+DECLARE
+    data JSONB := jsonb_build_object(
+        'id', NEW.id,
+        'key', NEW.key,
+        'url', NEW.url,
+        'type', NEW.type,
+        'updatedAt', NEW.updated_at
+    );
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM emit_event('media.uploaded', NULL, data);
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        PERFORM emit_event('media.deleted', NULL, data);
+    ELSIF NEW.deleted_at IS NULL THEN
+        PERFORM emit_event('media.updated', NULL, data);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_media_events
+AFTER INSERT OR UPDATE ON media
+FOR EACH ROW
+EXECUTE FUNCTION media_events();
@@ -0,0 +1,39 @@
+-- ========================================
+-- 0014_events.up.sql
+-- Change event log behind the /events stream
+-- ========================================
+
+-- Every event raised by the triggers of 0013 is kept here for a while, so
+-- clients can resume a stream from the last id they saw.
+CREATE TABLE events (
+    id BIGSERIAL PRIMARY KEY,
+    event TEXT NOT NULL,
+    schema_id UUID,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_events_created_at ON events (created_at);
+
+-- Function: log the event, announce it to the API replicas listening on
+-- nota_events and queue it for the subscribed webhooks. Notifications are
+-- only sent on commit and carry the id, payloads can exceed their size limit.
+CREATE OR REPLACE FUNCTION emit_event(event TEXT, schema_id UUID, data JSONB)
+RETURNS VOID AS $$
+DECLARE
+    payload JSONB := jsonb_build_object('id', gen_random_uuid(), 'event', event, 'createdAt', now(), 'data', data);
+    event_id BIGINT;
+BEGIN
+    INSERT INTO events (event, schema_id, data)
+    VALUES (event, schema_id, data)
+    RETURNING id INTO event_id;
+    PERFORM pg_notify('nota_events', event_id::text);
+
+    INSERT INTO webhook_deliveries (webhook_id, event, payload)
+    SELECT w.id, event, payload
+    FROM webhooks w
+    WHERE w.active
+      AND (cardinality(w.events) = 0 OR event = ANY(w.events))
+      AND (cardinality(w.schema_ids) = 0 OR schema_id = ANY(w.schema_ids));
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,6 @@
+-- ========================================
+-- 0015_content_sync.up.sql
+-- Walks a schema's changes in order for /content/sync
+-- ========================================
+
+CREATE INDEX idx_contents_schema_changes ON contents (schema_id, updated_at, id);
@@ -0,0 +1,112 @@
+-- ========================================
+-- 0016_richtext_links.up.sql
+-- Links from rich text fields to other entries and to URLs such as media
+-- ========================================
+
+-- One row per link of an entry's rich text, live or draft, kept in sync by
+-- the triggers below. locale is NULL for the default locale's data.
+-- Targets are not foreign keys: links to deleted entries or media stay
+-- listed so they can be found and fixed.
+CREATE TABLE content_links (
+    content_id UUID NOT NULL REFERENCES contents(id) ON DELETE CASCADE,
+    locale TEXT NULL REFERENCES locales(code) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    target_content_id UUID NULL,               -- content:<id> links
+    url TEXT NULL,                             -- link hrefs and image srcs
+    CHECK ((target_content_id IS NULL) <> (url IS NULL))
+);
+
+CREATE INDEX idx_content_links_content ON content_links (content_id, locale);
+CREATE INDEX idx_content_links_target ON content_links (target_content_id) WHERE target_content_id IS NOT NULL;
+CREATE INDEX idx_content_links_url ON content_links (url) WHERE url IS NOT NULL;
+
+-- Function: the links in the rich text fields of some data. Rich text that
+-- is still a string, from before documents, has none.
+CREATE OR REPLACE FUNCTION richtext_links(definition JSONB, data JSONB)
+RETURNS TABLE (field TEXT, target_content_id UUID, url TEXT) AS $$
+    WITH docs AS (
+        SELECT d->>'name' AS field, doc
+        FROM jsonb_array_elements(definition) d,
+        LATERAL jsonb_array_elements(
+            CASE jsonb_typeof(data->(d->>'name'))
+                WHEN 'array' THEN data->(d->>'name')
+                ELSE jsonb_build_array(data->(d->>'name'))
+            END
+        ) doc
+        WHERE d->'type' IN ('"richtext"'::jsonb, '["richtext"]'::jsonb)
+          AND jsonb_typeof(doc) = 'object'
+    )
+    SELECT field, (l #>> '{}')::uuid, NULL
+    FROM docs, LATERAL jsonb_path_query(doc, 'strict $.** ? (@.type == "link" && @.attrs.contentId.type() == "string").attrs.contentId') l
+    UNION
+    SELECT field, NULL, u #>> '{}'
+    FROM docs, LATERAL jsonb_path_query(doc, 'strict $.** ? (@.type == "link" && @.attrs.href.type() == "string").attrs.href') u
+    UNION
+    SELECT field, NULL, u #>> '{}'
+    FROM docs, LATERAL jsonb_path_query(doc, 'strict $.** ? (@.type == "image" && @.attrs.src.type() == "string").attrs.src') u;
+$$ LANGUAGE sql STABLE;
+
+-- Function: store the links of one entry and locale, from its data and draft
+CREATE OR REPLACE FUNCTION index_content_links(entry_id UUID, entry_locale TEXT, definition JSONB, data JSONB, draft JSONB)
+RETURNS VOID AS $$
+BEGIN
+    DELETE FROM content_links WHERE content_id = entry_id AND locale IS NOT DISTINCT FROM entry_locale;
+    INSERT INTO content_links (content_id, locale, field, target_content_id, url)
+    SELECT entry_id, entry_locale, l.field, l.target_content_id, l.url
+    FROM (
+        SELECT * FROM richtext_links(definition, data)
+        UNION
+        SELECT * FROM richtext_links(definition, draft)
+    ) l;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION sync_content_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM index_content_links(NEW.id, NULL, (SELECT definition FROM schemas WHERE id = NEW.schema_id), NEW.data, NEW.draft_data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_contents_links
+AFTER INSERT OR UPDATE OF data, draft_data, schema_id ON contents
+FOR EACH ROW
+EXECUTE FUNCTION sync_content_links();
+
+CREATE OR REPLACE FUNCTION sync_content_locale_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM content_links WHERE content_id = OLD.content_id AND locale = OLD.locale;
+        RETURN OLD;
+    END IF;
+    PERFORM index_content_links(NEW.content_id, NEW.locale,
+        (SELECT s.definition FROM contents c JOIN schemas s ON s.id = c.schema_id WHERE c.id = NEW.content_id),
+        NEW.data, NEW.draft_data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_content_locales_links
+AFTER INSERT OR UPDATE OF data, draft_data OR DELETE ON content_locales
+FOR EACH ROW
+EXECUTE FUNCTION sync_content_locale_links();
+
+-- A changed definition finds the links of fields that became rich text
+CREATE OR REPLACE FUNCTION sync_schema_links()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM index_content_links(c.id, NULL, NEW.definition, c.data, c.draft_data)
+    FROM contents c WHERE c.schema_id = NEW.id;
+    PERFORM index_content_links(l.content_id, l.locale, NEW.definition, l.data, l.draft_data)
+    FROM content_locales l JOIN contents c ON c.id = l.content_id WHERE c.schema_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_schemas_links
+AFTER UPDATE OF definition ON schemas
+FOR EACH ROW
+WHEN (OLD.definition IS DISTINCT FROM NEW.definition)
+EXECUTE FUNCTION sync_schema_links();
@@ -0,0 +1,96 @@
+-- ========================================
+-- 0017_taxonomies.up.sql
+-- Tag sets and hierarchical categories that taxonomy fields pick terms from
+-- ========================================
+
+CREATE TABLE taxonomies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    hierarchical BOOLEAN NOT NULL DEFAULT FALSE,  -- terms may have a parent
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER trg_taxonomies_updated_at
+BEFORE UPDATE ON taxonomies
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Entries hold the slugs of their terms, so slugs are unique in a taxonomy
+-- whatever the term's place in the tree
+CREATE TABLE taxonomy_terms (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    taxonomy_id UUID NOT NULL REFERENCES taxonomies(id) ON DELETE CASCADE,
+    parent_id UUID NULL REFERENCES taxonomy_terms(id) ON DELETE CASCADE,
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,  -- order among siblings
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (taxonomy_id, slug)
+);
+
+CREATE INDEX idx_taxonomy_terms_parent ON taxonomy_terms (parent_id);
+
+CREATE TRIGGER trg_taxonomy_terms_updated_at
+BEFORE UPDATE ON taxonomy_terms
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Function: the slugs of some terms and of every term below them
+CREATE OR REPLACE FUNCTION taxonomy_subtree(taxonomy_name TEXT, term_slugs TEXT[])
+RETURNS SETOF TEXT AS $$
+    WITH RECURSIVE tree AS (
+        SELECT t.id, t.slug FROM taxonomy_terms t
+        JOIN taxonomies x ON x.id = t.taxonomy_id
+        WHERE x.name = taxonomy_name AND t.slug = ANY(term_slugs)
+        UNION
+        SELECT t.id, t.slug FROM taxonomy_terms t
+        JOIN tree ON t.parent_id = tree.id
+    )
+    SELECT slug FROM tree;
+$$ LANGUAGE sql STABLE;
+
+-- Function: every term of a taxonomy paired with itself and each of its
+-- ancestors, to count entries under a term
+CREATE OR REPLACE FUNCTION taxonomy_ancestry(taxonomy_name TEXT)
+RETURNS TABLE (slug TEXT, ancestor TEXT) AS $$
+    WITH RECURSIVE terms AS (
+        SELECT t.id, t.parent_id, t.slug FROM taxonomy_terms t
+        JOIN taxonomies x ON x.id = t.taxonomy_id
+        WHERE x.name = taxonomy_name
+    ), up AS (
+        SELECT slug, slug AS ancestor, parent_id FROM terms
+        UNION
+        SELECT up.slug, p.slug, p.parent_id FROM up
+        JOIN terms p ON p.id = up.parent_id
+    )
+    SELECT slug, ancestor FROM up;
+$$ LANGUAGE sql STABLE;
+
+-- Function: whether some data holds a term in a field of the taxonomy
+CREATE OR REPLACE FUNCTION uses_taxonomy_term(definition JSONB, data JSONB, taxonomy_name TEXT, term_slug TEXT)
+RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM jsonb_array_elements(definition) d
+        WHERE d->>'taxonomy' = taxonomy_name
+          AND (data->(d->>'name') = to_jsonb(term_slug) OR data->(d->>'name') @> jsonb_build_array(term_slug))
+    );
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Function: some data with a term's slug replaced in the fields of the taxonomy
+CREATE OR REPLACE FUNCTION rename_taxonomy_term(definition JSONB, data JSONB, taxonomy_name TEXT, old_slug TEXT, new_slug TEXT)
+RETURNS JSONB AS $$
+    SELECT COALESCE(data || jsonb_object_agg(d->>'name',
+        CASE jsonb_typeof(data->(d->>'name'))
+            WHEN 'array' THEN (
+                SELECT jsonb_agg(CASE WHEN e = to_jsonb(old_slug) THEN to_jsonb(new_slug) ELSE e END)
+                FROM jsonb_array_elements(data->(d->>'name')) e
+            )
+            ELSE to_jsonb(new_slug)
+        END), data)
+    FROM jsonb_array_elements(definition) d
+    WHERE d->>'taxonomy' = taxonomy_name
+      AND (data->(d->>'name') = to_jsonb(old_slug) OR data->(d->>'name') @> jsonb_build_array(old_slug));
+$$ LANGUAGE sql IMMUTABLE;
@@ -0,0 +1,8 @@
+-- ========================================
+-- 0018_locale_draft_published.up.sql
+-- Pending publish state of translation drafts
+-- ========================================
+
+-- Like the entry's own published flag, a translation's flag only changes when
+-- its draft goes live; NULL means no pending edits.
+ALTER TABLE content_locales ADD COLUMN draft_published BOOLEAN;
@@ -0,0 +1,27 @@
+-- ========================================
+-- 0019_content_change_xid.up.sql
+-- Commit-safe change marker for /content/sync
+-- ========================================
+
+-- updated_at is taken when a transaction starts, so a change can commit after
+-- a sync already read past its time. The id of the writing transaction is a
+-- safe marker instead: every transaction below the xmin of a snapshot has
+-- finished, so once a sync has read up to that xmin no change can turn up
+-- behind it.
+ALTER TABLE contents ADD COLUMN change_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE OR REPLACE FUNCTION set_change_xid()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.change_xid = pg_current_xact_id();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_contents_change_xid
+BEFORE INSERT OR UPDATE ON contents
+FOR EACH ROW
+EXECUTE FUNCTION set_change_xid();
+
+DROP INDEX idx_contents_schema_changes;
+CREATE INDEX idx_contents_schema_changes ON contents (schema_id, change_xid, id);
@@ -0,0 +1,52 @@
+-- ========================================
+-- 0020_event_was_published.up.sql
+-- Whether an entry was live before the change that raised an event
+-- ========================================
+
+-- Events on updates carry wasPublished, so the public stream only tells
+-- anonymous clients about entries leaving the published content they could
+-- have seen.
+CREATE OR REPLACE FUNCTION content_events()
+RETURNS TRIGGER AS $$
+DECLARE
+    data JSONB := jsonb_build_object(
+        'id', NEW.id,
+        'schemaId', NEW.schema_id,
+        'schema', (SELECT name FROM schemas WHERE id = NEW.schema_id),
+        'version', NEW.version,
+        'published', NEW.published,
+        'data', NEW.data,
+        'updatedAt', NEW.updated_at
+    );
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM emit_event('content.created', NEW.schema_id, data);
+        IF NEW.published THEN
+            PERFORM emit_event('content.published', NEW.schema_id, data);
+        END IF;
+        RETURN NULL;
+    END IF;
+
+    data := data || jsonb_build_object('wasPublished', COALESCE(OLD.published, FALSE) AND OLD.deleted_at IS NULL);
+
+    IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        PERFORM emit_event('content.deleted', NEW.schema_id, data);
+        RETURN NULL;
+    END IF;
+    IF NEW.deleted_at IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+    IF OLD.deleted_at IS NOT NULL THEN
+        PERFORM emit_event('content.restored', NEW.schema_id, data);
+    ELSIF NEW.data IS DISTINCT FROM OLD.data OR NEW.draft_data IS DISTINCT FROM OLD.draft_data OR NEW.version IS DISTINCT FROM OLD.version THEN
+        PERFORM emit_event('content.updated', NEW.schema_id, data);
+    END IF;
+
+    IF NEW.published AND (OLD.published IS NOT TRUE OR OLD.deleted_at IS NOT NULL OR NEW.data IS DISTINCT FROM OLD.data) THEN
+        PERFORM emit_event('content.published', NEW.schema_id, data);
+    ELSIF OLD.published AND NEW.published IS NOT TRUE THEN
+        PERFORM emit_event('content.unpublished', NEW.schema_id, data);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,12 @@
+-- ========================================
+-- 0021_event_xid.up.sql
+-- Commit-safe resume position for the event stream
+-- ========================================
+
+-- Event ids are taken on insert, so an event can commit after a stream already
+-- resumed past its id. Like /content/sync, the stream orders events by the id
+-- of the transaction that raised them and only reads below the xmin of a
+-- snapshot, where every transaction has finished.
+ALTER TABLE events ADD COLUMN xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX idx_events_xid ON events (xid, id);
@@ -0,0 +1,31 @@
+-- ========================================
+-- 0022_content_was_published.up.sql
+-- Whether an entry was ever live, for /content/sync removals
+-- ========================================
+
+-- Sync clients only hold entries that were published at some point, so only
+-- those get a removal when they are unpublished or trashed. Drafts that never
+-- went live stay out of the public feed entirely.
+ALTER TABLE contents ADD COLUMN was_published BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE OR REPLACE FUNCTION set_was_published()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.was_published = NEW.was_published OR COALESCE(NEW.published, FALSE);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_contents_was_published
+BEFORE INSERT OR UPDATE ON contents
+FOR EACH ROW
+EXECUTE FUNCTION set_was_published();
+
+-- Entries published before now, judged by their revisions. The other triggers
+-- stay off so the backfill is not taken for a change.
+ALTER TABLE contents DISABLE TRIGGER USER;
+UPDATE contents c SET was_published = TRUE
+WHERE c.published OR EXISTS (
+    SELECT 1 FROM content_revisions r WHERE r.content_id = c.id AND r.published
+);
+ALTER TABLE contents ENABLE TRIGGER USER;
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	db "github.com/manthan307/nota-cms/db/output"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// RegisterJobs starts the background jobs with the app lifecycle
+func RegisterJobs(lc fx.Lifecycle, queries *db.Queries, logger *zap.Logger) {
+	every(lc, logger, "purge deleted schemas", time.Hour, PurgeDeletedSchemas(queries, logger))
+	every(lc, logger, "purge deleted content", time.Hour, PurgeDeletedContents(queries, logger))
+	every(lc, logger, "prune content revisions", time.Hour, PruneRevisions(queries, logger))
+	every(lc, logger, "publish scheduled content", time.Minute, PublishScheduled(queries, logger))
+	every(lc, logger, "purge import reports", time.Hour, PurgeImports(queries, logger))
+	every(lc, logger, "deliver webhooks", 5*time.Second, DeliverWebhooks(queries, logger))
+	every(lc, logger, "purge webhook deliveries", time.Hour, PurgeWebhookDeliveries(queries, logger))
+	every(lc, logger, "purge events", time.Hour, PurgeEvents(queries, logger))
+}
+
+// every runs fn once per interval until the app stops.
+// Jobs must be safe to run concurrently on several API replicas.
+func every(lc fx.Lifecycle, logger *zap.Logger, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					if err := fn(ctx); err != nil && ctx.Err() == nil {
+						logger.Error("job failed", zap.String("job", name), zap.Error(err))
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+// PurgeDeletedSchemas permanently removes schemas (and through ON DELETE CASCADE
+// their content) that have been in the trash longer than SCHEMA_RETENTION_DAYS (default 30)
+func PurgeDeletedSchemas(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	keep := utils.Retention("SCHEMA_RETENTION_DAYS", 30)
+
+	return func(ctx context.Context) error {
+		cutoff := pgtype.Timestamptz{Time: time.Now().Add(-keep), Valid: true}
+		n, err := queries.PurgeDeletedSchemas(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logger.Info("purged deleted schemas", zap.Int64("count", n))
+		}
+		return nil
+	}
+}
+
+// PurgeDeletedContents permanently removes entries that have been in the
+// trash longer than CONTENT_RETENTION_DAYS (default 30)
+func PurgeDeletedContents(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	keep := utils.Retention("CONTENT_RETENTION_DAYS", 30)
+
+	return func(ctx context.Context) error {
+		cutoff := pgtype.Timestamptz{Time: time.Now().Add(-keep), Valid: true}
+		n, err := queries.PurgeDeletedContents(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logger.Info("purged deleted content", zap.Int64("count", n))
+		}
+		return nil
+	}
+}
+
+// PurgeImports removes import runs and their error reports after
+// IMPORT_RETENTION_DAYS (default 7)
+func PurgeImports(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	keep := utils.Retention("IMPORT_RETENTION_DAYS", 7)
+
+	return func(ctx context.Context) error {
+		cutoff := pgtype.Timestamptz{Time: time.Now().Add(-keep), Valid: true}
+		n, err := queries.DeleteContentImportsBefore(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logger.Info("purged import reports", zap.Int64("count", n))
+		}
+		return nil
+	}
+}
+
+// PurgeEvents trims the event log streams resume from after
+// EVENT_RETENTION_DAYS (default 7)
+func PurgeEvents(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	keep := utils.Retention("EVENT_RETENTION_DAYS", 7)
+
+	return func(ctx context.Context) error {
+		cutoff := pgtype.Timestamptz{Time: time.Now().Add(-keep), Valid: true}
+		n, err := queries.PurgeEvents(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logger.Info("purged events", zap.Int64("count", n))
+		}
+		return nil
+	}
+}
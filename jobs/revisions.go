@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+// PruneRevisions drops old content revisions following each schema's
+// settings.revisions policy. The current revision of an entry is always kept.
+func PruneRevisions(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		schemas, err := queries.ListSchemas(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, schema := range schemas {
+			settings, err := utils.ParseSettings(schema.Settings)
+			if err != nil {
+				logger.Warn("invalid schema settings", zap.String("schema", schema.Name), zap.Error(err))
+				continue
+			}
+			policy := settings.Revisions
+			if policy.MaxCount == 0 && policy.MaxAgeDays == 0 {
+				continue
+			}
+
+			n, err := queries.PruneRevisions(ctx, db.PruneRevisionsParams{
+				SchemaID:   schema.ID,
+				MaxAgeDays: int32(policy.MaxAgeDays),
+				MaxCount:   int32(policy.MaxCount),
+			})
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				logger.Info("pruned content revisions", zap.String("schema", schema.Name), zap.Int64("count", n))
+			}
+		}
+		return nil
+	}
+}
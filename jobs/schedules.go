@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils/searchindex"
+	"go.uber.org/zap"
+)
+
+const scheduleBatch = 100
+
+// PublishScheduled applies due publish_at and unpublish_at times. Each batch
+// skips rows another replica has locked, and overdue actions from while the
+// API was down are applied on the first run. When both actions are due the
+// later one decides the published state. Publishing waits while the schema's
+// workflow keeps the entry from being published.
+func PublishScheduled(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for {
+			contents, err := queries.RunDueSchedules(ctx, scheduleBatch)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range contents {
+				content := db.Content(row)
+				logger.Info("applied content schedule", zap.String("content", content.ID.String()), zap.Bool("published", content.Published.Bool))
+
+				// Drafts went live with the entry in the same statement
+				if content.Published.Bool {
+					if err := searchindex.Reindex(ctx, queries, content); err != nil {
+						logger.Error("Error indexing content for search", zap.Error(err))
+					}
+				}
+			}
+
+			if len(contents) < scheduleBatch {
+				return nil
+			}
+		}
+	}
+}
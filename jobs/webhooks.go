@@ -0,0 +1,196 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookBatch       = 20
+	webhookTimeout     = 10 * time.Second
+	webhookMaxAttempts = 8
+	webhookMaxBackoff  = 6 * time.Hour
+	webhookBodyLimit   = 1024 // bytes of the response kept in the log
+)
+
+// DeliverWebhooks sends the due deliveries of the queue. A claimed batch is
+// leased for longer than its requests can take, so other replicas skip it and
+// a crash only delays it. Failures are retried with exponential backoff until
+// webhookMaxAttempts, then the delivery is dead and only a redeliver sends it.
+func DeliverWebhooks(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	client := webhookClient(os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true")
+
+	return func(ctx context.Context) error {
+		for {
+			deliveries, err := queries.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+				LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(2 * webhookTimeout), Valid: true},
+				BatchSize:  webhookBatch,
+			})
+			if err != nil || len(deliveries) == 0 {
+				return err
+			}
+
+			ids := make([]uuid.UUID, len(deliveries))
+			for i, d := range deliveries {
+				ids[i] = d.WebhookID
+			}
+			hooks, err := queries.GetWebhooksByIDs(ctx, ids)
+			if err != nil {
+				return err
+			}
+			byID := map[uuid.UUID]db.Webhook{}
+			for _, h := range hooks {
+				byID[h.ID] = h
+			}
+
+			var wg sync.WaitGroup
+			for _, d := range deliveries {
+				hook, ok := byID[d.WebhookID]
+				if !ok {
+					continue // deleted since, its deliveries went with it
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					deliverWebhook(ctx, queries, logger, client, hook, d)
+				}()
+			}
+			wg.Wait()
+
+			if len(deliveries) < webhookBatch {
+				return nil
+			}
+		}
+	}
+}
+
+// webhookClient doesn't follow redirects and, unless allowPrivate is set,
+// only connects to public addresses. The address is checked as it is dialed,
+// after DNS, so a name can't resolve to an internal host between checks.
+func webhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !utils.PublicAddr(addr.Addr()) {
+				return fmt.Errorf("webhook target %s is not a public address", address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		// a redirect is answered like any other non-2xx status
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func deliverWebhook(ctx context.Context, queries *db.Queries, logger *zap.Logger, client *http.Client, hook db.Webhook, d db.WebhookDelivery) {
+	finish := db.FinishWebhookDeliveryParams{ID: d.ID, Status: "delivered", NextAttemptAt: d.NextAttemptAt}
+
+	var err error
+	if !hook.Active {
+		err = fmt.Errorf("webhook is disabled")
+	} else {
+		var status int
+		var body string
+		status, body, err = sendWebhook(ctx, client, hook, d)
+		if status != 0 {
+			finish.ResponseStatus = pgtype.Int4{Int32: int32(status), Valid: true}
+			finish.ResponseBody = pgtype.Text{String: body, Valid: true}
+		}
+	}
+
+	if err != nil {
+		finish.Error = pgtype.Text{String: err.Error(), Valid: true}
+		finish.Status = "pending"
+		finish.NextAttemptAt = pgtype.Timestamptz{Time: time.Now().Add(webhookBackoff(d.Attempts)), Valid: true}
+		if d.Attempts >= webhookMaxAttempts || !hook.Active {
+			finish.Status = "dead"
+			logger.Warn("webhook delivery failed for good", zap.String("webhook", hook.Name), zap.String("delivery", d.ID.String()), zap.Error(err))
+		}
+	}
+	if err := queries.FinishWebhookDelivery(ctx, finish); err != nil {
+		logger.Error("Error recording webhook delivery", zap.Error(err))
+	}
+}
+
+// sendWebhook posts the payload, anything but a 2xx answer is an error
+func sendWebhook(ctx context.Context, client *http.Client, hook db.Webhook, d db.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nota-cms-webhooks")
+	req.Header.Set("X-Nota-Event", d.Event)
+	req.Header.Set("X-Nota-Delivery", d.ID.String())
+	req.Header.Set("X-Nota-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Nota-Signature", utils.SignWebhook(hook.Secret, timestamp, d.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookBodyLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(body), fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// webhookBackoff is the wait after the given attempt: 30s, 1m, 2m, ... up to webhookMaxBackoff
+func webhookBackoff(attempts int32) time.Duration {
+	wait := 30 * time.Second
+	for i := int32(1); i < attempts && wait < webhookMaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, webhookMaxBackoff)
+}
+
+// PurgeWebhookDeliveries drops finished deliveries from the log after
+// WEBHOOK_RETENTION_DAYS (default 30)
+func PurgeWebhookDeliveries(queries *db.Queries, logger *zap.Logger) func(ctx context.Context) error {
+	keep := utils.Retention("WEBHOOK_RETENTION_DAYS", 30)
+
+	return func(ctx context.Context) error {
+		cutoff := pgtype.Timestamptz{Time: time.Now().Add(-keep), Valid: true}
+		n, err := queries.PurgeWebhookDeliveries(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logger.Info("purged webhook deliveries", zap.Int64("count", n))
+		}
+		return nil
+	}
+}
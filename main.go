@@ -1,12 +1,17 @@
 package main
 
 import (
+	"os"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/manthan307/nota-cms/api"
 	v1 "github.com/manthan307/nota-cms/api/v1"
+	"github.com/manthan307/nota-cms/api/v1/events"
+	"github.com/manthan307/nota-cms/cli"
 	postgres "github.com/manthan307/nota-cms/db"
 	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/jobs"
 	"github.com/manthan307/nota-cms/logger"
 	minio_pkg "github.com/manthan307/nota-cms/utils/minio"
 	"go.uber.org/fx"
@@ -17,6 +22,11 @@ import (
 func main() {
 	_ = godotenv.Load()
 
+	// Subcommands like `typegen` run once and exit instead of serving
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	fx.New(
 		fx.Provide(
 			logger.InitLogger,
@@ -27,10 +37,12 @@ func main() {
 
 			minio_pkg.InitS3,
 			api.StartServer,
+			events.NewHub,
 		),
 		fx.Invoke(
 			postgres.RunMigrations,
 			v1.RegisterRoutes,
+			jobs.RegisterJobs,
 		),
 		fx.WithLogger(func(log *zap.Logger) fxevent.Logger {
 			return &fxevent.ZapLogger{Logger: log}
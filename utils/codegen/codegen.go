@@ -0,0 +1,272 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+)
+
+const header = "Code generated by nota-cms typegen. DO NOT EDIT."
+
+var initialisms = map[string]string{
+	"id":   "ID",
+	"url":  "URL",
+	"uuid": "UUID",
+	"api":  "API",
+	"html": "HTML",
+	"json": "JSON",
+	"seo":  "SEO",
+}
+
+type model struct {
+	Name   string
+	Fields []utils.Field
+}
+
+// load turns stored schemas into models sorted by name
+func load(schemas []db.Schema) ([]model, error) {
+	models := make([]model, 0, len(schemas))
+	for _, s := range schemas {
+		fields, err := utils.ParseFields(s.Definition)
+		if err != nil {
+			return nil, fmt.Errorf("schema %q: %w", s.Name, err)
+		}
+		models = append(models, model{Name: s.Name, Fields: fields})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
+
+// Pascal converts snake, kebab or space separated names to PascalCase.
+// Anything but letters and digits separates words, and names that would not
+// start with an upper case letter get a T in front.
+func Pascal(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, p := range parts {
+		if v, ok := initialisms[strings.ToLower(p)]; ok {
+			b.WriteString(v)
+			continue
+		}
+		r := []rune(p)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	out := b.String()
+	if r := []rune(out); len(r) == 0 || !unicode.IsUpper(r[0]) {
+		out = "T" + out
+	}
+	return out
+}
+
+// names hands out identifiers, numbering the ones already taken
+type names map[string]bool
+
+func (n names) take(name string) string {
+	base := name
+	for i := 2; n[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	n[name] = true
+	return name
+}
+
+// idents are the type names of one generated file. Schemas and enum fields
+// whose names collide once converted are numbered in name order.
+type idents struct {
+	taken names
+	types map[string]string    // by schema name
+	enums map[[2]string]string // by schema and field name
+}
+
+func newIdents(models []model, reserved ...string) *idents {
+	id := &idents{taken: names{}, types: map[string]string{}, enums: map[[2]string]string{}}
+	for _, r := range reserved {
+		id.taken[r] = true
+	}
+	for _, m := range models {
+		id.types[m.Name] = id.taken.take(Pascal(m.Name))
+	}
+	for _, m := range models {
+		for _, f := range m.Fields {
+			if elem, _ := f.ElemType(); elem == "enum" {
+				id.enums[[2]string{m.Name, f.Name}] = id.taken.take(id.types[m.Name] + Pascal(f.Name))
+			}
+		}
+	}
+	return id
+}
+
+// typeName names a schema's type, references to unknown schemas included
+func (id *idents) typeName(schema string) string {
+	if name, ok := id.types[schema]; ok {
+		return name
+	}
+	return Pascal(schema)
+}
+
+func (id *idents) enumName(m model, f utils.Field) string {
+	return id.enums[[2]string{m.Name, f.Name}]
+}
+
+// TypeScript renders one interface per schema
+func TypeScript(schemas []db.Schema) (string, error) {
+	models, err := load(schemas)
+	if err != nil {
+		return "", err
+	}
+
+	id := newIdents(models, "ID", "RichText")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\n", header)
+	b.WriteString("/** UUID of a content entry. */\nexport type ID = string;\n")
+	b.WriteString("\n/** Rich text document, or an HTML or Markdown string when read with ?format. */\n" +
+		"export interface RichText {\n  type: string;\n  attrs?: Record<string, unknown>;\n  content?: RichText[];\n" +
+		"  text?: string;\n  marks?: { type: string; attrs?: Record<string, unknown> }[];\n}\n")
+
+	for _, m := range models {
+		for _, f := range m.Fields {
+			if elem, _ := f.ElemType(); elem == "enum" {
+				quoted := make([]string, len(f.Options))
+				for i, o := range f.Options {
+					quoted[i] = fmt.Sprintf("%q", o)
+				}
+				fmt.Fprintf(&b, "\nexport type %s = %s;\n", id.enumName(m, f), strings.Join(quoted, " | "))
+			}
+		}
+
+		fmt.Fprintf(&b, "\nexport interface %s {\n", id.typeName(m.Name))
+		for _, f := range m.Fields {
+			elem, isArray := f.ElemType()
+			switch elem {
+			case "reference":
+				fmt.Fprintf(&b, "  /** References {@link %s} */\n", id.typeName(f.Ref))
+			case "taxonomy":
+				fmt.Fprintf(&b, "  /** Term slugs of the %s taxonomy */\n", f.Taxonomy)
+			}
+			t := tsType(id, m, f, elem)
+			if isArray {
+				t += "[]"
+			}
+			opt := "?"
+			if f.IsRequired {
+				opt = ""
+			}
+			fmt.Fprintf(&b, "  %q%s: %s;\n", f.Name, opt, t)
+		}
+		b.WriteString("}\n")
+	}
+
+	return b.String(), nil
+}
+
+func tsType(id *idents, m model, f utils.Field, elem string) string {
+	switch elem {
+	case "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "json":
+		return "Record<string, unknown>"
+	case "richtext":
+		return "RichText"
+	case "reference":
+		return "ID"
+	case "enum":
+		return id.enumName(m, f)
+	case "":
+		return "unknown"
+	default:
+		return "string"
+	}
+}
+
+// Go renders one struct per schema in the given package
+func Go(schemas []db.Schema, pkg string) (string, error) {
+	models, err := load(schemas)
+	if err != nil {
+		return "", err
+	}
+	if pkg == "" {
+		pkg = "models"
+	}
+
+	id := newIdents(models)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\npackage %s\n", header, pkg)
+
+	for _, m := range models {
+		for _, f := range m.Fields {
+			if elem, _ := f.ElemType(); elem == "enum" {
+				name := id.enumName(m, f)
+				fmt.Fprintf(&b, "\ntype %s string\n\nconst (\n", name)
+				for _, o := range f.Options {
+					fmt.Fprintf(&b, "%s %s = %q\n", id.taken.take(name+Pascal(o)), name, o)
+				}
+				b.WriteString(")\n")
+			}
+		}
+
+		fmt.Fprintf(&b, "\ntype %s struct {\n", id.typeName(m.Name))
+		fieldNames := names{}
+		for _, f := range m.Fields {
+			elem, isArray := f.ElemType()
+			field := fieldNames.take(Pascal(f.Name))
+			t := goType(id, m, f, elem)
+			tag := f.Name
+			switch {
+			case isArray:
+				t = "[]" + t
+				if !f.IsRequired {
+					tag += ",omitempty"
+				}
+			case !f.IsRequired:
+				if !strings.HasPrefix(t, "map[") {
+					t = "*" + t
+				}
+				tag += ",omitempty"
+			}
+			switch elem {
+			case "reference":
+				fmt.Fprintf(&b, "// %s references %s by ID.\n", field, id.typeName(f.Ref))
+			case "taxonomy":
+				fmt.Fprintf(&b, "// %s holds term slugs of the %s taxonomy.\n", field, f.Taxonomy)
+			}
+			fmt.Fprintf(&b, "%s %s `json:%q`\n", field, t, tag)
+		}
+		b.WriteString("}\n")
+	}
+
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("formatting generated code: %w", err)
+	}
+	return string(out), nil
+}
+
+func goType(id *idents, m model, f utils.Field, elem string) string {
+	switch elem {
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "json", "richtext":
+		return "map[string]interface{}"
+	case "enum":
+		return id.enumName(m, f)
+	case "":
+		return "interface{}"
+	default:
+		return "string"
+	}
+}
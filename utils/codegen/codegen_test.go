@@ -0,0 +1,120 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	db "github.com/manthan307/nota-cms/db/output"
+)
+
+var testSchemas = []db.Schema{{
+	Name: "blog_posts",
+	Definition: []byte(`[
+		{"name": "title", "type": "text", "isRequired": true},
+		{"name": "views", "type": "number"},
+		{"name": "tags", "type": ["text"]},
+		{"name": "author", "type": "reference", "ref": "authors"},
+		{"name": "status", "type": "enum", "options": ["draft", "live"], "isRequired": true},
+		{"name": "body", "type": "richtext"}
+	]`),
+}}
+
+func TestTypeScript(t *testing.T) {
+	out, err := TypeScript(testSchemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`export type BlogPostsStatus = "draft" | "live";`,
+		"export interface BlogPosts {",
+		`"title": string;`,
+		`"views"?: number;`,
+		`"tags"?: string[];`,
+		`"author"?: ID;`,
+		`"status": BlogPostsStatus;`,
+		`"body"?: RichText;`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestGo(t *testing.T) {
+	out, err := Go(testSchemas, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ignore gofmt alignment
+	out = strings.Join(strings.Fields(out), " ")
+
+	for _, want := range []string{
+		"package models",
+		"BlogPostsStatusDraft BlogPostsStatus = \"draft\"",
+		"Title string `json:\"title\"`",
+		"Views *float64 `json:\"views,omitempty\"`",
+		"Tags []string `json:\"tags,omitempty\"`",
+		"Author *string `json:\"author,omitempty\"`",
+		"Body map[string]interface{} `json:\"body,omitempty\"`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestPascal(t *testing.T) {
+	cases := map[string]string{
+		"blog_posts": "BlogPosts",
+		"seo-title":  "SEOTitle",
+		"foo:bar":    "FooBar",
+		"1st":        "T1st",
+		"über uns":   "ÜberUns",
+		"名前":         "T名前",
+		"":           "T",
+		"__":         "T",
+	}
+	for in, want := range cases {
+		if got := Pascal(in); got != want {
+			t.Errorf("Pascal(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGoCollisions(t *testing.T) {
+	schemas := []db.Schema{
+		{Name: "a-b", Definition: []byte(`[{"name": "kind", "type": "enum", "options": ["x-y", "x_y"]}]`)},
+		{Name: "a_b", Definition: []byte(`[{"name": "foo:bar", "type": "text"}, {"name": "foo_bar", "type": "text"}, {"name": "1st", "type": "number"}]`)},
+		{Name: "a_b_kind", Definition: []byte(`[]`)},
+	}
+	out, err := Go(schemas, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = strings.Join(strings.Fields(out), " ")
+
+	for _, want := range []string{
+		"type AB struct",
+		"type AB2 struct",
+		"type ABKind struct",
+		"type ABKind2 string",
+		"ABKind2XY ABKind2 = \"x-y\"",
+		"ABKind2XY2 ABKind2 = \"x_y\"",
+		"FooBar *string `json:\"foo:bar,omitempty\"`",
+		"FooBar2 *string `json:\"foo_bar,omitempty\"`",
+		"T1st *float64 `json:\"1st,omitempty\"`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	ts, err := TypeScript(append(schemas, db.Schema{Name: "id", Definition: []byte(`[]`)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ts, "export interface ID2 {") {
+		t.Errorf("schema id should not shadow the ID type:\n%s", ts)
+	}
+}
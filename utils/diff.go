@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// FieldChange is one field that differs between two versions of content data
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"` // added | removed | changed
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+// DiffData compares two versions of content data field by field, sorted by field name
+func DiffData(from, to map[string]interface{}) []FieldChange {
+	keys := map[string]bool{}
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+
+	changes := []FieldChange{}
+	for _, k := range slices.Sorted(maps.Keys(keys)) {
+		a, inFrom := from[k]
+		b, inTo := to[k]
+		switch {
+		case !inFrom:
+			changes = append(changes, FieldChange{Field: k, Change: "added", To: b})
+		case !inTo:
+			changes = append(changes, FieldChange{Field: k, Change: "removed", From: a})
+		case !reflect.DeepEqual(a, b):
+			changes = append(changes, FieldChange{Field: k, Change: "changed", From: a, To: b})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffData(t *testing.T) {
+	from := map[string]interface{}{
+		"title": "Old",
+		"tags":  []interface{}{"a"},
+		"views": float64(1),
+	}
+	to := map[string]interface{}{
+		"title": "New",
+		"tags":  []interface{}{"a"},
+		"body":  "text",
+	}
+
+	got := DiffData(from, to)
+	want := []FieldChange{
+		{Field: "body", Change: "added", To: "text"},
+		{Field: "title", Change: "changed", From: "Old", To: "New"},
+		{Field: "views", Change: "removed", From: float64(1)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if len(DiffData(from, from)) != 0 {
+		t.Error("expected no changes for equal data")
+	}
+}
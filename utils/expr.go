@@ -0,0 +1,516 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A small, side-effect free expression language used by schema rules.
+//
+//	type == 'video'
+//	end_date > start_date && status in ['live', 'scheduled']
+//	!exists(video_url) || price >= 0
+//
+// Identifiers are field names, missing fields evaluate to null. Supported
+// operators: ! && || == != < <= > >= in, plus parentheses, list literals
+// and the exists() builtin. Strings that look like dates compare as dates.
+
+const maxExprLength = 500
+
+type Expr struct {
+	src    string
+	root   node
+	fields []string
+}
+
+// Fields lists the field names the expression refers to
+func (e *Expr) Fields() []string {
+	return e.fields
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// ParseExpr compiles an expression
+func ParseExpr(src string) (*Expr, error) {
+	if len(src) > maxExprLength {
+		return nil, fmt.Errorf("expression longer than %d characters", maxExprLength)
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, seen: map[string]bool{}}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return &Expr{src: src, root: root, fields: p.fields}, nil
+}
+
+// Eval runs the expression against content data
+func (e *Expr) Eval(data map[string]interface{}) (interface{}, error) {
+	return e.root.eval(data)
+}
+
+// Truthy evaluates the expression as a condition
+func (e *Expr) Truthy(data map[string]interface{}) (bool, error) {
+	v, err := e.Eval(data)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// ---------- lexer ----------
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokIdent, string(rs[i:j])})
+			i = j
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i + 1
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, string(rs[i:j])})
+			i = j
+		case r == '\'' || r == '"':
+			j := i + 1
+			var b strings.Builder
+			for j < len(rs) && rs[j] != r {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				b.WriteRune(rs[j])
+				j++
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			toks = append(toks, token{tokString, b.String()})
+			i = j + 1
+		default:
+			two := ""
+			if i+1 < len(rs) {
+				two = string(rs[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				toks = append(toks, token{tokOp, two})
+				i += 2
+				continue
+			}
+			if strings.ContainsRune("!<>()[],", r) {
+				toks = append(toks, token{tokOp, string(r)})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+// ---------- parser ----------
+
+type parser struct {
+	toks   []token
+	pos    int
+	fields []string
+	seen   map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); (t.kind == tokOp || t.kind == tokIdent) && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %q", op)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.accept(op) {
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return compareNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return literal{f}, nil
+	case tokString:
+		return literal{t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		case "exists":
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			f := p.next()
+			if f.kind != tokIdent {
+				return nil, fmt.Errorf("exists() takes a field name")
+			}
+			p.addField(f.text)
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return existsNode{f.text}, nil
+		}
+		p.addField(t.text)
+		return fieldNode{t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			var items []node
+			for !p.accept("]") {
+				if len(items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				n, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, n)
+			}
+			return listNode{items}, nil
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+func (p *parser) addField(name string) {
+	if !p.seen[name] {
+		p.seen[name] = true
+		p.fields = append(p.fields, name)
+	}
+}
+
+// ---------- evaluation ----------
+
+type node interface {
+	eval(data map[string]interface{}) (interface{}, error)
+}
+
+type literal struct{ v interface{} }
+
+func (l literal) eval(map[string]interface{}) (interface{}, error) { return l.v, nil }
+
+type fieldNode struct{ name string }
+
+func (f fieldNode) eval(data map[string]interface{}) (interface{}, error) {
+	return lookup(data, f.name), nil
+}
+
+type existsNode struct{ name string }
+
+func (e existsNode) eval(data map[string]interface{}) (interface{}, error) {
+	v := lookup(data, e.name)
+	return v != nil && v != "", nil
+}
+
+type listNode struct{ items []node }
+
+func (l listNode) eval(data map[string]interface{}) (interface{}, error) {
+	out := make([]interface{}, len(l.items))
+	for i, n := range l.items {
+		v, err := n.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+type notNode struct{ n node }
+
+func (n notNode) eval(data map[string]interface{}) (interface{}, error) {
+	v, err := n.n.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func (l logicNode) eval(data map[string]interface{}) (interface{}, error) {
+	v, err := l.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	if l.op == "&&" && !truthy(v) {
+		return false, nil
+	}
+	if l.op == "||" && truthy(v) {
+		return true, nil
+	}
+	r, err := l.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(r), nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (c compareNode) eval(data map[string]interface{}) (interface{}, error) {
+	a, err := c.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.op {
+	case "==":
+		return equal(a, b), nil
+	case "!=":
+		return !equal(a, b), nil
+	case "in":
+		list, ok := b.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("right side of 'in' must be a list")
+		}
+		for _, item := range list {
+			if equal(a, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	// Ordering comparisons with a missing side are false
+	if a == nil || b == nil {
+		return false, nil
+	}
+	cmp, err := order(a, b)
+	if err != nil {
+		return nil, err
+	}
+	switch c.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// lookup resolves dotted paths into nested json objects
+func lookup(data map[string]interface{}, path string) interface{} {
+	var cur interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	case []interface{}:
+		return len(t) > 0
+	}
+	return true
+}
+
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if cmp, err := order(a, b); err == nil {
+		return cmp == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// ParseDate accepts RFC 3339 timestamps and plain dates
+func ParseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func order(a, b interface{}) (int, error) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			if tx, ok := ParseDate(x); ok {
+				if ty, ok := ParseDate(y); ok {
+					return tx.Compare(ty), nil
+				}
+			}
+			return strings.Compare(x, y), nil
+		}
+	case bool:
+		if y, ok := b.(bool); ok && x == y {
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+}
@@ -0,0 +1,969 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Schema is an executable set of types rooted at the query and mutation objects
+type Schema struct {
+	Query    *Object
+	Mutation *Object // nil when there are no mutations
+
+	opts       Options
+	types      map[string]Named
+	schemaMeta *Field
+	typeMeta   *Field
+}
+
+// Options limit what a single operation may ask for. Zero means no limit.
+// Introspection fields count like any other.
+type Options struct {
+	MaxLength     int // bytes of query text
+	MaxFragments  int
+	MaxDepth      int
+	MaxComplexity int // every field costs 1, times the Multiplier of the fields above it
+}
+
+// NewSchema collects the types reachable from the roots and checks their names
+func NewSchema(query, mutation *Object, opts Options) (*Schema, error) {
+	s := &Schema{Query: query, Mutation: mutation, opts: opts, types: map[string]Named{}}
+	roots := []Type{query, String, Boolean, introspectionSchema}
+	if mutation != nil {
+		roots = append(roots, mutation)
+	}
+	for _, t := range roots {
+		if err := s.collect(t); err != nil {
+			return nil, err
+		}
+	}
+
+	s.schemaMeta = &Field{
+		Name:        "__schema",
+		Description: "Access the current type schema of this server.",
+		Type:        &NonNull{Of: introspectionSchema},
+		Resolve: func(p ResolveParams) (interface{}, error) {
+			return s, nil
+		},
+	}
+	s.typeMeta = &Field{
+		Name:        "__type",
+		Description: "Request the type information of a single type.",
+		Type:        introspectionType,
+		Args:        []*InputValue{{Name: "name", Type: &NonNull{Of: String}}},
+		Resolve: func(p ResolveParams) (interface{}, error) {
+			if t, ok := s.types[p.Args["name"].(string)]; ok {
+				return t, nil
+			}
+			return nil, nil
+		},
+	}
+	return s, nil
+}
+
+func (s *Schema) collect(t Type) error {
+	switch t := t.(type) {
+	case *List:
+		return s.collect(t.Of)
+	case *NonNull:
+		return s.collect(t.Of)
+	case Named:
+		name := t.TypeName()
+		if seen, ok := s.types[name]; ok {
+			if seen != t {
+				return fmt.Errorf("graphql: two different types are named %q", name)
+			}
+			return nil
+		}
+		if !ValidName(name) {
+			return fmt.Errorf("graphql: invalid type name %q", name)
+		}
+		s.types[name] = t
+
+		switch t := t.(type) {
+		case *Object:
+			for _, f := range t.Fields {
+				if !ValidName(f.Name) {
+					return fmt.Errorf("graphql: invalid field name %s.%s", name, f.Name)
+				}
+				if err := s.collect(f.Type); err != nil {
+					return err
+				}
+				for _, a := range f.Args {
+					if err := s.collect(a.Type); err != nil {
+						return err
+					}
+				}
+			}
+		case *InputObject:
+			for _, f := range t.Fields {
+				if err := s.collect(f.Type); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// field looks a field up, with the introspection entry points on the query type
+func (s *Schema) field(t *Object, name string) *Field {
+	if t == s.Query {
+		switch name {
+		case "__schema":
+			return s.schemaMeta
+		case "__type":
+			return s.typeMeta
+		}
+	}
+	return t.Field(name)
+}
+
+// sortedTypes lists the schema's types by name
+func (s *Schema) sortedTypes() []interface{} {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	out := make([]interface{}, len(names))
+	for i, name := range names {
+		out[i] = s.types[name]
+	}
+	return out
+}
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response holds the data of an executed operation and the errors met on the
+// way. Data is left out when the request failed before execution.
+type Response struct {
+	Data     interface{}
+	Errors   []*Error
+	executed bool
+}
+
+func (r *Response) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if len(r.Errors) > 0 {
+		errs, err := json.Marshal(r.Errors)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`"errors":`)
+		buf.Write(errs)
+	}
+	if r.executed {
+		data, err := json.Marshal(r.Data)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.Errors) > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"data":`)
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Operation parses the request and names the kind of its selected operation,
+// so callers can tell queries from mutations before executing
+func (s *Schema) Operation(req Request) (string, error) {
+	doc, err := s.parse(req.Query)
+	if err != nil {
+		return "", err
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return "", err
+	}
+	return op.kind, nil
+}
+
+// Execute runs one operation of the request
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := s.parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+
+	var root *Object
+	switch op.kind {
+	case "query":
+		root = s.Query
+	case "mutation":
+		root = s.Mutation
+	}
+	if root == nil {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("Schema does not support %s operations.", op.kind), Locations: []Location{op.loc}}}}
+	}
+
+	e := &executor{schema: s, doc: doc}
+	if err := e.coerceVariables(op, req.Variables); err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+
+	a := &analysis{executor: e, fragments: map[string]bool{}, measured: map[string]measure{}}
+	m := a.selections(root, op.selections)
+	if a.exceeded != nil {
+		return &Response{Errors: []*Error{a.exceeded}}
+	}
+	if len(a.errors) > 0 {
+		return &Response{Errors: a.errors}
+	}
+	if s.opts.MaxDepth > 0 && m.depth > s.opts.MaxDepth {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("Query is nested %d levels deep, the limit is %d.", m.depth, s.opts.MaxDepth)}}}
+	}
+
+	data, ok := e.executeFields(ctx, root, nil, op.selections, nil)
+	resp := &Response{Errors: e.errors, executed: true}
+	if ok {
+		resp.Data = data
+	}
+	return resp
+}
+
+// parse reads a query document within the size limits
+func (s *Schema) parse(src string) (*document, error) {
+	if s.opts.MaxLength > 0 && len(src) > s.opts.MaxLength {
+		return nil, &Error{Message: fmt.Sprintf("Query is %d bytes long, the limit is %d.", len(src), s.opts.MaxLength)}
+	}
+	doc, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.MaxFragments > 0 && len(doc.fragments) > s.opts.MaxFragments {
+		return nil, &Error{Message: fmt.Sprintf("Query defines %d fragments, the limit is %d.", len(doc.fragments), s.opts.MaxFragments)}
+	}
+	return doc, nil
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+func asError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Message: err.Error()}
+}
+
+// ---------- Values ----------
+
+type executor struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]interface{}
+	errors []*Error
+}
+
+func (e *executor) coerceVariables(op *operation, raw map[string]interface{}) error {
+	e.vars = map[string]interface{}{}
+	for _, def := range op.vars {
+		t, err := e.inputType(def.typ)
+		if err != nil {
+			return &Error{Message: fmt.Sprintf("Variable \"$%s\": %s", def.name, err), Locations: []Location{def.loc}}
+		}
+		v, provided := raw[def.name]
+		if !provided && def.def != nil {
+			if v, err = e.literal(t, def.def); err != nil {
+				return &Error{Message: fmt.Sprintf("Variable \"$%s\" has an invalid default value: %s", def.name, err), Locations: []Location{def.loc}}
+			}
+			e.vars[def.name] = v
+			continue
+		}
+		if !provided {
+			if _, ok := t.(*NonNull); ok {
+				return &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %q was not provided.", def.name, t), Locations: []Location{def.loc}}
+			}
+			continue
+		}
+		if v, err = coerceInput(t, v); err != nil {
+			return &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value: %s", def.name, err), Locations: []Location{def.loc}}
+		}
+		e.vars[def.name] = v
+	}
+	return nil
+}
+
+// inputType resolves a written type, which must be usable as input
+func (e *executor) inputType(ref *typeRef) (Type, error) {
+	var t Type
+	if ref.elem != nil {
+		elem, err := e.inputType(ref.elem)
+		if err != nil {
+			return nil, err
+		}
+		t = &List{Of: elem}
+	} else {
+		named, ok := e.schema.types[ref.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q", ref.name)
+		}
+		if _, ok := named.(*Object); ok {
+			return nil, fmt.Errorf("type %q cannot be used as input", ref.name)
+		}
+		t = named
+	}
+	if ref.nonNull {
+		t = &NonNull{Of: t}
+	}
+	return t, nil
+}
+
+// coerceInput checks a JSON decoded value against an input type
+func coerceInput(t Type, v interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected non-null %s", t)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			c, err := coerceInput(t.Of, item)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *InputObject:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object for %s", t.Name)
+		}
+		return inputObject(t, m, func(f *InputValue, v interface{}) (interface{}, error) {
+			return coerceInput(f.Type, v)
+		})
+	case *Enum:
+		s, ok := v.(string)
+		if !ok || !slices.Contains(t.Values, s) {
+			return nil, fmt.Errorf("value %v does not exist in %q enum", v, t.Name)
+		}
+		return s, nil
+	case *Scalar:
+		return t.Parse(v)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// inputObject fills the fields of an input object, with defaults for missing ones
+func inputObject[V any](t *InputObject, fields map[string]V, coerce func(*InputValue, V) (interface{}, error)) (interface{}, error) {
+	for name := range fields {
+		if !slices.ContainsFunc(t.Fields, func(f *InputValue) bool { return f.Name == name }) {
+			return nil, fmt.Errorf("field %q is not defined by type %q", name, t.Name)
+		}
+	}
+	out := map[string]interface{}{}
+	for _, f := range t.Fields {
+		v, ok := fields[f.Name]
+		if !ok {
+			if f.Default != nil {
+				out[f.Name] = f.Default
+			} else if _, required := f.Type.(*NonNull); required {
+				return nil, fmt.Errorf("field %s.%s of required type %s was not provided", t.Name, f.Name, f.Type)
+			}
+			continue
+		}
+		c, err := coerce(f, v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", f.Name, err)
+		}
+		out[f.Name] = c
+	}
+	return out, nil
+}
+
+// literal turns a value written in the document into a Go value of type t
+func (e *executor) literal(t Type, v value) (interface{}, error) {
+	if name, ok := v.(variable); ok {
+		val, provided := e.vars[string(name)]
+		if _, required := t.(*NonNull); required && (!provided || val == nil) {
+			return nil, fmt.Errorf("variable \"$%s\" must not be null", name)
+		}
+		return val, nil
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if _, isNull := v.(nullValue); isNull {
+			return nil, fmt.Errorf("expected non-null %s", t)
+		}
+		return e.literal(nn.Of, v)
+	}
+	if _, isNull := v.(nullValue); isNull {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.(listValue)
+		if !ok {
+			items = listValue{v}
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			c, err := e.literal(t.Of, item)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *InputObject:
+		obj, ok := v.(objectValue)
+		if !ok {
+			return nil, fmt.Errorf("expected an object for %s", t.Name)
+		}
+		fields := map[string]value{}
+		for _, f := range obj {
+			fields[f.name] = f.value
+		}
+		return inputObject(t, fields, func(f *InputValue, v value) (interface{}, error) {
+			return e.literal(f.Type, v)
+		})
+	case *Enum:
+		s, ok := v.(enumValue)
+		if !ok || !slices.Contains(t.Values, string(s)) {
+			return nil, fmt.Errorf("value %s does not exist in %q enum", printLiteral(v), t.Name)
+		}
+		return string(s), nil
+	case *Scalar:
+		g, err := e.goValue(v, t == JSON)
+		if err != nil {
+			return nil, err
+		}
+		return t.Parse(g)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// goValue converts a scalar literal, or any literal for JSON
+func (e *executor) goValue(v value, structured bool) (interface{}, error) {
+	switch v := v.(type) {
+	case intValue:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return strconv.ParseFloat(string(v), 64)
+		}
+		return n, nil
+	case floatValue:
+		return strconv.ParseFloat(string(v), 64)
+	case stringValue:
+		return string(v), nil
+	case boolValue:
+		return bool(v), nil
+	case variable:
+		return e.vars[string(v)], nil
+	case nullValue:
+		return nil, nil
+	}
+	if !structured {
+		return nil, fmt.Errorf("expected a scalar, got %s", printLiteral(v))
+	}
+	switch v := v.(type) {
+	case enumValue:
+		return string(v), nil
+	case listValue:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			g, err := e.goValue(item, true)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = g
+		}
+		return out, nil
+	case objectValue:
+		out := make(map[string]interface{}, len(v))
+		for _, f := range v {
+			g, err := e.goValue(f.value, true)
+			if err != nil {
+				return nil, err
+			}
+			out[f.name] = g
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unexpected value")
+}
+
+// argValues coerces the arguments of a field, with defaults filled in
+func (e *executor) argValues(defs []*InputValue, nodes []*argument) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	for _, def := range defs {
+		i := slices.IndexFunc(nodes, func(a *argument) bool { return a.name == def.Name })
+		if i >= 0 {
+			if name, isVar := nodes[i].value.(variable); !isVar || hasKey(e.vars, string(name)) {
+				v, err := e.literal(def.Type, nodes[i].value)
+				if err != nil {
+					return nil, fmt.Errorf("argument %q: %w", def.Name, err)
+				}
+				args[def.Name] = v
+				continue
+			}
+		}
+		if def.Default != nil {
+			args[def.Name] = def.Default
+		} else if _, required := def.Type.(*NonNull); required {
+			return nil, fmt.Errorf("argument %q of required type %s was not provided", def.Name, def.Type)
+		}
+	}
+	return args, nil
+}
+
+func hasKey(m map[string]interface{}, k string) bool {
+	_, ok := m[k]
+	return ok
+}
+
+// printLiteral renders a value the way it is written in a query
+func printLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil, nullValue:
+		return "null"
+	case variable:
+		return "$" + string(v)
+	case intValue:
+		return string(v)
+	case floatValue:
+		return string(v)
+	case enumValue:
+		return string(v)
+	case boolValue:
+		return strconv.FormatBool(bool(v))
+	case stringValue:
+		return printLiteral(string(v))
+	case listValue:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = printLiteral(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case objectValue:
+		parts := make([]string, len(v))
+		for i, f := range v {
+			parts[i] = f.name + ": " + printLiteral(f.value)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = printLiteral(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ": " + printLiteral(v[k])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// ---------- Validation ----------
+
+// analysis checks the selections against the schema before anything runs and
+// measures their depth and complexity. The walk stops as soon as the complexity
+// is over the limit, and every fragment is measured once however often it is spread.
+type analysis struct {
+	*executor
+	fragments map[string]bool    // fragments on the current path, to catch cycles
+	measured  map[string]measure // fragments already walked
+	exceeded  *Error
+	errors    []*Error
+}
+
+type measure struct{ depth, cost int }
+
+func (a *analysis) errorf(loc Location, format string, args ...interface{}) {
+	a.errors = append(a.errors, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+// overLimit records the first cost over the complexity limit. Costs only grow
+// towards the root, so the whole operation is over it too.
+func (a *analysis) overLimit(cost int) bool {
+	limit := a.schema.opts.MaxComplexity
+	if a.exceeded == nil && limit > 0 && cost > limit {
+		a.exceeded = &Error{Message: fmt.Sprintf("Query has a complexity of at least %d, the limit is %d.", cost, limit)}
+	}
+	return a.exceeded != nil
+}
+
+// selections returns the depth and complexity of a selection set
+func (a *analysis) selections(t *Object, sels []selection) (m measure) {
+	for _, sel := range sels {
+		var sm measure
+		switch sel := sel.(type) {
+		case *fieldNode:
+			a.directives(sel.directives)
+			sm = a.field(t, sel)
+		case *fragmentSpread:
+			a.directives(sel.directives)
+			f, ok := a.doc.fragments[sel.name]
+			if !ok {
+				a.errorf(sel.loc, "Unknown fragment %q.", sel.name)
+				continue
+			}
+			if a.fragments[sel.name] {
+				a.errorf(sel.loc, "Cannot spread fragment %q within itself.", sel.name)
+				continue
+			}
+			if f.on != t.Name {
+				a.errorf(sel.loc, "Fragment %q cannot be spread here as objects of type %q can never be of type %q.", sel.name, t.Name, f.on)
+				continue
+			}
+			if done, ok := a.measured[sel.name]; ok {
+				sm = done
+				break
+			}
+			a.fragments[sel.name] = true
+			sm = a.selections(t, f.selections)
+			delete(a.fragments, sel.name)
+			a.measured[sel.name] = sm
+		case *inlineFragment:
+			a.directives(sel.directives)
+			if sel.on != "" && sel.on != t.Name {
+				a.errorf(sel.loc, "Fragment cannot be spread here as objects of type %q can never be of type %q.", t.Name, sel.on)
+				continue
+			}
+			sm = a.selections(t, sel.selections)
+		}
+		m.depth = max(m.depth, sm.depth)
+		m.cost = min(m.cost+sm.cost, math.MaxInt/2)
+		if a.overLimit(m.cost) {
+			return m
+		}
+	}
+	return m
+}
+
+func (a *analysis) field(t *Object, node *fieldNode) measure {
+	if node.name == "__typename" {
+		if len(node.args) > 0 || len(node.selections) > 0 {
+			a.errorf(node.loc, "Field \"__typename\" takes no arguments or selections.")
+		}
+		return measure{}
+	}
+	f := a.schema.field(t, node.name)
+	if f == nil {
+		a.errorf(node.loc, "Cannot query field %q on type %q.", node.name, t.Name)
+		return measure{}
+	}
+	for _, arg := range node.args {
+		if !slices.ContainsFunc(f.Args, func(d *InputValue) bool { return d.Name == arg.name }) {
+			a.errorf(node.loc, "Unknown argument %q on field %q.", arg.name, t.Name+"."+f.Name)
+		}
+	}
+	args, err := a.argValues(f.Args, node.args)
+	if err != nil {
+		a.errorf(node.loc, "Field %q: %s.", f.Name, err)
+		return measure{}
+	}
+
+	obj, isObject := namedType(f.Type).(*Object)
+	switch {
+	case isObject && len(node.selections) == 0:
+		a.errorf(node.loc, "Field %q of type %q must have a selection of subfields.", f.Name, f.Type)
+		return measure{}
+	case !isObject && len(node.selections) > 0:
+		a.errorf(node.loc, "Field %q must not have a selection since type %q has no subfields.", f.Name, f.Type)
+		return measure{}
+	}
+
+	var child measure
+	if isObject {
+		child = a.selections(obj, node.selections)
+	}
+	multiplier := 1
+	if f.Multiplier != nil {
+		multiplier = max(f.Multiplier(args), 1)
+	}
+	cost := math.MaxInt / 2
+	if child.cost < cost/multiplier {
+		cost = 1 + multiplier*child.cost
+	}
+	return measure{depth: child.depth + 1, cost: cost}
+}
+
+func (a *analysis) directives(dirs []*directive) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			a.errorf(d.loc, "Unknown directive \"@%s\".", d.name)
+			continue
+		}
+		if _, err := a.argValues(ifArgs, d.args); err != nil {
+			a.errorf(d.loc, "Directive \"@%s\": %s.", d.name, err)
+		}
+	}
+}
+
+var ifArgs = []*InputValue{{Name: "if", Type: &NonNull{Of: Boolean}}}
+
+// namedType strips lists and non-null wrappers
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.Of
+		case *NonNull:
+			t = w.Of
+		default:
+			return t
+		}
+	}
+}
+
+// ---------- Execution ----------
+
+// orderedMap keeps response fields in the order they were asked for
+type orderedMap struct {
+	keys   []string
+	values []interface{}
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (e *executor) addError(err error, loc Location, path []interface{}) {
+	e.errors = append(e.errors, &Error{Message: err.Error(), Locations: []Location{loc}, Path: path})
+}
+
+// included evaluates @skip and @include
+func (e *executor) included(dirs []*directive) bool {
+	for _, d := range dirs {
+		args, err := e.argValues(ifArgs, d.args)
+		if err != nil {
+			continue
+		}
+		cond, _ := args["if"].(bool)
+		if (d.name == "skip" && cond) || (d.name == "include" && !cond) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectFields groups the fields of a selection set by response key,
+// expanding each fragment once
+func (e *executor) collectFields(sels []selection, keys *[]string, fields map[string][]*fieldNode, visited map[string]bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *fieldNode:
+			if !e.included(sel.directives) {
+				continue
+			}
+			key := sel.key()
+			if _, ok := fields[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			fields[key] = append(fields[key], sel)
+		case *fragmentSpread:
+			if e.included(sel.directives) && !visited[sel.name] {
+				visited[sel.name] = true
+				e.collectFields(e.doc.fragments[sel.name].selections, keys, fields, visited)
+			}
+		case *inlineFragment:
+			if e.included(sel.directives) {
+				e.collectFields(sel.selections, keys, fields, visited)
+			}
+		}
+	}
+}
+
+// executeFields resolves a selection set on a source value. ok is false when
+// a non-null field failed and the whole object has to become null.
+func (e *executor) executeFields(ctx context.Context, t *Object, source interface{}, sels []selection, path []interface{}) (*orderedMap, bool) {
+	var keys []string
+	fields := map[string][]*fieldNode{}
+	e.collectFields(sels, &keys, fields, map[string]bool{})
+
+	out := &orderedMap{}
+	for _, key := range keys {
+		nodes := fields[key]
+		node := nodes[0]
+		fieldPath := append(slices.Clip(path), key)
+		if node.name == "__typename" {
+			out.keys = append(out.keys, key)
+			out.values = append(out.values, t.Name)
+			continue
+		}
+
+		f := e.schema.field(t, node.name)
+		_, required := f.Type.(*NonNull)
+		v, err := e.resolve(ctx, f, source, node)
+		if err != nil {
+			e.addError(err, node.loc, fieldPath)
+			if required {
+				return nil, false
+			}
+			v = nil
+		} else {
+			var ok bool
+			if v, ok = e.complete(ctx, f.Type, nodes, v, fieldPath); !ok {
+				return nil, false
+			}
+		}
+		out.keys = append(out.keys, key)
+		out.values = append(out.values, v)
+	}
+	return out, true
+}
+
+func (e *executor) resolve(ctx context.Context, f *Field, source interface{}, node *fieldNode) (v interface{}, err error) {
+	args, err := e.argValues(f.Args, node.args)
+	if err != nil {
+		return nil, err
+	}
+	if f.Resolve == nil {
+		if m, ok := source.(map[string]interface{}); ok {
+			return m[f.Name], nil
+		}
+		return nil, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error resolving %q", f.Name)
+		}
+	}()
+	return f.Resolve(ResolveParams{Context: ctx, Source: source, Args: args})
+}
+
+// complete turns a resolved value into its response form. ok is false when a
+// null has to propagate to the nearest nullable parent.
+func (e *executor) complete(ctx context.Context, t Type, nodes []*fieldNode, v interface{}, path []interface{}) (interface{}, bool) {
+	if nn, ok := t.(*NonNull); ok {
+		r, ok := e.completeValue(ctx, nn.Of, nodes, v, path)
+		if ok && r == nil {
+			e.addError(fmt.Errorf("Cannot return null for non-nullable field"), nodes[0].loc, path)
+		}
+		return r, ok && r != nil
+	}
+	r, ok := e.completeValue(ctx, t, nodes, v, path)
+	if !ok {
+		return nil, true
+	}
+	return r, true
+}
+
+func (e *executor) completeValue(ctx context.Context, t Type, nodes []*fieldNode, v interface{}, path []interface{}) (interface{}, bool) {
+	if isNil(v) {
+		return nil, true
+	}
+	switch t := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.addError(fmt.Errorf("Expected a list for %s", t), nodes[0].loc, path)
+			return nil, false
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			r, ok := e.complete(ctx, t.Of, nodes, rv.Index(i).Interface(), append(slices.Clip(path), i))
+			if !ok {
+				return nil, false
+			}
+			out[i] = r
+		}
+		return out, true
+	case *Object:
+		var sels []selection
+		for _, n := range nodes {
+			sels = append(sels, n.selections...)
+		}
+		m, ok := e.executeFields(ctx, t, v, sels, path)
+		if !ok {
+			return nil, false
+		}
+		return m, true
+	case *Enum:
+		s, ok := v.(string)
+		if !ok || !slices.Contains(t.Values, s) {
+			e.addError(fmt.Errorf("Enum %q cannot represent value %v", t.Name, v), nodes[0].loc, path)
+			return nil, false
+		}
+		return s, true
+	case *Scalar:
+		r, err := t.Serialize(v)
+		if err != nil {
+			e.addError(err, nodes[0].loc, path)
+			return nil, false
+		}
+		return r, true
+	}
+	return nil, false
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type post struct {
+	ID    string
+	Title string
+}
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	posts := []post{{"1", "Hello"}, {"2", "World"}}
+
+	postType := &Object{Name: "Post"}
+	postType.Fields = []*Field{
+		{Name: "id", Type: &NonNull{Of: ID}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(post).ID, nil
+		}},
+		{Name: "title", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(post).Title, nil
+		}},
+		{Name: "broken", Type: &NonNull{Of: String}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nil, errors.New("boom")
+		}},
+		{Name: "next", Type: postType, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source, nil
+		}},
+	}
+	query := &Object{Name: "Query", Fields: []*Field{
+		{Name: "post", Type: postType, Args: []*InputValue{{Name: "id", Type: &NonNull{Of: ID}}}, Resolve: func(p ResolveParams) (interface{}, error) {
+			for _, item := range posts {
+				if item.ID == p.Args["id"] {
+					return item, nil
+				}
+			}
+			return nil, nil
+		}},
+		{
+			Name: "posts",
+			Type: &NonNull{Of: &List{Of: &NonNull{Of: postType}}},
+			Args: []*InputValue{{Name: "limit", Type: Int, Default: int64(10)}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return posts[:min(int(p.Args["limit"].(int64)), len(posts))], nil
+			},
+			Multiplier: func(args map[string]interface{}) int { return int(args["limit"].(int64)) },
+		},
+	}}
+
+	s, err := NewSchema(query, nil, Options{MaxLength: 2000, MaxFragments: 40, MaxDepth: 4, MaxComplexity: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func run(s *Schema, query string, vars map[string]interface{}) string {
+	b, _ := json.Marshal(s.Execute(context.Background(), Request{Query: query, Variables: vars}))
+	return string(b)
+}
+
+func TestExecute(t *testing.T) {
+	s := testSchema(t)
+	cases := []struct{ query, want string }{
+		{`{ post(id: 2) { title id } }`, `{"data":{"post":{"title":"World","id":"2"}}}`},
+		{`query Q($id: ID!) { a: post(id: $id) { ...F } }  fragment F on Post { t: title, __typename }`,
+			`{"data":{"a":{"t":"Hello","__typename":"Post"}}}`},
+		{`{ posts(limit: 1) { id title @skip(if: true) } }`, `{"data":{"posts":[{"id":"1"}]}}`},
+		{`{ post(id: "9") { id } }`, `{"data":{"post":null}}`},
+		{`{ post(id: 1) { id broken } }`,
+			`{"errors":[{"message":"boom","locations":[{"line":1,"column":20}],"path":["post","broken"]}],"data":{"post":null}}`},
+	}
+	for _, c := range cases {
+		if got := run(s, c.query, map[string]interface{}{"id": "1"}); got != c.want {
+			t.Errorf("%s:\n got %s\nwant %s", c.query, got, c.want)
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	s := testSchema(t)
+	cases := map[string]string{
+		`{ post(id: 1) { nope } }`:                              "Cannot query field",
+		`{ post { id } }`:                                       "was not provided",
+		`{ post(id: 1, x: 2) { id } }`:                          "Unknown argument",
+		`{ post(id: 1) }`:                                       "must have a selection",
+		`{ posts { id { x } } }`:                                "must not have a selection",
+		`{ ...F } fragment F on Query { ...F }`:                 "within itself",
+		`{ post(id: 1) { next { next { next { id } } } } }`:     "nested 5 levels deep",
+		`{ posts(limit: 30) { id next { id } } }`:               "complexity of at least 91",
+		`{ posts(limit: "x") { id } }`:                          "Int cannot represent",
+		`mutation { x }`:                                        "does not support mutation",
+		`{ post(id: 1) { id `:                                   "Syntax Error",
+		`{ posts { id ` + strings.Repeat("title ", 400) + `} }`: "bytes long",
+		`{ ...F0 } ` + fragmentChain(41, "__typename"):          "defines 41 fragments",
+		`{ ...F0 } ` + fragmentChain(30, "posts { id }"):        "complexity of",
+	}
+	for query, want := range cases {
+		if got := run(s, query, nil); !strings.Contains(got, want) || strings.Contains(got, `"data"`) {
+			t.Errorf("%s: got %s, want an error containing %q", query, got, want)
+		}
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	s := testSchema(t)
+	got := run(s, `{ __type(name: "Post") { kind fields { name type { kind name } } } }`, nil)
+	if !strings.Contains(got, `{"name":"id","type":{"kind":"NON_NULL","name":null}}`) {
+		t.Errorf("unexpected __type result: %s", got)
+	}
+
+	// introspection counts against the limits
+	got = run(s, `{ __schema { types { name fields { type { ofType { ofType { ofType { name } } } } } } } }`, nil)
+	if !strings.Contains(got, "nested 8 levels deep") || strings.Contains(got, `"data"`) {
+		t.Errorf("deep __schema query was not rejected: %s", got)
+	}
+}
+
+// fragmentChain defines fragments F0 to F(n-1) on Query, each spreading the
+// next one twice, so walking the spreads naively would take 2^n steps
+func fragmentChain(n int, leaf string) string {
+	var b strings.Builder
+	for i := 0; i < n-1; i++ {
+		fmt.Fprintf(&b, "fragment F%d on Query { ...F%d ...F%d } ", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "fragment F%d on Query { %s }", n-1, leaf)
+	return b.String()
+}
+
+func TestFragmentChain(t *testing.T) {
+	s := testSchema(t)
+	got := run(s, `{ ...F0 } `+fragmentChain(40, "__typename"), nil)
+	if got != `{"data":{"__typename":"Query"}}` {
+		t.Errorf("unexpected result: %s", got)
+	}
+}
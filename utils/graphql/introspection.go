@@ -0,0 +1,224 @@
+package graphql
+
+// Introspection types, as the spec defines them. Resolvers read the engine's
+// own type values; there are no interfaces, unions or deprecations to report.
+
+var (
+	introspectionSchema     = &Object{Name: "__Schema"}
+	introspectionType       = &Object{Name: "__Type"}
+	introspectionField      = &Object{Name: "__Field"}
+	introspectionInputValue = &Object{Name: "__InputValue"}
+	introspectionEnumValue  = &Object{Name: "__EnumValue"}
+	introspectionDirective  = &Object{Name: "__Directive"}
+
+	typeKind = &Enum{
+		Name:   "__TypeKind",
+		Values: []string{"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL"},
+	}
+	directiveLocation = &Enum{
+		Name: "__DirectiveLocation",
+		Values: []string{
+			"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT", "VARIABLE_DEFINITION",
+			"SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE", "UNION", "ENUM", "ENUM_VALUE",
+			"INPUT_OBJECT", "INPUT_FIELD_DEFINITION",
+		},
+	}
+)
+
+type directiveDef struct {
+	name        string
+	description string
+}
+
+var directives = []*directiveDef{
+	{"include", "Directs the executor to include this field or fragment only when the `if` argument is true."},
+	{"skip", "Directs the executor to skip this field or fragment when the `if` argument is true."},
+}
+
+type enumValueDef string
+
+func init() {
+	nonNullString := &NonNull{Of: String}
+	nonNullBoolean := &NonNull{Of: Boolean}
+	nonNullType := &NonNull{Of: introspectionType}
+	includeDeprecated := []*InputValue{{Name: "includeDeprecated", Type: Boolean, Default: false}}
+	notDeprecated := []*Field{
+		{Name: "isDeprecated", Type: nonNullBoolean, Resolve: constant(false)},
+		{Name: "deprecationReason", Type: String, Resolve: constant(nil)},
+	}
+
+	introspectionSchema.Fields = []*Field{
+		{Name: "description", Type: String, Resolve: constant(nil)},
+		{Name: "types", Type: &NonNull{Of: &List{Of: nonNullType}}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*Schema).sortedTypes(), nil
+		}},
+		{Name: "queryType", Type: nonNullType, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*Schema).Query, nil
+		}},
+		{Name: "mutationType", Type: introspectionType, Resolve: func(p ResolveParams) (interface{}, error) {
+			if m := p.Source.(*Schema).Mutation; m != nil {
+				return m, nil
+			}
+			return nil, nil
+		}},
+		{Name: "subscriptionType", Type: introspectionType, Resolve: constant(nil)},
+		{Name: "directives", Type: &NonNull{Of: &List{Of: &NonNull{Of: introspectionDirective}}}, Resolve: constant(directives)},
+	}
+
+	introspectionType.Fields = []*Field{
+		{Name: "kind", Type: &NonNull{Of: typeKind}, Resolve: func(p ResolveParams) (interface{}, error) {
+			switch p.Source.(type) {
+			case *Scalar:
+				return "SCALAR", nil
+			case *Object:
+				return "OBJECT", nil
+			case *Enum:
+				return "ENUM", nil
+			case *InputObject:
+				return "INPUT_OBJECT", nil
+			case *List:
+				return "LIST", nil
+			}
+			return "NON_NULL", nil
+		}},
+		{Name: "name", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			if t, ok := p.Source.(Named); ok {
+				return t.TypeName(), nil
+			}
+			return nil, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			var d string
+			switch t := p.Source.(type) {
+			case *Scalar:
+				d = t.Description
+			case *Object:
+				d = t.Description
+			case *Enum:
+				d = t.Description
+			case *InputObject:
+				d = t.Description
+			}
+			return optional(d), nil
+		}},
+		{Name: "specifiedByURL", Type: String, Resolve: constant(nil)},
+		{Name: "fields", Type: &List{Of: &NonNull{Of: introspectionField}}, Args: includeDeprecated, Resolve: func(p ResolveParams) (interface{}, error) {
+			if t, ok := p.Source.(*Object); ok {
+				return t.Fields, nil
+			}
+			return nil, nil
+		}},
+		{Name: "interfaces", Type: &List{Of: nonNullType}, Resolve: func(p ResolveParams) (interface{}, error) {
+			if _, ok := p.Source.(*Object); ok {
+				return []interface{}{}, nil
+			}
+			return nil, nil
+		}},
+		{Name: "possibleTypes", Type: &List{Of: nonNullType}, Resolve: constant(nil)},
+		{Name: "enumValues", Type: &List{Of: &NonNull{Of: introspectionEnumValue}}, Args: includeDeprecated, Resolve: func(p ResolveParams) (interface{}, error) {
+			t, ok := p.Source.(*Enum)
+			if !ok {
+				return nil, nil
+			}
+			values := make([]enumValueDef, len(t.Values))
+			for i, v := range t.Values {
+				values[i] = enumValueDef(v)
+			}
+			return values, nil
+		}},
+		{Name: "inputFields", Type: &List{Of: &NonNull{Of: introspectionInputValue}}, Args: includeDeprecated, Resolve: func(p ResolveParams) (interface{}, error) {
+			if t, ok := p.Source.(*InputObject); ok {
+				return t.Fields, nil
+			}
+			return nil, nil
+		}},
+		{Name: "ofType", Type: introspectionType, Resolve: func(p ResolveParams) (interface{}, error) {
+			switch t := p.Source.(type) {
+			case *List:
+				return t.Of, nil
+			case *NonNull:
+				return t.Of, nil
+			}
+			return nil, nil
+		}},
+		{Name: "isOneOf", Type: Boolean, Resolve: func(p ResolveParams) (interface{}, error) {
+			if _, ok := p.Source.(*InputObject); ok {
+				return false, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	introspectionField.Fields = append([]*Field{
+		{Name: "name", Type: nonNullString, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*Field).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*Field).Description), nil
+		}},
+		{Name: "args", Type: &NonNull{Of: &List{Of: &NonNull{Of: introspectionInputValue}}}, Args: includeDeprecated, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nonNilArgs(p.Source.(*Field).Args), nil
+		}},
+		{Name: "type", Type: nonNullType, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*Field).Type, nil
+		}},
+	}, notDeprecated...)
+
+	introspectionInputValue.Fields = append([]*Field{
+		{Name: "name", Type: nonNullString, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*InputValue).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return optional(p.Source.(*InputValue).Description), nil
+		}},
+		{Name: "type", Type: nonNullType, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*InputValue).Type, nil
+		}},
+		{Name: "defaultValue", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			if d := p.Source.(*InputValue).Default; d != nil {
+				return printLiteral(d), nil
+			}
+			return nil, nil
+		}},
+	}, notDeprecated...)
+
+	introspectionEnumValue.Fields = append([]*Field{
+		{Name: "name", Type: nonNullString, Resolve: func(p ResolveParams) (interface{}, error) {
+			return string(p.Source.(enumValueDef)), nil
+		}},
+		{Name: "description", Type: String, Resolve: constant(nil)},
+	}, notDeprecated...)
+
+	introspectionDirective.Fields = []*Field{
+		{Name: "name", Type: nonNullString, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDef).name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDef).description, nil
+		}},
+		{Name: "locations", Type: &NonNull{Of: &List{Of: &NonNull{Of: directiveLocation}}}, Resolve: constant([]string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"})},
+		{Name: "args", Type: &NonNull{Of: &List{Of: &NonNull{Of: introspectionInputValue}}}, Args: includeDeprecated, Resolve: constant([]*InputValue{
+			{Name: "if", Type: nonNullBoolean},
+		})},
+		{Name: "isRepeatable", Type: nonNullBoolean, Resolve: constant(false)},
+	}
+}
+
+func constant(v interface{}) func(ResolveParams) (interface{}, error) {
+	return func(ResolveParams) (interface{}, error) { return v, nil }
+}
+
+// optional turns an empty description into null
+func optional(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nonNilArgs(args []*InputValue) []*InputValue {
+	if args == nil {
+		return []*InputValue{}
+	}
+	return args
+}
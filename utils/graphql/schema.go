@@ -0,0 +1,262 @@
+// Package graphql is a small GraphQL executor for schemas built at runtime.
+// It supports queries and mutations with variables, aliases, fragments and
+// the @skip and @include directives, introspection, and depth and complexity
+// limits. Types are objects, scalars, enums, input objects, lists and
+// non-null wrappers; there are no interfaces, unions or subscriptions.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// Type is a *Scalar, *Enum, *Object, *InputObject, *List or *NonNull
+type Type interface {
+	String() string
+}
+
+// Named types have a name of their own
+type Named interface {
+	Type
+	TypeName() string
+}
+
+type Scalar struct {
+	Name        string
+	Description string
+	// Serialize turns a resolved value into its JSON form
+	Serialize func(v interface{}) (interface{}, error)
+	// Parse checks an input value, decoded from JSON or a literal
+	Parse func(v interface{}) (interface{}, error)
+}
+
+type Enum struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*InputValue
+}
+
+type List struct{ Of Type }
+
+type NonNull struct{ Of Type }
+
+func (t *Scalar) String() string      { return t.Name }
+func (t *Enum) String() string        { return t.Name }
+func (t *Object) String() string      { return t.Name }
+func (t *InputObject) String() string { return t.Name }
+func (t *List) String() string        { return "[" + t.Of.String() + "]" }
+func (t *NonNull) String() string     { return t.Of.String() + "!" }
+
+func (t *Scalar) TypeName() string      { return t.Name }
+func (t *Enum) TypeName() string        { return t.Name }
+func (t *Object) TypeName() string      { return t.Name }
+func (t *InputObject) TypeName() string { return t.Name }
+
+// Field returns the object's field by name, nil if there is none
+func (t *Object) Field(name string) *Field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*InputValue
+	// Resolve computes the value, nil reads Name from a map[string]interface{} source
+	Resolve func(p ResolveParams) (interface{}, error)
+	// Multiplier scales the complexity of the field's selection, e.g. by a
+	// list's page size. Nil counts it once.
+	Multiplier func(args map[string]interface{}) int
+}
+
+type InputValue struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{} // nil for none
+}
+
+type ResolveParams struct {
+	Context context.Context
+	Source  interface{}
+	Args    map[string]interface{}
+}
+
+// Location points into the query document, counting from 1
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a GraphQL error as it appears in a response
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+var nameRe = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// ValidName reports whether s can name a type, field or argument
+func ValidName(s string) bool {
+	return nameRe.MatchString(s)
+}
+
+// ---------- Built-in scalars ----------
+
+var (
+	String = &Scalar{
+		Name:        "String",
+		Description: "UTF-8 text.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch s := v.(type) {
+			case string:
+				return s, nil
+			case fmt.Stringer:
+				return s.String(), nil
+			case bool, float64, int, int32, int64:
+				return fmt.Sprint(s), nil
+			}
+			return nil, fmt.Errorf("String cannot represent %T", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent a non string value")
+		},
+	}
+
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "A unique identifier.",
+		Serialize:   String.Serialize,
+		Parse: func(v interface{}) (interface{}, error) {
+			switch s := v.(type) {
+			case string:
+				return s, nil
+			case int64:
+				return strconv.FormatInt(s, 10), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent a non string value")
+		},
+	}
+
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "A signed 32-bit integer.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			return toInt(v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if _, ok := v.(string); ok {
+				return nil, fmt.Errorf("Int cannot represent a non integer value")
+			}
+			return toInt(v)
+		},
+	}
+
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "A double-precision floating point number.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			return toFloat(v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			return toFloat(v)
+		},
+	}
+
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "true or false.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %T", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value")
+		},
+	}
+
+	// JSON passes any JSON value through, object literals included
+	JSON = &Scalar{
+		Name:        "JSON",
+		Description: "Any JSON value.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			return v, nil
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			return v, nil
+		},
+	}
+)
+
+func toInt(v interface{}) (interface{}, error) {
+	var f float64
+	switch n := v.(type) {
+	case int:
+		f = float64(n)
+	case int32:
+		return int64(n), nil
+	case int64:
+		f = float64(n)
+	case float64:
+		f = n
+	case json.Number:
+		var err error
+		if f, err = n.Float64(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Int cannot represent %T", v)
+	}
+	if f != math.Trunc(f) || f > math.MaxInt32 || f < math.MinInt32 {
+		return nil, fmt.Errorf("Int cannot represent %v", v)
+	}
+	return int64(f), nil
+}
+
+func toFloat(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	}
+	return nil, fmt.Errorf("Float cannot represent %T", v)
+}
@@ -0,0 +1,83 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	target := decode(t, `{"data":{"title":"Old","tags":["a"],"meta":{"x":1,"y":2}},"published":false}`)
+	patch := decode(t, `{"data":{"title":"New","tags":null,"meta":{"y":3}},"published":true}`)
+
+	got := MergePatch(target, patch)
+	want := decode(t, `{"data":{"title":"New","meta":{"x":1,"y":3}},"published":true}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if target.(map[string]interface{})["published"] != false {
+		t.Error("target was modified")
+	}
+}
+
+func TestApply(t *testing.T) {
+	doc := decode(t, `{"data":{"title":"Old","tags":["a","b"],"a/b":1},"published":false}`)
+	ops, err := Decode([]byte(`[
+		{"op":"test","path":"/data/title","value":"Old"},
+		{"op":"replace","path":"/data/title","value":"New"},
+		{"op":"add","path":"/data/tags/-","value":"c"},
+		{"op":"add","path":"/data/tags/0","value":"z"},
+		{"op":"remove","path":"/data/tags/1"},
+		{"op":"copy","from":"/data/title","path":"/data/subtitle"},
+		{"op":"move","from":"/data/a~1b","path":"/data/views"},
+		{"op":"replace","path":"/published","value":true}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Apply(doc, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decode(t, `{"data":{"title":"New","subtitle":"New","tags":["z","b","c"],"views":1},"published":true}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := decode(t, `{"data":{"title":"Old","tags":["a"]}}`)
+	cases := map[string]string{
+		"failed test":     `[{"op":"test","path":"/data/title","value":"Other"}]`,
+		"missing path":    `[{"op":"replace","path":"/data/nope","value":1}]`,
+		"missing parent":  `[{"op":"add","path":"/data/a/b","value":1}]`,
+		"index range":     `[{"op":"remove","path":"/data/tags/5"}]`,
+		"unknown op":      `[{"op":"merge","path":"/data"}]`,
+		"move into child": `[{"op":"move","from":"/data","path":"/data/inner"}]`,
+		"bad pointer":     `[{"op":"remove","path":"data"}]`,
+	}
+	for name, raw := range cases {
+		ops, err := Decode([]byte(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Apply(doc, ops)
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if errors.Is(err, ErrTestFailed) != (name == "failed test") {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+// Package listquery turns the filter, sort and pagination query params of
+// content list endpoints into parameterized SQL over the contents.data JSONB.
+//
+//	filter={"status":"live","views":{"gt":10},"or":[{"tags":{"contains":"go"}},{"featured":{"eq":true}}]}
+//	sort=-createdAt,title
+//	limit=20&offset=40   or   limit=20&cursor=<nextCursor>
+//
+// Operators: eq, ne, lt, lte, gt, gte, in, contains, exists, and under for
+// taxonomy fields, which matches a term or any term below it. Comparisons use
+// the field's schema type, so numbers compare numerically and dates as timestamps.
+package listquery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/manthan307/nota-cms/utils"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+
+	maxDepth      = 5
+	maxConditions = 50
+)
+
+// System columns that can be filtered and sorted on next to schema fields
+var systemColumns = map[string]string{
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+type Params struct {
+	Filter string
+	Sort   string
+	Cursor string
+	Limit  int
+	Offset int
+}
+
+type Query struct {
+	Limit  int
+	Offset int
+
+	filter *cond
+	sort   []sortKey
+	sortID string
+	after  *Cursor
+}
+
+type sortKey struct {
+	target target
+	desc   bool
+}
+
+// target is something a condition or sort key points at
+type target struct {
+	name     string
+	column   string // system column, empty for data fields
+	elem     string // schema type
+	array    bool
+	taxonomy string // taxonomy of "taxonomy" fields
+}
+
+type cond struct {
+	op       string // and, or, or a comparison operator
+	children []*cond
+	target   target
+	value    interface{}
+}
+
+// Cursor marks the last row of a page for keyset pagination
+type Cursor struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// Parse validates the params against the schema fields
+func Parse(p Params, fields []utils.Field) (*Query, error) {
+	byName := make(map[string]utils.Field, len(fields))
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+	resolve := func(name string) (target, error) {
+		if col, ok := systemColumns[name]; ok {
+			return target{name: name, column: col, elem: "date"}, nil
+		}
+		f, ok := byName[name]
+		if !ok {
+			return target{}, fmt.Errorf("unknown field %q", name)
+		}
+		elem, array := f.ElemType()
+		return target{name: name, elem: elem, array: array, taxonomy: f.Taxonomy}, nil
+	}
+
+	q := &Query{Limit: p.Limit, Offset: p.Offset}
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		return nil, fmt.Errorf("limit must be at most %d", MaxLimit)
+	}
+	if q.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+
+	// Sort, newest first by default
+	if p.Sort == "" {
+		p.Sort = "-createdAt"
+	}
+	q.sortID = p.Sort
+	for _, part := range strings.Split(p.Sort, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		t, err := resolve(strings.TrimLeft(part, "-+"))
+		if err != nil {
+			return nil, fmt.Errorf("sort: %w", err)
+		}
+		if t.array || t.elem == "json" {
+			return nil, fmt.Errorf("sort: field %q cannot be sorted", t.name)
+		}
+		q.sort = append(q.sort, sortKey{target: t, desc: desc})
+	}
+
+	if p.Filter != "" {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(p.Filter), &raw); err != nil {
+			return nil, fmt.Errorf("filter must be a JSON object: %w", err)
+		}
+		count := 0
+		f, err := parseGroup("and", raw, resolve, 0, &count)
+		if err != nil {
+			return nil, fmt.Errorf("filter: %w", err)
+		}
+		q.filter = f
+	}
+
+	if p.Cursor != "" {
+		c, err := DecodeCursor(p.Cursor)
+		if err != nil || c.Sort != q.sortID || len(c.Values) != len(q.sort) {
+			return nil, fmt.Errorf("invalid cursor for this sort")
+		}
+		q.after = c
+		q.Offset = 0
+	}
+
+	return q, nil
+}
+
+func parseGroup(op string, raw map[string]interface{}, resolve func(string) (target, error), depth int, count *int) (*cond, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("nested deeper than %d levels", maxDepth)
+	}
+	group := &cond{op: op}
+
+	for _, key := range slices.Sorted(maps.Keys(raw)) {
+		val := raw[key]
+		switch key {
+		case "and", "or":
+			list, ok := val.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%q takes an array of filters", key)
+			}
+			sub := &cond{op: key}
+			for _, item := range list {
+				m, ok := item.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%q items must be objects", key)
+				}
+				child, err := parseGroup("and", m, resolve, depth+1, count)
+				if err != nil {
+					return nil, err
+				}
+				sub.children = append(sub.children, child)
+			}
+			group.children = append(group.children, sub)
+		default:
+			t, err := resolve(key)
+			if err != nil {
+				return nil, err
+			}
+			ops, ok := val.(map[string]interface{})
+			if !ok {
+				ops = map[string]interface{}{"eq": val}
+			}
+			for _, op := range slices.Sorted(maps.Keys(ops)) {
+				v := ops[op]
+				*count++
+				if *count > maxConditions {
+					return nil, fmt.Errorf("more than %d conditions", maxConditions)
+				}
+				c, err := comparison(t, op, v)
+				if err != nil {
+					return nil, err
+				}
+				group.children = append(group.children, c)
+			}
+		}
+	}
+
+	return group, nil
+}
+
+// comparison checks that op and value fit the field type
+func comparison(t target, op string, v interface{}) (*cond, error) {
+	c := &cond{op: op, target: t}
+
+	switch op {
+	case "exists":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: exists takes true or false", t.name)
+		}
+		c.value = b
+		return c, nil
+	case "in":
+		list, ok := v.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("%s: in takes a non-empty array", t.name)
+		}
+		vals := make([]interface{}, len(list))
+		for i, item := range list {
+			s, err := scalar(t, item)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = s
+		}
+		c.value = vals
+		return c, nil
+	case "contains":
+		if !t.array && !isText(t.elem) {
+			return nil, fmt.Errorf("%s: contains works on text and array fields", t.name)
+		}
+		s, err := scalar(t, v)
+		if err != nil {
+			return nil, err
+		}
+		c.value = s
+		return c, nil
+	case "under":
+		if t.elem != "taxonomy" {
+			return nil, fmt.Errorf("%s: under works on taxonomy fields", t.name)
+		}
+		list, ok := v.([]interface{})
+		if !ok {
+			list = []interface{}{v}
+		}
+		slugs := make([]string, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: under takes a term slug or an array of them", t.name)
+			}
+			slugs[i] = s
+		}
+		if len(slugs) == 0 {
+			return nil, fmt.Errorf("%s: under takes a term slug or an array of them", t.name)
+		}
+		c.value = slugs
+		return c, nil
+	case "eq", "ne", "lt", "lte", "gt", "gte":
+		if t.array || t.elem == "json" {
+			return nil, fmt.Errorf("%s: %s is not supported on %s fields", t.name, op, t.elem)
+		}
+		if t.elem == "boolean" && op != "eq" && op != "ne" {
+			return nil, fmt.Errorf("%s: booleans only support eq and ne", t.name)
+		}
+		s, err := scalar(t, v)
+		if err != nil {
+			return nil, err
+		}
+		c.value = s
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("%s: unknown operator %q", t.name, op)
+}
+
+// scalar converts a filter value to the SQL text form of the field type
+func scalar(t target, v interface{}) (interface{}, error) {
+	switch t.elem {
+	case "number":
+		switch n := v.(type) {
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		case string:
+			if _, err := strconv.ParseFloat(n, 64); err == nil {
+				return n, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: expected a number", t.name)
+	case "boolean":
+		if b, ok := v.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+		return nil, fmt.Errorf("%s: expected true or false", t.name)
+	case "date":
+		if s, ok := v.(string); ok {
+			if d, ok := utils.ParseDate(s); ok {
+				return d.Format("2006-01-02T15:04:05.999999999Z07:00"), nil
+			}
+		}
+		return nil, fmt.Errorf("%s: expected a date", t.name)
+	case "json":
+		return nil, fmt.Errorf("%s: json fields only support exists", t.name)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%s: expected a string", t.name)
+}
+
+func isText(elem string) bool {
+	switch elem {
+	case "number", "boolean", "date", "json":
+		return false
+	}
+	return true
+}
+
+// ---------- SQL ----------
+
+// Args collects positional query arguments
+type Args struct {
+	Values []interface{}
+}
+
+// Add appends a value and returns its placeholder
+func (a *Args) Add(v interface{}) string {
+	a.Values = append(a.Values, v)
+	return "$" + strconv.Itoa(len(a.Values))
+}
+
+// sqlType is the Postgres type used to compare a field
+func sqlType(elem string) string {
+	switch elem {
+	case "number":
+		return "numeric"
+	case "date":
+		return "timestamptz"
+	case "boolean":
+		return "boolean"
+	}
+	return "text"
+}
+
+// expr renders the typed SQL expression of a scalar target
+func (t target) expr(args *Args) string {
+	if t.column != "" {
+		return t.column
+	}
+	e := "(data->>" + args.Add(t.name) + "::text)"
+	if typ := sqlType(t.elem); typ != "text" {
+		e += "::" + typ
+	}
+	return e
+}
+
+func (t target) castParam(args *Args, v interface{}) string {
+	return args.Add(v) + "::text::" + sqlType(t.elem)
+}
+
+var compareOps = map[string]string{
+	"eq":  "=",
+	"ne":  "IS DISTINCT FROM",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+func (c *cond) sql(args *Args) string {
+	switch c.op {
+	case "and", "or":
+		if len(c.children) == 0 {
+			return "TRUE"
+		}
+		parts := make([]string, len(c.children))
+		for i, child := range c.children {
+			parts[i] = child.sql(args)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(c.op)+" ") + ")"
+
+	case "exists":
+		var e string
+		if c.target.column != "" {
+			e = c.target.column + " IS NOT NULL"
+		} else {
+			e = "COALESCE(data->" + args.Add(c.target.name) + "::text, 'null'::jsonb) <> 'null'::jsonb"
+		}
+		if !c.value.(bool) {
+			e = "NOT (" + e + ")"
+		}
+		return e
+
+	case "in":
+		vals := c.value.([]interface{})
+		if c.target.array {
+			key := args.Add(c.target.name) + "::text"
+			parts := make([]string, len(vals))
+			for i, v := range vals {
+				parts[i] = "data->" + key + " @> " + args.Add(c.target.jsonArray(v)) + "::jsonb"
+			}
+			return "(" + strings.Join(parts, " OR ") + ")"
+		}
+		strs := make([]string, len(vals))
+		for i, v := range vals {
+			strs[i] = v.(string)
+		}
+		return c.target.expr(args) + " = ANY(" + args.Add(strs) + "::text[]::" + sqlType(c.target.elem) + "[])"
+
+	case "contains":
+		if c.target.array {
+			return "data->" + args.Add(c.target.name) + "::text @> " + args.Add(c.target.jsonArray(c.value)) + "::jsonb"
+		}
+		return c.target.expr(args) + " ILIKE '%' || " + args.Add(escapeLike(c.value.(string))) + " || '%'"
+
+	case "under":
+		var e string
+		if c.target.array {
+			e = "data->" + args.Add(c.target.name) + "::text ?| ARRAY("
+		} else {
+			e = c.target.expr(args) + " IN ("
+		}
+		return e + "SELECT taxonomy_subtree(" + args.Add(c.target.taxonomy) + "::text, " + args.Add(c.value) + "::text[]))"
+	}
+
+	return c.target.expr(args) + " " + compareOps[c.op] + " " + c.target.castParam(args, c.value)
+}
+
+// jsonArray wraps a scalar for jsonb containment, restoring numbers and booleans
+func (t target) jsonArray(v interface{}) string {
+	s := v.(string)
+	var typed interface{} = s
+	switch t.elem {
+	case "number":
+		typed, _ = strconv.ParseFloat(s, 64)
+	case "boolean":
+		typed, _ = strconv.ParseBool(s)
+	}
+	out, _ := json.Marshal([]interface{}{typed})
+	return string(out)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Where renders the filter condition, TRUE when there is none
+func (q *Query) Where(args *Args) string {
+	if q.filter == nil {
+		return "TRUE"
+	}
+	return q.filter.sql(args)
+}
+
+// After renders the keyset condition for cursor pagination, TRUE without a cursor.
+// Sort keys use NULLS LAST and the id breaks ties.
+func (q *Query) After(args *Args) string {
+	if q.after == nil {
+		return "TRUE"
+	}
+
+	var ors []string
+	var eqs []string
+	for i, k := range q.sort {
+		e := k.target.expr(args)
+		v := q.after.Values[i]
+
+		var beyond string
+		if v == nil {
+			beyond = "FALSE" // nothing sorts after NULL except more NULLs
+		} else {
+			op := ">"
+			if k.desc {
+				op = "<"
+			}
+			beyond = "(" + e + " " + op + " " + k.target.castParam(args, *v) + " OR " + e + " IS NULL)"
+		}
+		ors = append(ors, joinAnd(append(eqs, beyond)))
+
+		if v == nil {
+			eqs = append(eqs, e+" IS NULL")
+		} else {
+			eqs = append(eqs, e+" = "+k.target.castParam(args, *v))
+		}
+	}
+	ors = append(ors, joinAnd(append(eqs, "id > "+args.Add(q.after.ID))))
+
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func joinAnd(parts []string) string {
+	return "(" + strings.Join(parts, " AND ") + ")"
+}
+
+// OrderBy renders the ORDER BY list
+func (q *Query) OrderBy(args *Args) string {
+	parts := make([]string, 0, len(q.sort)+1)
+	for _, k := range q.sort {
+		dir := "ASC"
+		if k.desc {
+			dir = "DESC"
+		}
+		parts = append(parts, k.target.expr(args)+" "+dir+" NULLS LAST")
+	}
+	return strings.Join(append(parts, "id ASC"), ", ")
+}
+
+// SortValues renders the sort expressions as text, to build the next cursor from
+func (q *Query) SortValues(args *Args) string {
+	parts := make([]string, len(q.sort))
+	for i, k := range q.sort {
+		parts[i] = "(" + k.target.expr(args) + ")::text"
+	}
+	return "ARRAY[" + strings.Join(parts, ", ") + "]::text[]"
+}
+
+// NextCursor builds the cursor that continues after a row
+func (q *Query) NextCursor(values []*string, id uuid.UUID) string {
+	b, _ := json.Marshal(Cursor{Sort: q.sortID, Values: values, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,160 @@
+package listquery
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/manthan307/nota-cms/utils"
+)
+
+var fields = []utils.Field{
+	{Name: "title", Type: "string"},
+	{Name: "views", Type: "number"},
+	{Name: "featured", Type: "boolean"},
+	{Name: "tags", Type: []interface{}{"string"}},
+	{Name: "meta", Type: "json"},
+	{Name: "category", Type: "taxonomy", Taxonomy: "categories"},
+	{Name: "topics", Type: []interface{}{"taxonomy"}, Taxonomy: "topics"},
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]Params{
+		"unknown field":   {Filter: `{"nope":1}`},
+		"bad operator":    {Filter: `{"views":{"like":1}}`},
+		"type mismatch":   {Filter: `{"views":"ten"}`},
+		"bool ordering":   {Filter: `{"featured":{"gt":true}}`},
+		"json compare":    {Filter: `{"meta":{"eq":"x"}}`},
+		"not an object":   {Filter: `[1]`},
+		"under on text":   {Filter: `{"title":{"under":"x"}}`},
+		"under no terms":  {Filter: `{"category":{"under":[]}}`},
+		"sort array":      {Sort: "tags"},
+		"limit too large": {Limit: MaxLimit + 1},
+		"bad cursor":      {Cursor: "garbage"},
+	}
+	for name, p := range cases {
+		if _, err := Parse(p, fields); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	deep := `{"title":"x"}`
+	for i := 0; i <= maxDepth; i++ {
+		deep = `{"or":[` + deep + `]}`
+	}
+	if _, err := Parse(Params{Filter: deep}, fields); err == nil {
+		t.Error("expected depth error")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	q, err := Parse(Params{
+		Filter: `{"title":"Go","views":{"gte":10},"or":[{"tags":{"contains":"cms"}},{"featured":true}]}`,
+	}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := &Args{}
+	got := q.Where(args)
+	want := "(((data->$1::text @> $2::jsonb) OR ((data->>$3::text)::boolean = $4::text::boolean)) AND " +
+		"(data->>$5::text) = $6::text::text AND (data->>$7::text)::numeric >= $8::text::numeric)"
+	if got != want {
+		t.Errorf("where:\n got %s\nwant %s", got, want)
+	}
+	wantArgs := []interface{}{"tags", `["cms"]`, "featured", "true", "title", "Go", "views", "10"}
+	if len(args.Values) != len(wantArgs) {
+		t.Fatalf("args: got %v", args.Values)
+	}
+	for i, v := range wantArgs {
+		if args.Values[i] != v {
+			t.Errorf("arg %d: got %v, want %v", i+1, args.Values[i], v)
+		}
+	}
+}
+
+func TestWhereUnder(t *testing.T) {
+	q, err := Parse(Params{Filter: `{"category":{"under":"tech"},"topics":{"under":["go","rust"]}}`}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &Args{}
+	got := q.Where(args)
+	want := "((data->>$1::text) IN (SELECT taxonomy_subtree($2::text, $3::text[])) AND " +
+		"data->$4::text ?| ARRAY(SELECT taxonomy_subtree($5::text, $6::text[])))"
+	if got != want {
+		t.Errorf("where:\n got %s\nwant %s", got, want)
+	}
+	if args.Values[1] != "categories" || args.Values[4] != "topics" || len(args.Values[5].([]string)) != 2 {
+		t.Errorf("args: %v", args.Values)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	q, err := Parse(Params{Sort: "-views", Limit: 10}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.OrderBy(&Args{}); got != "(data->>$1::text)::numeric DESC NULLS LAST, id ASC" {
+		t.Errorf("order by: %s", got)
+	}
+
+	v := "42"
+	id := uuid.New()
+	cursor := q.NextCursor([]*string{&v}, id)
+
+	next, err := Parse(Params{Sort: "-views", Cursor: cursor}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &Args{}
+	after := next.After(args)
+	if !strings.Contains(after, "< $2::text::numeric") || args.Values[len(args.Values)-1] != id {
+		t.Errorf("after: %s %v", after, args.Values)
+	}
+
+	// A cursor only continues the sort it was made for
+	if _, err := Parse(Params{Sort: "title", Cursor: cursor}, fields); err == nil {
+		t.Error("expected cursor/sort mismatch error")
+	}
+}
+
+func TestProjection(t *testing.T) {
+	for _, param := range []string{"nope", "title.x", "tags.x", "title,,views", "meta."} {
+		p, err := ParseProjection(param)
+		if err == nil {
+			err = p.Check(fields)
+		}
+		if err == nil {
+			t.Errorf("%q: expected error", param)
+		}
+	}
+
+	p, err := ParseProjection("meta.seo.title, title, meta.seo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Check(fields); err != nil {
+		t.Fatal(err)
+	}
+
+	args := &Args{}
+	got := p.SQL("data", args)
+	want := "CASE WHEN data IS NOT NULL THEN ('{}'::jsonb || " +
+		"CASE WHEN jsonb_typeof(data #> $1::text[]) = 'object' THEN jsonb_build_object($4::text, ('{}'::jsonb || " +
+		"CASE WHEN data #> $2::text[] IS NOT NULL THEN jsonb_build_object($3::text, data #> $2::text[]) ELSE '{}'::jsonb END)) ELSE '{}'::jsonb END || " +
+		"CASE WHEN data #> $5::text[] IS NOT NULL THEN jsonb_build_object($6::text, data #> $5::text[]) ELSE '{}'::jsonb END) END"
+	if got != want {
+		t.Errorf("projection:\n got %s\nwant %s", got, want)
+	}
+	if len(args.Values) != 6 {
+		t.Errorf("args %v", args.Values)
+	}
+	if names := p.Fields(fields); len(names) != 2 || names[0].Name != "title" || names[1].Name != "meta" {
+		t.Errorf("fields %v", names)
+	}
+
+	if p, _ := ParseProjection(""); p != nil || p.SQL("data", &Args{}) != "data" {
+		t.Error("empty fields should keep the data")
+	}
+}
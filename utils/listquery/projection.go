@@ -0,0 +1,129 @@
+package listquery
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/manthan307/nota-cms/utils"
+)
+
+const maxFields = 50
+
+// Projection picks parts of the data JSONB, parsed from a fields param such
+// as "title,slug,seo.description". Paths below a field walk json objects.
+type Projection struct {
+	root *pathNode
+}
+
+type pathNode struct {
+	whole    bool // the value is kept as is
+	children map[string]*pathNode
+}
+
+// ParseProjection reads a comma separated list of dotted paths. An empty
+// param returns nil, which keeps the whole data.
+func ParseProjection(param string) (*Projection, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+
+	p := &Projection{root: &pathNode{}}
+	parts := strings.Split(param, ",")
+	if len(parts) > maxFields {
+		return nil, fmt.Errorf("fields: more than %d paths", maxFields)
+	}
+	for _, part := range parts {
+		path := strings.Split(strings.TrimSpace(part), ".")
+		if len(path) > maxDepth {
+			return nil, fmt.Errorf("fields: %q is nested deeper than %d levels", part, maxDepth)
+		}
+		node := p.root
+		for i, key := range path {
+			if key == "" {
+				return nil, fmt.Errorf("fields: invalid path %q", part)
+			}
+			if node.whole {
+				break
+			}
+			if node.children == nil {
+				node.children = map[string]*pathNode{}
+			}
+			child, ok := node.children[key]
+			if !ok {
+				child = &pathNode{}
+				node.children[key] = child
+			}
+			if i == len(path)-1 {
+				// A whole field wins over paths below it
+				child.whole = true
+				child.children = nil
+			}
+			node = child
+		}
+	}
+	return p, nil
+}
+
+// Check validates the top-level names against the schema fields. Only json
+// fields have paths below them.
+func (p *Projection) Check(fields []utils.Field) error {
+	if p == nil {
+		return nil
+	}
+	byName := make(map[string]utils.Field, len(fields))
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+	for _, name := range slices.Sorted(maps.Keys(p.root.children)) {
+		f, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("fields: unknown field %q", name)
+		}
+		if elem, array := f.ElemType(); !p.root.children[name].whole && (array || elem != "json") {
+			return fmt.Errorf("fields: field %q has no nested fields", name)
+		}
+	}
+	return nil
+}
+
+// Fields narrows the schema fields to the ones the projection selects from
+func (p *Projection) Fields(fields []utils.Field) []utils.Field {
+	if p == nil {
+		return fields
+	}
+	var out []utils.Field
+	for _, f := range fields {
+		if _, ok := p.root.children[f.Name]; ok {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// SQL renders the projected form of a jsonb column. Missing keys stay
+// missing and a NULL column stays NULL.
+func (p *Projection) SQL(column string, args *Args) string {
+	if p == nil {
+		return column
+	}
+	return "CASE WHEN " + column + " IS NOT NULL THEN " + p.root.sql(column, nil, args) + " END"
+}
+
+func (n *pathNode) sql(column string, path []string, args *Args) string {
+	parts := []string{"'{}'::jsonb"}
+	for _, key := range slices.Sorted(maps.Keys(n.children)) {
+		child := n.children[key]
+		at := append(slices.Clip(path), key)
+		value := column + " #> " + args.Add(at) + "::text[]"
+
+		cond := value + " IS NOT NULL"
+		if !child.whole {
+			cond = "jsonb_typeof(" + value + ") = 'object'"
+			value = child.sql(column, at, args)
+		}
+		parts = append(parts, "CASE WHEN "+cond+" THEN jsonb_build_object("+args.Add(key)+"::text, "+value+") ELSE '{}'::jsonb END")
+	}
+	return "(" + strings.Join(parts, " || ") + ")"
+}
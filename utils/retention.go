@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Retention reads a retention period in days from the environment
+func Retention(env string, fallbackDays int) time.Duration {
+	days, err := strconv.Atoi(os.Getenv(env))
+	if err != nil || days < 0 {
+		days = fallbackDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/manthan307/nota-cms/utils/richtext"
+)
+
+// RichTextFormats are the forms rich text can be read in, a document tree by default
+var RichTextFormats = []string{"json", "html", "markdown"}
+
+func (f Field) richTextAllowed() richtext.Allowed {
+	return richtext.Allowed{Nodes: f.Nodes, Marks: f.Marks}
+}
+
+// checkRichText validates the nodes and marks a field allows
+func checkRichText(f Field, elem string) error {
+	if f.Nodes == nil && f.Marks == nil {
+		return nil
+	}
+	if elem != "richtext" {
+		return fmt.Errorf("field %q: only richtext fields have 'nodes' and 'marks'", f.Name)
+	}
+	for _, n := range f.Nodes {
+		if !slices.Contains(richtext.Nodes, n) {
+			return fmt.Errorf("field %q: unknown rich text node %q", f.Name, n)
+		}
+	}
+	for _, m := range f.Marks {
+		if !slices.Contains(richtext.Marks, m) {
+			return fmt.Errorf("field %q: unknown rich text mark %q", f.Name, m)
+		}
+	}
+	return nil
+}
+
+// NormalizeRichText replaces the rich text values of data, given as documents,
+// Markdown or HTML, with sanitized documents the fields allow
+func NormalizeRichText(fields []Field, data map[string]interface{}) error {
+	for _, f := range fields {
+		elem, isArray := f.ElemType()
+		value, ok := data[f.Name]
+		if elem != "richtext" || !ok || value == nil {
+			continue
+		}
+		if !isArray {
+			doc, err := richtext.Parse(value, f.richTextAllowed())
+			if err != nil {
+				return fmt.Errorf("field %q: %w", f.Name, err)
+			}
+			data[f.Name] = doc.Value()
+			continue
+		}
+		arr, ok := value.([]interface{})
+		if !ok {
+			continue // left for the type check to report
+		}
+		for i, item := range arr {
+			doc, err := richtext.Parse(item, f.richTextAllowed())
+			if err != nil {
+				return fmt.Errorf("field %q: element %d: %w", f.Name, i, err)
+			}
+			arr[i] = doc.Value()
+		}
+	}
+	return nil
+}
+
+// FormatRichText converts the rich text values of data for reading. Values
+// stored before rich text was structured are strings and read as Markdown
+// or HTML, until the entry is saved again.
+func FormatRichText(fields []Field, data map[string]interface{}, format string) {
+	for _, f := range fields {
+		if elem, _ := f.ElemType(); elem != "richtext" {
+			continue
+		}
+		if value, ok := data[f.Name]; ok {
+			data[f.Name] = FormatRichTextValue(f, value, format)
+		}
+	}
+}
+
+// FormatRichTextValue converts the value of a rich text field, a document or an array of them
+func FormatRichTextValue(f Field, value interface{}, format string) interface{} {
+	arr, ok := value.([]interface{})
+	if _, isArray := f.ElemType(); !isArray || !ok {
+		return formatRichText(f, value, format)
+	}
+	out := make([]interface{}, len(arr))
+	for i, item := range arr {
+		out[i] = formatRichText(f, item, format)
+	}
+	return out
+}
+
+func formatRichText(f Field, value interface{}, format string) interface{} {
+	if _, isDoc := value.(map[string]interface{}); value == nil || (isDoc && format == "json") {
+		return value
+	}
+	doc, err := richtext.Parse(value, f.richTextAllowed())
+	if err != nil {
+		return value
+	}
+	switch format {
+	case "html":
+		return richtext.RenderHTML(doc)
+	case "markdown":
+		return richtext.RenderMarkdown(doc)
+	}
+	return doc.Value()
+}
+
+// richTextPlain is the text of a stored rich text value, for search
+func richTextPlain(value interface{}) (string, bool) {
+	if _, isDoc := value.(map[string]interface{}); !isDoc {
+		return "", false
+	}
+	doc, err := richtext.Parse(value, richtext.Allowed{})
+	if err != nil {
+		return "", false
+	}
+	return richtext.PlainText(doc), true
+}
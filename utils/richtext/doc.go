@@ -0,0 +1,556 @@
+// Package richtext stores rich text as a document tree shaped like
+// ProseMirror's JSON, so editors such as TipTap load it as is:
+//
+//	{"type": "doc", "content": [
+//	  {"type": "paragraph", "content": [
+//	    {"type": "text", "text": "Hello "},
+//	    {"type": "text", "text": "world", "marks": [{"type": "bold"}]}
+//	  ]}
+//	]}
+//
+// Documents come in as JSON, Markdown or HTML and go out as any of them.
+// Only the nodes, marks and attributes below exist, and links and images
+// only keep safe URLs, so rendered HTML cannot carry scripts.
+package richtext
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type Node struct {
+	Type    string                 `json:"type"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*Node                `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []*Mark                `json:"marks,omitempty"`
+}
+
+type Mark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Nodes and Marks list the types a field can allow. Documents, paragraphs
+// and text are always allowed.
+var (
+	Nodes = []string{"heading", "blockquote", "bulletList", "orderedList", "listItem", "codeBlock", "horizontalRule", "image", "hardBreak"}
+	Marks = []string{"bold", "italic", "strike", "code", "link"}
+)
+
+// what each node holds: blocks, inline nodes, list items, plain text or nothing
+const (
+	holdsBlocks = iota
+	holdsInline
+	holdsItems
+	holdsText
+	holdsNothing
+)
+
+type nodeSpec struct {
+	block bool
+	holds int
+	attrs []string
+}
+
+var specs = map[string]nodeSpec{
+	"doc":            {holds: holdsBlocks},
+	"paragraph":      {block: true, holds: holdsInline},
+	"heading":        {block: true, holds: holdsInline, attrs: []string{"level"}},
+	"blockquote":     {block: true, holds: holdsBlocks},
+	"bulletList":     {block: true, holds: holdsItems},
+	"orderedList":    {block: true, holds: holdsItems, attrs: []string{"start"}},
+	"listItem":       {holds: holdsBlocks},
+	"codeBlock":      {block: true, holds: holdsText, attrs: []string{"language"}},
+	"horizontalRule": {block: true, holds: holdsNothing},
+	"image":          {block: true, holds: holdsNothing, attrs: []string{"src", "alt", "title"}},
+	"hardBreak":      {holds: holdsNothing},
+	"text":           {holds: holdsNothing},
+}
+
+var markAttrs = map[string][]string{
+	"bold":   nil,
+	"italic": nil,
+	"strike": nil,
+	"code":   nil,
+	"link":   {"href", "title", "contentId"},
+}
+
+var language = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{0,32}$`)
+
+// Allowed narrows the nodes and marks of a field, nil allows all of them
+type Allowed struct {
+	Nodes []string
+	Marks []string
+}
+
+func (a Allowed) node(t string) bool {
+	return a.Nodes == nil || t == "doc" || t == "paragraph" || t == "text" || slices.Contains(a.Nodes, t)
+}
+
+func (a Allowed) mark(t string) bool {
+	return a.Marks == nil || slices.Contains(a.Marks, t)
+}
+
+// Parse turns a stored or submitted value into a checked document. Strings
+// are Markdown, or HTML when they start with a tag; {"markdown": ...} and
+// {"html": ...} name the format. Markdown and HTML lose what the field does
+// not allow, documents must already fit.
+func Parse(value interface{}, allowed Allowed) (*Node, error) {
+	var doc *Node
+	switch v := value.(type) {
+	case string:
+		// a document exported to CSV and imported back
+		if trimmed := strings.TrimSpace(v); strings.HasPrefix(trimmed, "{") {
+			var m map[string]interface{}
+			if json.Unmarshal([]byte(trimmed), &m) == nil && m["type"] == "doc" {
+				return Parse(m, allowed)
+			}
+		}
+		if trimmed := strings.TrimLeft(v, " \t\r\n"); len(trimmed) > 1 && trimmed[0] == '<' && (isLetter(trimmed[1]) || trimmed[1] == '!' || trimmed[1] == '/') {
+			doc = ParseHTML(v)
+		} else {
+			doc = ParseMarkdown(v)
+		}
+	case map[string]interface{}:
+		if s, ok := v["markdown"].(string); ok && len(v) == 1 {
+			doc = ParseMarkdown(s)
+		} else if s, ok := v["html"].(string); ok && len(v) == 1 {
+			doc = ParseHTML(s)
+		} else {
+			b, _ := json.Marshal(v)
+			doc = &Node{}
+			if err := json.Unmarshal(b, doc); err != nil {
+				return nil, fmt.Errorf("invalid rich text document: %w", err)
+			}
+			if err := Check(doc, allowed); err != nil {
+				return nil, err
+			}
+			return doc, nil
+		}
+	default:
+		return nil, fmt.Errorf("expected a rich text document, Markdown or HTML, got %T", value)
+	}
+	fit(doc, allowed)
+	return doc, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Value is the document as decoded JSON, the form stored in content data
+func (n *Node) Value() map[string]interface{} {
+	b, _ := json.Marshal(n)
+	var v map[string]interface{}
+	_ = json.Unmarshal(b, &v)
+	return v
+}
+
+// Check reports the first part of a document that is unknown, unsafe,
+// misplaced or not allowed
+func Check(doc *Node, allowed Allowed) error {
+	if doc.Type != "doc" {
+		return fmt.Errorf("a rich text document must have type doc, got %q", doc.Type)
+	}
+	return check(doc, allowed, "content")
+}
+
+func check(n *Node, allowed Allowed, path string) error {
+	spec, ok := specs[n.Type]
+	if !ok {
+		return fmt.Errorf("%s: unknown node type %q", path, n.Type)
+	}
+	if !allowed.node(n.Type) {
+		return fmt.Errorf("%s: %s is not allowed in this field", path, n.Type)
+	}
+	if err := checkAttrs(n.Type, n.Attrs, spec.attrs); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if n.Type == "text" {
+		if n.Text == "" {
+			return fmt.Errorf("%s: text nodes must not be empty", path)
+		}
+		for _, m := range n.Marks {
+			attrs, ok := markAttrs[m.Type]
+			if !ok {
+				return fmt.Errorf("%s: unknown mark type %q", path, m.Type)
+			}
+			if !allowed.mark(m.Type) {
+				return fmt.Errorf("%s: %s is not allowed in this field", path, m.Type)
+			}
+			if err := checkAttrs(m.Type, m.Attrs, attrs); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	} else if n.Text != "" || len(n.Marks) > 0 {
+		return fmt.Errorf("%s: only text nodes have text and marks", path)
+	}
+
+	for i, child := range n.Content {
+		childPath := fmt.Sprintf("%s[%d]", path, i)
+		if child == nil {
+			return fmt.Errorf("%s: missing node", childPath)
+		}
+		if !fits(spec.holds, child.Type) {
+			return fmt.Errorf("%s: %s cannot hold %s", childPath, n.Type, child.Type)
+		}
+		if err := check(child, allowed, childPath+".content"); err != nil {
+			return err
+		}
+		if spec.holds == holdsText && len(child.Marks) > 0 {
+			return fmt.Errorf("%s: code blocks hold unmarked text", childPath)
+		}
+	}
+	if spec.holds == holdsNothing && len(n.Content) > 0 {
+		return fmt.Errorf("%s: %s has no content", path, n.Type)
+	}
+	if (spec.holds == holdsItems || n.Type == "listItem") && len(n.Content) == 0 {
+		return fmt.Errorf("%s: %s must not be empty", path, n.Type)
+	}
+	return nil
+}
+
+func fits(holds int, child string) bool {
+	switch holds {
+	case holdsBlocks:
+		return specs[child].block
+	case holdsInline:
+		return child == "text" || child == "hardBreak"
+	case holdsItems:
+		return child == "listItem"
+	case holdsText:
+		return child == "text"
+	}
+	return false
+}
+
+func checkAttrs(owner string, attrs map[string]interface{}, known []string) error {
+	for key, v := range attrs {
+		if !slices.Contains(known, key) {
+			return fmt.Errorf("%s has no attribute %q", owner, key)
+		}
+		if v == nil {
+			continue
+		}
+		switch key {
+		case "level":
+			if f, ok := number(v); !ok || f != float64(int(f)) || f < 1 || f > 6 {
+				return fmt.Errorf("heading level must be 1 to 6")
+			}
+		case "start":
+			if f, ok := number(v); !ok || f != float64(int(f)) || f < 0 {
+				return fmt.Errorf("list start must be a whole number")
+			}
+		case "language":
+			if s, ok := v.(string); !ok || !language.MatchString(s) {
+				return fmt.Errorf("invalid code block language")
+			}
+		case "src":
+			if s, ok := v.(string); !ok || !SafeURL(s, false) {
+				return fmt.Errorf("image src %v is not a http(s) or relative URL", v)
+			}
+		case "href":
+			if s, ok := v.(string); !ok || !SafeURL(s, true) {
+				return fmt.Errorf("link href %v is not a safe URL", v)
+			}
+		case "contentId":
+			if s, ok := v.(string); !ok || uuid.Validate(s) != nil {
+				return fmt.Errorf("link contentId must be a UUID")
+			}
+		default:
+			if _, ok := v.(string); !ok {
+				return fmt.Errorf("%s %s must be a string", owner, key)
+			}
+		}
+	}
+	if owner == "image" && attrs["src"] == nil {
+		return fmt.Errorf("images need a src")
+	}
+	if owner == "link" && attrs["href"] == nil && attrs["contentId"] == nil {
+		return fmt.Errorf("links need an href or a contentId")
+	}
+	return nil
+}
+
+// SafeURL accepts relative URLs and http(s), plus mailto, tel and content
+// links when link is set. Anything a browser could run is refused.
+func SafeURL(s string, link bool) bool {
+	u, err := url.Parse(s)
+	if err != nil || strings.ContainsAny(s, "\x00\t\n\r") {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		// "javascript&colon;" and friends decode to a scheme before this
+		return !strings.Contains(strings.SplitN(s, "/", 2)[0], ":")
+	case "http", "https":
+		return true
+	case "mailto", "tel":
+		return link
+	case "content":
+		return link && uuid.Validate(u.Opaque) == nil
+	}
+	return false
+}
+
+// fit drops what a parsed document may not hold: disallowed marks go, and
+// disallowed blocks become paragraphs or lose their wrapper
+func fit(doc *Node, allowed Allowed) {
+	doc.Content = fitBlocks(doc.Content, allowed)
+}
+
+func fitBlocks(blocks []*Node, allowed Allowed) []*Node {
+	var out []*Node
+	for _, b := range blocks {
+		b.Content = fitChildren(b, allowed)
+		if allowed.node(b.Type) {
+			if (specs[b.Type].holds == holdsItems || b.Type == "listItem") && len(b.Content) == 0 {
+				continue
+			}
+			out = append(out, b)
+			continue
+		}
+		switch specs[b.Type].holds {
+		case holdsInline:
+			b.Type, b.Attrs = "paragraph", nil
+			out = append(out, b)
+		case holdsText:
+			out = append(out, &Node{Type: "paragraph", Content: b.Content})
+		case holdsBlocks:
+			out = append(out, b.Content...)
+		case holdsItems:
+			for _, item := range b.Content {
+				out = append(out, item.Content...)
+			}
+		}
+		// rules and images are left out
+	}
+	return out
+}
+
+func fitChildren(n *Node, allowed Allowed) []*Node {
+	switch specs[n.Type].holds {
+	case holdsBlocks:
+		return fitBlocks(n.Content, allowed)
+	case holdsItems:
+		var items []*Node
+		for _, item := range n.Content {
+			item.Content = fitBlocks(item.Content, allowed)
+			if len(item.Content) > 0 {
+				items = append(items, item)
+			}
+		}
+		// a list that is not allowed is unwrapped by the caller
+		if allowed.node(n.Type) && !allowed.node("listItem") {
+			return nil
+		}
+		return items
+	case holdsInline:
+		var inline []*Node
+		for _, c := range n.Content {
+			if c.Type == "hardBreak" && !allowed.node("hardBreak") {
+				c = &Node{Type: "text", Text: " "}
+			}
+			marks := c.Marks[:0]
+			for _, m := range c.Marks {
+				if allowed.mark(m.Type) {
+					marks = append(marks, m)
+				}
+			}
+			c.Marks = marks
+			if len(c.Marks) == 0 {
+				c.Marks = nil
+			}
+			inline = append(inline, c)
+		}
+		return mergeText(inline)
+	}
+	return n.Content
+}
+
+// mergeText joins neighbouring text nodes with the same marks and drops empty ones
+func mergeText(nodes []*Node) []*Node {
+	var out []*Node
+	for _, n := range nodes {
+		if n.Type == "text" && n.Text == "" {
+			continue
+		}
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			if last.Type == "text" && n.Type == "text" && sameMarks(last.Marks, n.Marks) {
+				last.Text += n.Text
+				continue
+			}
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+func sameMarks(a, b []*Mark) bool {
+	return slices.EqualFunc(a, b, sameMark)
+}
+
+func sameMark(a, b *Mark) bool {
+	if a.Type != b.Type || len(a.Attrs) != len(b.Attrs) {
+		return false
+	}
+	for k, v := range a.Attrs {
+		if b.Attrs[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// PlainText is the text of a document, blocks on their own lines, as indexed for search
+func PlainText(doc *Node) string {
+	var b strings.Builder
+	plainText(&b, doc)
+	return strings.TrimSpace(b.String())
+}
+
+func plainText(b *strings.Builder, n *Node) {
+	switch n.Type {
+	case "text":
+		b.WriteString(n.Text)
+		return
+	case "hardBreak":
+		b.WriteString("\n")
+		return
+	case "image":
+		if alt, _ := n.Attrs["alt"].(string); alt != "" {
+			b.WriteString(alt + "\n")
+		}
+		return
+	}
+	for _, c := range n.Content {
+		plainText(b, c)
+	}
+	if specs[n.Type].block {
+		b.WriteString("\n")
+	}
+}
+
+// attr reads a string attribute
+func attr(attrs map[string]interface{}, key string) string {
+	s, _ := attrs[key].(string)
+	return s
+}
+
+// number reads a number decoded from JSON or set by a parser
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func intAttr(attrs map[string]interface{}, key string, fallback int) int {
+	switch v := attrs[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return fallback
+}
+
+func listItem(blocks []*Node) *Node {
+	if len(blocks) == 0 {
+		blocks = []*Node{{Type: "paragraph"}}
+	}
+	return &Node{Type: "listItem", Content: blocks}
+}
+
+// paragraphs wraps inline nodes in paragraphs, split around the images in them
+func paragraphs(inline []*Node) []*Node {
+	var blocks, run []*Node
+	flush := func() {
+		run = trimInline(run)
+		if len(run) > 0 {
+			blocks = append(blocks, &Node{Type: "paragraph", Content: mergeText(run)})
+		}
+		run = nil
+	}
+	for _, n := range inline {
+		if n.Type == "image" {
+			flush()
+			blocks = append(blocks, n)
+		} else {
+			run = append(run, n)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// trimInline drops the breaks and spaces at either end, nothing is left of a
+// run without text
+func trimInline(nodes []*Node) []*Node {
+	for len(nodes) > 0 && (nodes[0].Type == "hardBreak" || strings.TrimSpace(nodes[0].Text) == "") {
+		nodes = nodes[1:]
+	}
+	for len(nodes) > 0 && (nodes[len(nodes)-1].Type == "hardBreak" || strings.TrimSpace(nodes[len(nodes)-1].Text) == "") {
+		nodes = nodes[:len(nodes)-1]
+	}
+	if len(nodes) > 0 {
+		nodes[0].Text = strings.TrimLeft(nodes[0].Text, " ")
+		nodes[len(nodes)-1].Text = strings.TrimRight(nodes[len(nodes)-1].Text, " ")
+	}
+	return nodes
+}
+
+// inlineOnly replaces images with their description, for headings
+func inlineOnly(nodes []*Node) []*Node {
+	out := nodes[:0]
+	for _, n := range nodes {
+		if n.Type == "image" {
+			n = &Node{Type: "text", Text: attr(n.Attrs, "alt")}
+		}
+		out = append(out, n)
+	}
+	return mergeText(out)
+}
+
+func inlineText(nodes []*Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(n.Text)
+	}
+	return b.String()
+}
+
+var markOrder = map[string]int{"link": 0, "bold": 1, "italic": 2, "strike": 3, "code": 4}
+
+// orderMarks sorts marks outermost first, so that runs of text share their
+// outer marks
+func orderMarks(marks []*Mark, keep func(string) bool) []*Mark {
+	var out []*Mark
+	for _, m := range marks {
+		if keep(m.Type) {
+			out = append(out, m)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b *Mark) int { return markOrder[a.Type] - markOrder[b.Type] })
+	return out
+}
+
+// commonMarks is how many marks from the start two runs share
+func commonMarks(a, b []*Mark) int {
+	k := 0
+	for k < len(a) && k < len(b) && sameMark(a[k], b[k]) {
+		k++
+	}
+	return k
+}
@@ -0,0 +1,410 @@
+package richtext
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// elements dropped with everything inside them
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "template": true, "noscript": true,
+	"iframe": true, "frame": true, "frameset": true, "object": true, "embed": true, "applet": true, "param": true,
+	"svg": true, "math": true, "canvas": true, "audio": true, "video": true, "source": true, "track": true,
+	"input": true, "textarea": true, "select": true, "option": true, "button": true,
+	"head": true, "title": true, "meta": true, "link": true, "base": true,
+}
+
+// elements whose content is read as blocks of their own
+var blockElements = map[string]bool{
+	"div": true, "section": true, "article": true, "aside": true, "header": true, "footer": true,
+	"nav": true, "main": true, "figure": true, "figcaption": true, "address": true, "center": true,
+	"details": true, "summary": true, "fieldset": true, "form": true, "hgroup": true, "li": true,
+	"table": true, "caption": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "td": true, "th": true,
+	"dl": true, "dt": true, "dd": true, "body": true, "html": true,
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "ul": true, "ol": true, "pre": true, "hr": true,
+}
+
+var htmlSpace = regexp.MustCompile(`[ \t\n\r\f]+`)
+
+// policy allows exactly what RenderHTML writes, a second line of defence
+// should a document ever get past Check with something it shouldn't hold
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "ul", "ol", "li", "pre", "hr", "br", "strong", "em", "s", "code")
+	p.AllowAttrs("start").Matching(regexp.MustCompile(`^-?[0-9]+$`)).OnElements("ol")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]{0,32}$`)).OnElements("code")
+	p.AllowAttrs("href", "title").OnElements("a")
+	p.AllowAttrs("data-content-id").Matching(regexp.MustCompile(`^[0-9a-fA-F-]{36}$`)).OnElements("a")
+	p.AllowAttrs("src", "alt", "title").OnElements("img")
+	p.AllowURLSchemes("http", "https", "mailto", "tel")
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	return p
+}()
+
+// ParseHTML reads the structure and formatting of an HTML fragment, as an
+// editor pasting it would. Scripts, styles, embeds, forms, attributes and
+// unsafe links go; elements with no equivalent keep only their text.
+func ParseHTML(s string) *Node {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), body)
+	if err != nil {
+		return &Node{Type: "doc"}
+	}
+	return &Node{Type: "doc", Content: htmlBlocks(nodes, 0)}
+}
+
+func htmlBlocks(nodes []*html.Node, depth int) []*Node {
+	var blocks, inline []*Node
+	flush := func() {
+		blocks = append(blocks, paragraphs(collapse(inline))...)
+		inline = nil
+	}
+
+	for _, n := range nodes {
+		if n.Type == html.TextNode {
+			inline = append(inline, &Node{Type: "text", Text: n.Data})
+			continue
+		}
+		if n.Type != html.ElementNode || dropped(n) {
+			continue
+		}
+		if !blockElements[n.Data] {
+			inline = append(inline, htmlInline([]*html.Node{n}, nil)...)
+			continue
+		}
+
+		flush()
+		switch tag := n.Data; {
+		case tag == "p":
+			blocks = append(blocks, paragraphs(collapse(htmlInline(children(n), nil)))...)
+		case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+			blocks = append(blocks, heading(int(tag[1]-'0'), collapse(htmlInline(children(n), nil))))
+		case tag == "pre":
+			blocks = append(blocks, htmlCode(n))
+		case tag == "hr":
+			blocks = append(blocks, &Node{Type: "horizontalRule"})
+		case depth >= maxDepth:
+			blocks = append(blocks, htmlBlocks(children(n), depth)...)
+		case tag == "blockquote":
+			if inner := htmlBlocks(children(n), depth+1); len(inner) > 0 {
+				blocks = append(blocks, &Node{Type: "blockquote", Content: inner})
+			}
+		case tag == "ul" || tag == "ol":
+			if list := htmlList(n, depth); list != nil {
+				blocks = append(blocks, list)
+			}
+		default:
+			blocks = append(blocks, htmlBlocks(children(n), depth)...)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func htmlList(n *html.Node, depth int) *Node {
+	list := &Node{Type: "bulletList"}
+	if n.Data == "ol" {
+		list.Type = "orderedList"
+		if start, err := strconv.Atoi(strings.TrimSpace(attrOf(n, "start"))); err == nil && start >= 0 && start != 1 {
+			list.Attrs = map[string]interface{}{"start": start}
+		}
+	}
+
+	var stray []*html.Node
+	flush := func() {
+		if blocks := htmlBlocks(stray, depth+1); len(blocks) > 0 {
+			list.Content = append(list.Content, listItem(blocks))
+		}
+		stray = nil
+	}
+	for _, c := range children(n) {
+		if c.Type == html.ElementNode && c.Data == "li" {
+			flush()
+			list.Content = append(list.Content, listItem(htmlBlocks(children(c), depth+1)))
+		} else {
+			stray = append(stray, c)
+		}
+	}
+	flush()
+	if len(list.Content) == 0 {
+		return nil
+	}
+	return list
+}
+
+func htmlCode(pre *html.Node) *Node {
+	lang := languageOf(pre)
+	for _, c := range children(pre) {
+		if lang == "" && c.Type == html.ElementNode && c.Data == "code" {
+			lang = languageOf(c)
+		}
+	}
+	var b strings.Builder
+	textContent(&b, pre)
+	return codeBlock(strings.TrimSuffix(b.String(), "\n"), lang)
+}
+
+func languageOf(n *html.Node) string {
+	for _, class := range strings.Fields(attrOf(n, "class")) {
+		lang, ok := strings.CutPrefix(class, "language-")
+		if !ok {
+			lang, ok = strings.CutPrefix(class, "lang-")
+		}
+		if ok && language.MatchString(lang) {
+			return lang
+		}
+	}
+	return ""
+}
+
+func textContent(b *strings.Builder, n *html.Node) {
+	for _, c := range children(n) {
+		switch {
+		case c.Type == html.TextNode:
+			b.WriteString(c.Data)
+		case c.Type != html.ElementNode || dropped(c):
+		case c.Data == "br":
+			b.WriteString("\n")
+		default:
+			textContent(b, c)
+		}
+	}
+}
+
+func htmlInline(nodes []*html.Node, marks []*Mark) []*Node {
+	var out []*Node
+	for _, n := range nodes {
+		if n.Type == html.TextNode {
+			out = append(out, &Node{Type: "text", Text: n.Data, Marks: marks})
+			continue
+		}
+		if n.Type != html.ElementNode || dropped(n) {
+			continue
+		}
+
+		var mark *Mark
+		switch n.Data {
+		case "br":
+			out = append(out, &Node{Type: "hardBreak"})
+			continue
+		case "img":
+			if img := htmlImage(n); img != nil {
+				out = append(out, img)
+			}
+			continue
+		case "strong", "b":
+			mark = &Mark{Type: "bold"}
+		case "em", "i":
+			mark = &Mark{Type: "italic"}
+		case "s", "del", "strike":
+			mark = &Mark{Type: "strike"}
+		case "code", "kbd", "samp", "tt":
+			mark = &Mark{Type: "code"}
+		case "a":
+			if id := attrOf(n, "data-content-id"); uuid.Validate(id) == nil {
+				mark = &Mark{Type: "link", Attrs: map[string]interface{}{"contentId": id}}
+				if title := attrOf(n, "title"); title != "" {
+					mark.Attrs["title"] = title
+				}
+			} else if href := strings.TrimSpace(attrOf(n, "href")); href != "" {
+				mark = linkMark(href, attrOf(n, "title"))
+			}
+		}
+
+		inner := marks
+		if mark != nil && !hasMark(marks, mark.Type) {
+			inner = append(append([]*Mark(nil), marks...), mark)
+		}
+		// blocks inside inline elements at least stay apart
+		if blockElements[n.Data] {
+			out = append(out, &Node{Type: "text", Text: " ", Marks: marks})
+		}
+		out = append(out, htmlInline(children(n), inner)...)
+		if blockElements[n.Data] {
+			out = append(out, &Node{Type: "text", Text: " ", Marks: marks})
+		}
+	}
+	return out
+}
+
+func htmlImage(n *html.Node) *Node {
+	src := strings.TrimSpace(attrOf(n, "src"))
+	if src == "" || !SafeURL(src, false) {
+		return nil
+	}
+	attrs := map[string]interface{}{"src": src}
+	for _, key := range []string{"alt", "title"} {
+		if v := attrOf(n, key); v != "" {
+			attrs[key] = v
+		}
+	}
+	return &Node{Type: "image", Attrs: attrs}
+}
+
+// collapse folds whitespace the way a browser lays it out
+func collapse(inline []*Node) []*Node {
+	var out []*Node
+	var last *Node
+	space := true // at the start of a line
+	trim := func() {
+		if last != nil {
+			last.Text = strings.TrimRight(last.Text, " ")
+		}
+		last, space = nil, true
+	}
+
+	for _, n := range inline {
+		if n.Type != "text" {
+			trim()
+			out = append(out, n)
+			continue
+		}
+		text := htmlSpace.ReplaceAllString(n.Text, " ")
+		if space {
+			text = strings.TrimLeft(text, " ")
+		}
+		if text == "" {
+			continue
+		}
+		n.Text = text
+		out = append(out, n)
+		last, space = n, strings.HasSuffix(text, " ")
+	}
+	trim()
+	return mergeText(out)
+}
+
+func dropped(n *html.Node) bool {
+	return droppedElements[n.Data] || n.Namespace != ""
+}
+
+func children(n *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, c)
+	}
+	return nodes
+}
+
+func attrOf(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// RenderHTML writes a document as HTML, every text and attribute escaped and
+// the result passed through a strict allowlist
+func RenderHTML(doc *Node) string {
+	var b strings.Builder
+	for _, n := range doc.Content {
+		renderHTML(&b, n)
+	}
+	return policy.Sanitize(b.String())
+}
+
+func renderHTML(b *strings.Builder, n *Node) {
+	wrap := func(open, close string) {
+		b.WriteString(open)
+		for _, c := range n.Content {
+			renderHTML(b, c)
+		}
+		b.WriteString(close)
+	}
+
+	switch n.Type {
+	case "paragraph":
+		b.WriteString("<p>" + htmlInlineString(n.Content) + "</p>")
+	case "heading":
+		h := strconv.Itoa(min(max(intAttr(n.Attrs, "level", 1), 1), 6))
+		b.WriteString("<h" + h + ">" + htmlInlineString(n.Content) + "</h" + h + ">")
+	case "blockquote":
+		wrap("<blockquote>", "</blockquote>")
+	case "bulletList":
+		wrap("<ul>", "</ul>")
+	case "orderedList":
+		if start := intAttr(n.Attrs, "start", 1); start != 1 {
+			wrap(`<ol start="`+strconv.Itoa(start)+`">`, "</ol>")
+		} else {
+			wrap("<ol>", "</ol>")
+		}
+	case "listItem":
+		wrap("<li>", "</li>")
+	case "codeBlock":
+		b.WriteString("<pre><code")
+		if lang := attr(n.Attrs, "language"); lang != "" {
+			b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+		}
+		b.WriteString(">" + html.EscapeString(inlineText(n.Content)) + "</code></pre>")
+	case "horizontalRule":
+		b.WriteString("<hr>")
+	case "image":
+		b.WriteString(`<img src="` + html.EscapeString(attr(n.Attrs, "src")) + `"`)
+		for _, key := range []string{"alt", "title"} {
+			if v := attr(n.Attrs, key); v != "" {
+				b.WriteString(" " + key + `="` + html.EscapeString(v) + `"`)
+			}
+		}
+		b.WriteString(">")
+	}
+}
+
+func htmlInlineString(nodes []*Node) string {
+	var b strings.Builder
+	var open []*Mark
+	closeTo := func(k int) {
+		for len(open) > k {
+			b.WriteString(htmlClose(open[len(open)-1]))
+			open = open[:len(open)-1]
+		}
+	}
+	for _, n := range nodes {
+		if n.Type == "hardBreak" {
+			closeTo(0)
+			b.WriteString("<br>")
+			continue
+		}
+		marks := orderMarks(n.Marks, func(string) bool { return true })
+		k := commonMarks(open, marks)
+		closeTo(k)
+		for _, m := range marks[k:] {
+			b.WriteString(htmlOpen(m))
+		}
+		open = append(open, marks[k:]...)
+		b.WriteString(html.EscapeString(n.Text))
+	}
+	closeTo(0)
+	return b.String()
+}
+
+var htmlTags = map[string]string{"bold": "strong", "italic": "em", "strike": "s", "code": "code", "link": "a"}
+
+func htmlOpen(m *Mark) string {
+	if m.Type != "link" {
+		return "<" + htmlTags[m.Type] + ">"
+	}
+	a := "<a"
+	if id := attr(m.Attrs, "contentId"); id != "" {
+		a += ` data-content-id="` + html.EscapeString(id) + `"`
+	} else {
+		a += ` href="` + html.EscapeString(attr(m.Attrs, "href")) + `"`
+	}
+	if title := attr(m.Attrs, "title"); title != "" {
+		a += ` title="` + html.EscapeString(title) + `"`
+	}
+	return a + ">"
+}
+
+func htmlClose(m *Mark) string {
+	return "</" + htmlTags[m.Type] + ">"
+}
@@ -0,0 +1,505 @@
+package richtext
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// containers nested deeper than this are read as plain paragraphs
+const maxDepth = 32
+
+var entity = regexp.MustCompile(`^&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[A-Za-z][A-Za-z0-9]{1,31});`)
+
+// markdown is CommonMark with strikethrough and without HTML blocks: raw HTML
+// is text, so a line starting with a tag is an ordinary paragraph
+var markdown = goldmark.New(
+	goldmark.WithParser(parser.NewParser(
+		parser.WithBlockParsers(
+			util.Prioritized(parser.NewSetextHeadingParser(), 100),
+			util.Prioritized(parser.NewThematicBreakParser(), 200),
+			util.Prioritized(parser.NewListParser(), 300),
+			util.Prioritized(parser.NewListItemParser(), 400),
+			util.Prioritized(parser.NewCodeBlockParser(), 500),
+			util.Prioritized(parser.NewATXHeadingParser(), 600),
+			util.Prioritized(parser.NewFencedCodeBlockParser(), 700),
+			util.Prioritized(parser.NewBlockquoteParser(), 800),
+			util.Prioritized(parser.NewParagraphParser(), 1000),
+		),
+		parser.WithInlineParsers(parser.DefaultInlineParsers()...),
+		parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+	)),
+	goldmark.WithExtensions(extension.Strikethrough),
+)
+
+// ParseMarkdown reads CommonMark: headings, paragraphs, block quotes, lists,
+// code blocks, rules, emphasis, strikethrough, code, links and images. Raw
+// HTML stays text. Links to content:<id> point at another entry.
+func ParseMarkdown(s string) *Node {
+	src := []byte(s)
+	root := markdown.Parser().Parse(text.NewReader(src))
+	return &Node{Type: "doc", Content: goldmarkBlocks(root, src, 0)}
+}
+
+func goldmarkBlocks(parent ast.Node, src []byte, depth int) []*Node {
+	var blocks []*Node
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch n := n.(type) {
+		case *ast.Paragraph, *ast.TextBlock:
+			blocks = append(blocks, paragraphs(goldmarkInline(n, src, nil, nil))...)
+		case *ast.Heading:
+			blocks = append(blocks, heading(n.Level, trimInline(goldmarkInline(n, src, nil, nil))))
+		case *ast.ThematicBreak:
+			blocks = append(blocks, &Node{Type: "horizontalRule"})
+		case *ast.CodeBlock:
+			blocks = append(blocks, codeBlock(lines(n, src), ""))
+		case *ast.FencedCodeBlock:
+			lang := ""
+			if n.Info != nil {
+				lang = resolve(string(n.Language(src)))
+			}
+			if !language.MatchString(lang) {
+				lang = ""
+			}
+			blocks = append(blocks, codeBlock(lines(n, src), lang))
+		case *ast.Blockquote:
+			if depth >= maxDepth {
+				blocks = append(blocks, goldmarkBlocks(n, src, depth)...)
+			} else {
+				blocks = append(blocks, &Node{Type: "blockquote", Content: goldmarkBlocks(n, src, depth+1)})
+			}
+		case *ast.List:
+			if depth >= maxDepth {
+				for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+					blocks = append(blocks, goldmarkBlocks(item, src, depth)...)
+				}
+				continue
+			}
+			list := &Node{Type: "bulletList"}
+			if n.IsOrdered() {
+				list.Type = "orderedList"
+				if n.Start != 1 {
+					list.Attrs = map[string]interface{}{"start": n.Start}
+				}
+			}
+			for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+				list.Content = append(list.Content, listItem(goldmarkBlocks(item, src, depth+1)))
+			}
+			blocks = append(blocks, list)
+		}
+		// link reference definitions leave nothing behind
+	}
+	return blocks
+}
+
+// lines is the text of a leaf block without its final newline
+func lines(n ast.Node, src []byte) string {
+	var b strings.Builder
+	segments := n.Lines()
+	for i := 0; i < segments.Len(); i++ {
+		segment := segments.At(i)
+		b.Write(segment.Value(src))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func goldmarkInline(parent ast.Node, src []byte, marks []*Mark, out []*Node) []*Node {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch n := n.(type) {
+		case *ast.Text:
+			value := string(n.Segment.Value(src))
+			if !n.IsRaw() {
+				value = resolve(value)
+			}
+			out = append(out, &Node{Type: "text", Text: value, Marks: marks})
+			if n.HardLineBreak() {
+				out = append(out, &Node{Type: "hardBreak"})
+			} else if n.SoftLineBreak() {
+				out = append(out, &Node{Type: "text", Text: " ", Marks: marks})
+			}
+		case *ast.String:
+			value := string(n.Value)
+			if !n.IsRaw() && !n.IsCode() {
+				value = resolve(value)
+			}
+			out = append(out, &Node{Type: "text", Text: value, Marks: marks})
+		case *ast.CodeSpan:
+			var b strings.Builder
+			rawText(&b, n, src)
+			out = append(out, &Node{Type: "text", Text: strings.ReplaceAll(b.String(), "\n", " "), Marks: withMark(marks, &Mark{Type: "code"})})
+		case *ast.RawHTML:
+			for i := 0; i < n.Segments.Len(); i++ {
+				segment := n.Segments.At(i)
+				out = append(out, &Node{Type: "text", Text: string(segment.Value(src)), Marks: marks})
+			}
+		case *ast.Emphasis:
+			mark := &Mark{Type: "italic"}
+			if n.Level == 2 {
+				mark.Type = "bold"
+			}
+			out = goldmarkInline(n, src, withMark(marks, mark), out)
+		case *east.Strikethrough:
+			out = goldmarkInline(n, src, withMark(marks, &Mark{Type: "strike"}), out)
+		case *ast.Link:
+			inner := marks
+			if mark := linkMark(resolve(string(n.Destination)), resolve(string(n.Title))); mark != nil {
+				inner = withMark(marks, mark)
+			}
+			out = goldmarkInline(n, src, inner, out)
+		case *ast.AutoLink:
+			label := string(n.Label(src))
+			var mark *Mark
+			if n.AutoLinkType == ast.AutoLinkEmail {
+				mark = &Mark{Type: "link", Attrs: map[string]interface{}{"href": "mailto:" + label}}
+			} else {
+				mark = linkMark(string(n.URL(src)), "")
+			}
+			if mark != nil {
+				out = append(out, &Node{Type: "text", Text: label, Marks: withMark(marks, mark)})
+			} else {
+				out = append(out, &Node{Type: "text", Text: "<" + label + ">", Marks: marks})
+			}
+		case *ast.Image:
+			dest := resolve(string(n.Destination))
+			if !SafeURL(dest, false) {
+				continue
+			}
+			attrs := map[string]interface{}{"src": dest}
+			if alt := inlineText(goldmarkInline(n, src, nil, nil)); alt != "" {
+				attrs["alt"] = alt
+			}
+			if title := resolve(string(n.Title)); title != "" {
+				attrs["title"] = title
+			}
+			out = append(out, &Node{Type: "image", Attrs: attrs})
+		default:
+			out = goldmarkInline(n, src, marks, out)
+		}
+	}
+	return mergeText(out)
+}
+
+// rawText is the source text under a node, as code spans keep it
+func rawText(b *strings.Builder, n ast.Node, src []byte) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(src))
+		case *ast.String:
+			b.Write(c.Value)
+		default:
+			rawText(b, c, src)
+		}
+	}
+}
+
+func withMark(marks []*Mark, mark *Mark) []*Mark {
+	if hasMark(marks, mark.Type) {
+		return marks
+	}
+	return append(append([]*Mark(nil), marks...), mark)
+}
+
+func codeBlock(code, lang string) *Node {
+	n := &Node{Type: "codeBlock"}
+	if lang != "" {
+		n.Attrs = map[string]interface{}{"language": lang}
+	}
+	if code != "" {
+		n.Content = []*Node{{Type: "text", Text: code}}
+	}
+	return n
+}
+
+func heading(level int, inline []*Node) *Node {
+	return &Node{Type: "heading", Attrs: map[string]interface{}{"level": level}, Content: inlineOnly(inline)}
+}
+
+// linkMark is nil for destinations that are not safe to link to
+func linkMark(dest, title string) *Mark {
+	attrs := map[string]interface{}{}
+	if id, ok := strings.CutPrefix(dest, "content:"); ok && uuid.Validate(id) == nil {
+		attrs["contentId"] = id
+	} else if SafeURL(dest, true) {
+		attrs["href"] = dest
+	} else {
+		return nil
+	}
+	if title != "" {
+		attrs["title"] = title
+	}
+	return &Mark{Type: "link", Attrs: attrs}
+}
+
+// resolve reads the backslash escapes and character references of Markdown
+// text, so "jav&#x61;script:" is checked as the scheme it spells
+func resolve(s string) string {
+	if !strings.ContainsAny(s, `\&`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			i++
+			b.WriteByte(s[i])
+		case s[i] == '&':
+			if m := entity.FindString(s[i:]); m != "" {
+				b.WriteString(html.UnescapeString(m))
+				i += len(m) - 1
+				continue
+			}
+			b.WriteByte('&')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func isASCIIPunct(c byte) bool {
+	return c > ' ' && c < 0x7f && !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9')
+}
+
+func hasMark(marks []*Mark, t string) bool {
+	for _, m := range marks {
+		if m.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderMarkdown writes a document as CommonMark that parses back to it
+func RenderMarkdown(doc *Node) string {
+	return markdownBlocks(doc.Content)
+}
+
+func markdownBlocks(blocks []*Node) string {
+	parts := make([]string, 0, len(blocks))
+	prev := ""
+	for _, b := range blocks {
+		// two lists in a row need different markers to stay apart
+		parts = append(parts, markdownBlock(b, prev == b.Type))
+		prev = b.Type
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func markdownBlock(n *Node, alternate bool) string {
+	switch n.Type {
+	case "paragraph":
+		return escapeLineStarts(markdownInline(n.Content))
+	case "heading":
+		level := min(max(intAttr(n.Attrs, "level", 1), 1), 6)
+		text := strings.ReplaceAll(markdownInline(n.Content), "\\\n", " ")
+		return strings.Repeat("#", level) + " " + text
+	case "blockquote":
+		lines := strings.Split(markdownBlocks(n.Content), "\n")
+		for i, line := range lines {
+			if line == "" {
+				lines[i] = ">"
+			} else {
+				lines[i] = "> " + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	case "bulletList", "orderedList":
+		return markdownList(n, alternate)
+	case "codeBlock":
+		code := inlineText(n.Content)
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		if code != "" {
+			code += "\n"
+		}
+		return fence + attr(n.Attrs, "language") + "\n" + code + fence
+	case "horizontalRule":
+		return "---"
+	case "image":
+		return "![" + escapeMarkdown(attr(n.Attrs, "alt")) + "](" + markdownDest(attr(n.Attrs, "src")) + markdownTitle(attr(n.Attrs, "title")) + ")"
+	}
+	return ""
+}
+
+func markdownList(n *Node, alternate bool) string {
+	start := intAttr(n.Attrs, "start", 1)
+	bullet, delim := "-", "."
+	if alternate {
+		bullet, delim = "*", ")"
+	}
+
+	tight := true
+	items := make([]string, 0, len(n.Content))
+	for i, item := range n.Content {
+		if len(item.Content) > 1 {
+			tight = false
+		}
+		marker := bullet + " "
+		if n.Type == "orderedList" {
+			marker = fmt.Sprintf("%d%s ", start+i, delim)
+		}
+		lines := strings.Split(markdownBlocks(item.Content), "\n")
+		for j, line := range lines {
+			if j == 0 {
+				lines[j] = marker + line
+			} else if line != "" {
+				lines[j] = strings.Repeat(" ", len(marker)) + line
+			}
+		}
+		items = append(items, strings.Join(lines, "\n"))
+	}
+	if tight {
+		return strings.Join(items, "\n")
+	}
+	return strings.Join(items, "\n\n")
+}
+
+func markdownInline(nodes []*Node) string {
+	var b strings.Builder
+	var open []*Mark
+	// spaces are held back so they end up outside closing marks
+	space := ""
+	write := func(s string) {
+		b.WriteString(space)
+		space = ""
+		b.WriteString(s)
+	}
+	closeTo := func(k int) {
+		for len(open) > k {
+			b.WriteString(markdownClose(open[len(open)-1]))
+			open = open[:len(open)-1]
+		}
+	}
+
+	for _, n := range nodes {
+		if n.Type == "hardBreak" {
+			closeTo(0)
+			space = ""
+			b.WriteString("\\\n")
+			continue
+		}
+		text := strings.ReplaceAll(n.Text, "\n", " ")
+		marks := markRun(n.Marks, false)
+		if strings.TrimSpace(text) == "" {
+			// emphasis around nothing but spaces doesn't parse
+			marks = markRun(n.Marks, true)
+		}
+		k := commonMarks(open, marks)
+		closeTo(k)
+		if len(marks) > k {
+			lead := text[:len(text)-len(strings.TrimLeft(text, " "))]
+			write(lead)
+			text = text[len(lead):]
+			for _, m := range marks[k:] {
+				write(markdownOpen(m))
+			}
+			open = append(open, marks[k:]...)
+		}
+		if hasMark(n.Marks, "code") {
+			write(codeSpan(text))
+			continue
+		}
+		body := strings.TrimRight(text, " ")
+		write(escapeMarkdown(body))
+		space += text[len(body):]
+	}
+	closeTo(0)
+	return b.String()
+}
+
+func markdownOpen(m *Mark) string {
+	switch m.Type {
+	case "bold":
+		return "**"
+	case "italic":
+		return "*"
+	case "strike":
+		return "~~"
+	case "link":
+		return "["
+	}
+	return ""
+}
+
+func markdownClose(m *Mark) string {
+	if m.Type == "link" {
+		dest := attr(m.Attrs, "href")
+		if id := attr(m.Attrs, "contentId"); id != "" {
+			dest = "content:" + id
+		}
+		return "](" + markdownDest(dest) + markdownTitle(attr(m.Attrs, "title")) + ")"
+	}
+	return markdownOpen(m)
+}
+
+func markdownDest(dest string) string {
+	if strings.ContainsAny(dest, " ()<>") {
+		return "<" + strings.NewReplacer("<", `\<`, ">", `\>`).Replace(dest) + ">"
+	}
+	return dest
+}
+
+func markdownTitle(title string) string {
+	if title == "" {
+		return ""
+	}
+	return ` "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(title) + `"`
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "~", `\~`, "[", `\[`, "]", `\]`, "<", `\<`, "&", `\&`)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var orderedStart = regexp.MustCompile(`^(\d{1,9})([.)])`)
+
+// escapeLineStarts keeps paragraph lines from reading as headings, quotes or lists
+func escapeLineStarts(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" && strings.IndexByte("#>-+=", line[0]) >= 0 {
+			lines[i] = `\` + line
+		} else if m := orderedStart.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + `\` + line[len(m[1]):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func codeSpan(s string) string {
+	longest, n := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '`' {
+			n++
+			longest = max(longest, n)
+		} else {
+			n = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") || (strings.HasPrefix(s, " ") && strings.HasSuffix(s, " ") && strings.Trim(s, " ") != "") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// markRun is the marks written around a text, code is written as a code span
+func markRun(marks []*Mark, linkOnly bool) []*Mark {
+	return orderMarks(marks, func(t string) bool {
+		return t == "link" || (!linkOnly && t != "code")
+	})
+}
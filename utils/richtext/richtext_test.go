@@ -0,0 +1,266 @@
+package richtext
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	cases := []struct{ in, html string }{
+		{"# Title", "<h1>Title</h1>"},
+		{"Title\n===\n\nSub\n---", "<h1>Title</h1><h2>Sub</h2>"},
+		{"one\ntwo  \nthree", "<p>one two<br>three</p>"},
+		{"*a* **b** ***c*** ~~d~~ `e*`", "<p><em>a</em> <strong>b</strong> <strong><em>c</em></strong> <s>d</s> <code>e*</code></p>"},
+		{"snake_case_name and *foo**bar*", "<p>snake_case_name and <em>foo**bar</em></p>"},
+		{`\*not\* &amp; <b>raw</b>`, "<p>*not* &amp; &lt;b&gt;raw&lt;/b&gt;</p>"},
+		{"[a **b**](https://x.io \"T\") <https://y.io>", `<p><a href="https://x.io" title="T">a <strong>b</strong></a> <a href="https://y.io">https://y.io</a></p>`},
+		{"[see](content:8c4b0c1e-7c1d-4e0a-9f2a-2b5e3f6a7d10)", `<p><a data-content-id="8c4b0c1e-7c1d-4e0a-9f2a-2b5e3f6a7d10">see</a></p>`},
+		{"[x](javascript:alert(1)) ![i](javascript:alert(1))", "<p>x</p>"},
+		{"text ![cat](/cat.png) more", `<p>text</p><img src="/cat.png" alt="cat"><p>more</p>`},
+		{"> quote\nlazy\n\n> two", "<blockquote><p>quote lazy</p></blockquote><blockquote><p>two</p></blockquote>"},
+		{"- a\n- b\n  - c\n\n3. x\n4. y", "<ul><li><p>a</p></li><li><p>b</p><ul><li><p>c</p></li></ul></li></ul><ol start=\"3\"><li><p>x</p></li><li><p>y</p></li></ol>"},
+		{"```go\nfmt.Println(\"<hi>\")\n```\n\n    indented", `<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre><pre><code>indented</code></pre>`},
+		{"***\n\nin\n2019. not a list", "<hr><p>in 2019. not a list</p>"},
+	}
+	for _, c := range cases {
+		doc := ParseMarkdown(c.in)
+		if err := Check(doc, Allowed{}); err != nil {
+			t.Errorf("ParseMarkdown(%q) is invalid: %v", c.in, err)
+		}
+		if got := RenderHTML(doc); got != c.html {
+			t.Errorf("ParseMarkdown(%q)\n got %s\nwant %s", c.in, got, c.html)
+		}
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	cases := []struct{ in, html string }{
+		{`<p onclick="x()">Hi <b>there</b><script>alert(1)</script></p>`, "<p>Hi <strong>there</strong></p>"},
+		{`<a href="javascript:alert(1)">x</a> <a href=" JaVaScRiPt:alert(1)">y</a> <a href="java&#x09;script:alert(1)">z</a>`, "<p>x y z</p>"},
+		{`<img src="x" onerror="alert(1)"><img src="data:image/svg+xml,<svg onload=alert(1)>">`, `<img src="x">`},
+		{`<div>loose <i>text</i><div><h2>Head<img src="/a.png" alt="A"></h2></div></div>`, "<p>loose <em>text</em></p><h2>HeadA</h2>"},
+		{"<ul>\n <li>one</li>\n <li><p>two</p><ol start=\"0\"><li>x</li></ol></li>\n</ul>", `<ul><li><p>one</p></li><li><p>two</p><ol start="0"><li><p>x</p></li></ol></li></ul>`},
+		{"<pre class=\"language-js\"><code>a &lt; b\n  c</code></pre>", `<pre><code class="language-js">a &lt; b
+  c</code></pre>`},
+		{`<iframe src="https://evil"></iframe><svg><a href="/x">svg</a></svg><style>p{}</style>`, ""},
+		{`<a data-content-id="8c4b0c1e-7c1d-4e0a-9f2a-2b5e3f6a7d10">entry</a>`, `<p><a data-content-id="8c4b0c1e-7c1d-4e0a-9f2a-2b5e3f6a7d10">entry</a></p>`},
+	}
+	for _, c := range cases {
+		doc := ParseHTML(c.in)
+		if err := Check(doc, Allowed{}); err != nil {
+			t.Errorf("ParseHTML(%q) is invalid: %v", c.in, err)
+		}
+		if got := RenderHTML(doc); got != c.html {
+			t.Errorf("ParseHTML(%q)\n got %s\nwant %s", c.in, got, c.html)
+		}
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	docs := []string{
+		"# A *b*\n\nSome **bold ***and italic*** text**, `code` and [a link](https://x.io \"t\").",
+		"- one\n- two\n\n* three\n\n1. a\n2. b",
+		"> quoted\n>\n> - list\n\n---\n\n```js\nlet a = \"```\"\n```",
+		"1\\. not a list, \\# not a heading, a\\_b and 2 \\* 3\\\nafter a break",
+		"![alt](</with space.png>)\n\n[x](content:8c4b0c1e-7c1d-4e0a-9f2a-2b5e3f6a7d10)",
+	}
+	for _, md := range docs {
+		doc := ParseMarkdown(md)
+		again := ParseMarkdown(RenderMarkdown(doc))
+		if RenderHTML(doc) != RenderHTML(again) {
+			t.Errorf("%q changed through Markdown:\n%s\n%s\n%s", md, RenderMarkdown(doc), RenderHTML(doc), RenderHTML(again))
+		}
+	}
+
+	// spaces at the edge of a mark move outside it
+	doc := &Node{Type: "doc", Content: []*Node{{Type: "paragraph", Content: []*Node{
+		{Type: "text", Text: "a "},
+		{Type: "text", Text: " b ", Marks: []*Mark{{Type: "bold"}}},
+		{Type: "text", Text: "c"},
+	}}}}
+	if got := RenderMarkdown(doc); got != "a  **b** c" {
+		t.Errorf("RenderMarkdown = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	var doc map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"type":"doc","content":[{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Hi","marks":[{"type":"link","attrs":{"href":"/a"}}]}]}]}`), &doc)
+	if _, err := Parse(doc, Allowed{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(doc, Allowed{Nodes: []string{"image"}}); err == nil || !strings.Contains(err.Error(), "heading is not allowed") {
+		t.Errorf("expected the heading to be refused, got %v", err)
+	}
+	if _, err := Parse(doc, Allowed{Marks: []string{"bold"}}); err == nil {
+		t.Error("expected the link to be refused")
+	}
+
+	bad := []string{
+		`{"type":"doc","content":[{"type":"script"}]}`,
+		`{"type":"doc","content":[{"type":"text","text":"loose"}]}`,
+		`{"type":"doc","content":[{"type":"paragraph","attrs":{"onclick":"x"}}]}`,
+		`{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"x","marks":[{"type":"link","attrs":{"href":"javascript:alert(1)"}}]}]}]}`,
+		`{"type":"doc","content":[{"type":"image","attrs":{"src":"data:text/html,x"}}]}`,
+		`{"type":"doc","content":[{"type":"heading","attrs":{"level":9}}]}`,
+		`{"type":"doc","content":[{"type":"bulletList","content":[]}]}`,
+	}
+	for _, s := range bad {
+		var v map[string]interface{}
+		_ = json.Unmarshal([]byte(s), &v)
+		if _, err := Parse(v, Allowed{}); err == nil {
+			t.Errorf("expected %s to be refused", s)
+		}
+		// and the same as a string, as a CSV import sends it
+		if _, err := Parse(s, Allowed{}); err == nil {
+			t.Errorf("expected the string %s to be refused", s)
+		}
+	}
+
+	// Markdown and HTML lose what a field doesn't allow
+	got, err := Parse("## Head\n\n- **a** [b](/b)", Allowed{Nodes: []string{}, Marks: []string{"italic"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html := RenderHTML(got); html != "<p>Head</p><p>a b</p>" {
+		t.Errorf("got %s", html)
+	}
+	got, _ = Parse(map[string]interface{}{"html": "<h1>x</h1>"}, Allowed{})
+	if html := RenderHTML(got); html != "<h1>x</h1>" {
+		t.Errorf("got %s", html)
+	}
+	if text := PlainText(ParseMarkdown("# A\n\nb *c*\n\n- d")); text != "A\nb c\nd" {
+		t.Errorf("PlainText = %q", text)
+	}
+}
+
+var xss = []string{
+	`<a href="javascript:alert(1)">a</a>`,
+	`<a href="JAVASCRIPT:alert(1)">a</a>`,
+	`<a href="jav&#x61;script:alert(1)">a</a>`,
+	`<a href="&#106;avascript:alert(1)">a</a>`,
+	`<a href="&#0000106&#0000097vascript:alert(1)">a</a>`,
+	`<a href="javascript&colon;alert(1)">a</a>`,
+	`<a href="java\tscript:alert(1)">a</a>`,
+	`<a href="vbscript:msgbox(1)">a</a>`,
+	`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">a</a>`,
+	`<img src="data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9YWxlcnQoMSk+">`,
+	`<img src="javascript:alert(1)">`,
+	`<img src=x onerror=alert(1)>`,
+	`<svg onload=alert(1)><a xlink:href="javascript:alert(1)">a</a></svg>`,
+	`<svg><script>alert(1)</script></svg>`,
+	`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)></style></mglyph></table></mtext></math>`,
+	`<math href="javascript:alert(1)">a</math>`,
+	`<svg><style><img src=x onerror=alert(1)></style></svg>`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+	`<a href="/x" onclick="alert(1)" style="x:expression(alert(1))">a</a>`,
+	`<p><iframe srcdoc="<script>alert(1)</script>"></iframe></p>`,
+	"[a](javascript:alert(1))",
+	"[a](jav&#x61;script:alert(1))",
+	"[a](&#106;avascript:alert(1))",
+	"[a](javascript&#58;alert(1))",
+	"[a](java\\script:alert(1))",
+	"[a](<javascript:alert(1)>)",
+	"[a](data:text/html,<script>alert(1)</script>)",
+	"![a](javascript:alert(1))",
+	"![a](data:image/svg+xml,<svg onload=alert(1)>)",
+	"<javascript:alert(1)>",
+	"[a][r]\n\n[r]: javascript:alert(1)",
+	"<svg onload=alert(1)>",
+	"<math><mi xlink:href=\"javascript:alert(1)\">a</mi></math>",
+	"```\"><script>alert(1)</script>\nx\n```",
+}
+
+// safe fails the test for any element, attribute or URL that RenderHTML
+// should never write
+func safe(t *testing.T, in, out string) {
+	t.Helper()
+	allowed := map[string][]string{
+		"p": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"blockquote": nil, "ul": nil, "ol": {"start"}, "li": nil, "pre": nil, "hr": nil, "br": nil,
+		"strong": nil, "em": nil, "s": nil, "code": {"class"},
+		"a": {"href", "title", "data-content-id"}, "img": {"src", "alt", "title"},
+	}
+	z := html.NewTokenizer(strings.NewReader(out))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		tok := z.Token()
+		attrs, ok := allowed[tok.Data]
+		if !ok {
+			t.Fatalf("%q rendered a <%s>: %s", in, tok.Data, out)
+		}
+		for _, a := range tok.Attr {
+			if !slices.Contains(attrs, a.Key) {
+				t.Fatalf("%q rendered %s on <%s>: %s", in, a.Key, tok.Data, out)
+			}
+			if (a.Key == "href" || a.Key == "src") && !SafeURL(a.Val, a.Key == "href") {
+				t.Fatalf("%q rendered the URL %q: %s", in, a.Val, out)
+			}
+		}
+	}
+}
+
+func TestXSS(t *testing.T) {
+	for _, in := range xss {
+		for _, doc := range []*Node{ParseHTML(in), ParseMarkdown(in)} {
+			if err := Check(doc, Allowed{}); err != nil {
+				t.Errorf("%q is invalid: %v", in, err)
+			}
+			safe(t, in, RenderHTML(doc))
+		}
+	}
+
+	// a document built by hand doesn't get through either
+	doc := &Node{Type: "doc", Content: []*Node{
+		{Type: "paragraph", Content: []*Node{{Type: "text", Text: "x", Marks: []*Mark{{Type: "link", Attrs: map[string]interface{}{"href": "javascript:alert(1)"}}}}}},
+		{Type: "image", Attrs: map[string]interface{}{"src": "data:text/html,x"}},
+		{Type: "codeBlock", Attrs: map[string]interface{}{"language": `"><script>alert(1)</script>`}},
+	}}
+	if out := RenderHTML(doc); strings.Contains(out, "javascript") || strings.Contains(out, "data:") || strings.Contains(out, "<script") {
+		t.Errorf("RenderHTML wrote %s", out)
+	}
+}
+
+func FuzzHTMLRoundTrip(f *testing.F) {
+	for _, s := range xss {
+		f.Add(s)
+	}
+	f.Add(`<p>Hi <b>there</b> <a href="/x" title="t">link</a></p><ul><li>one<ol start="3"><li>two</li></ol></li></ul>`)
+	f.Add("<pre class=\"language-go\"><code>a &lt; b\n</code></pre><blockquote><h2>x<br>y</h2></blockquote><hr><img src=\"/a.png\" alt=\"a\">")
+	f.Fuzz(func(t *testing.T, in string) {
+		doc := ParseHTML(in)
+		if err := Check(doc, Allowed{}); err != nil {
+			t.Fatalf("ParseHTML(%q) is invalid: %v", in, err)
+		}
+		out := RenderHTML(doc)
+		safe(t, in, out)
+		// what RenderHTML writes reads back as the same document
+		if again := RenderHTML(ParseHTML(out)); again != out {
+			t.Fatalf("%q changed through HTML:\n%s\n%s", in, out, again)
+		}
+	})
+}
+
+func FuzzMarkdown(f *testing.F) {
+	for _, s := range xss {
+		f.Add(s)
+	}
+	f.Add("# A *b*\n\n- [x](/x \"t\")\n  > q\n\n1. `c`\n\n```go\nx\n```")
+	f.Fuzz(func(t *testing.T, in string) {
+		doc := ParseMarkdown(in)
+		if err := Check(doc, Allowed{}); err != nil {
+			t.Fatalf("ParseMarkdown(%q) is invalid: %v", in, err)
+		}
+		safe(t, in, RenderHTML(doc))
+	})
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRichText(t *testing.T) {
+	def := []byte(`[
+		{"name": "body", "type": "richtext", "searchable": true},
+		{"name": "notes", "type": ["richtext"], "nodes": [], "marks": ["bold"]}
+	]`)
+	if ok, err := CheckTypes(def, nil); !ok {
+		t.Fatal(err)
+	}
+	fields, _ := ParseFields(def)
+
+	data := map[string]interface{}{
+		"body":  "# Hello\n\n<script>alert(1)</script> **world**",
+		"notes": []interface{}{map[string]interface{}{"html": "<h1>A <em>b</em> <b>c</b></h1>"}},
+	}
+	if err := NormalizeRichText(fields, data); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := CompareSchemaWithData(def, data); !ok {
+		t.Fatal(err)
+	}
+	if got := SearchText(fields, data); got != "Hello\n<script>alert(1)</script> world" {
+		t.Errorf("SearchText = %q", got)
+	}
+
+	FormatRichText(fields, data, "html")
+	if got := data["body"]; got != "<h1>Hello</h1><p>&lt;script&gt;alert(1)&lt;/script&gt; <strong>world</strong></p>" {
+		t.Errorf("body = %v", got)
+	}
+	if got := data["notes"].([]interface{})[0]; got != "<p>A b <strong>c</strong></p>" {
+		t.Errorf("notes = %v", got)
+	}
+
+	bad := map[string]interface{}{"notes": []interface{}{map[string]interface{}{"type": "doc", "content": []interface{}{
+		map[string]interface{}{"type": "horizontalRule"},
+	}}}}
+	if err := NormalizeRichText(fields, bad); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected the rule to be refused, got %v", err)
+	}
+
+	for _, def := range []string{
+		`[{"name": "title", "type": "text", "marks": ["bold"]}]`,
+		`[{"name": "body", "type": "richtext", "nodes": ["table"]}]`,
+	} {
+		if ok, _ := CheckTypes([]byte(def), nil); ok {
+			t.Errorf("%s: expected an error", def)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Rule is a schema-level validation rule.
+//
+//	{ "name": "video_url_required", "when": "type == 'video'", "require": ["video_url"] }
+//	{ "name": "audio_only", "when": "type != 'video'", "hide": ["video_url"] }
+//	{ "name": "date_order", "assert": "end_date > start_date", "message": "end_date must be after start_date" }
+//
+// When is optional and gates the whole rule. Require lists fields that must have a value,
+// Hide lists fields that must stay empty (the admin UI hides them). Assert must evaluate
+// to true and is skipped while any field it mentions is missing.
+type Rule struct {
+	Name    string   `json:"name"`
+	When    string   `json:"when,omitempty"`
+	Require []string `json:"require,omitempty"`
+	Hide    []string `json:"hide,omitempty"`
+	Assert  string   `json:"assert,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// RuleError reports which rule failed and on which fields
+type RuleError struct {
+	Rule    string   `json:"rule"`
+	Fields  []string `json:"fields"`
+	Message string   `json:"message"`
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("rule %q failed on %s: %s", e.Rule, strings.Join(e.Fields, ", "), e.Message)
+}
+
+// ParseRules decodes stored schema rules, an empty value means no rules
+func ParseRules(raw []byte) ([]Rule, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules JSON array: %w", err)
+	}
+	return rules, nil
+}
+
+// CheckRules validates rule syntax and that every referenced field exists
+func CheckRules(raw []byte, fields []Field) error {
+	rules, err := ParseRules(raw)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.Name] = true
+	}
+
+	names := map[string]bool{}
+	for i, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule %d must have a 'name'", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		names[r.Name] = true
+
+		if len(r.Require) == 0 && len(r.Hide) == 0 && r.Assert == "" {
+			return fmt.Errorf("rule %q needs 'require', 'hide' or 'assert'", r.Name)
+		}
+
+		var refs []string
+		refs = append(refs, r.Require...)
+		refs = append(refs, r.Hide...)
+		for _, src := range []string{r.When, r.Assert} {
+			if src == "" {
+				continue
+			}
+			e, err := ParseExpr(src)
+			if err != nil {
+				return fmt.Errorf("rule %q: %q: %w", r.Name, src, err)
+			}
+			refs = append(refs, e.Fields()...)
+		}
+
+		for _, ref := range refs {
+			root, _, _ := strings.Cut(ref, ".")
+			if !known[root] {
+				return fmt.Errorf("rule %q refers to unknown field %q", r.Name, ref)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateRules runs every rule against content data and returns the first failure
+func ValidateRules(raw []byte, data map[string]interface{}) error {
+	rules, err := ParseRules(raw)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		if r.When != "" {
+			when, err := ParseExpr(r.When)
+			if err != nil {
+				return err
+			}
+			active, err := when.Truthy(data)
+			if err != nil {
+				return &RuleError{Rule: r.Name, Fields: when.Fields(), Message: err.Error()}
+			}
+			if !active {
+				continue
+			}
+		}
+
+		var missing []string
+		for _, name := range r.Require {
+			if !hasValue(data[name]) {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return &RuleError{Rule: r.Name, Fields: missing, Message: r.message("is required")}
+		}
+
+		var set []string
+		for _, name := range r.Hide {
+			if hasValue(data[name]) {
+				set = append(set, name)
+			}
+		}
+		if len(set) > 0 {
+			return &RuleError{Rule: r.Name, Fields: set, Message: r.message("must be empty")}
+		}
+
+		if r.Assert != "" {
+			assert, err := ParseExpr(r.Assert)
+			if err != nil {
+				return err
+			}
+			if !allPresent(data, assert.Fields()) {
+				continue
+			}
+			ok, err := assert.Truthy(data)
+			if err != nil {
+				return &RuleError{Rule: r.Name, Fields: assert.Fields(), Message: err.Error()}
+			}
+			if !ok {
+				return &RuleError{Rule: r.Name, Fields: assert.Fields(), Message: r.message("assertion " + r.Assert + " failed")}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r Rule) message(fallback string) string {
+	if r.Message != "" {
+		return r.Message
+	}
+	return fallback
+}
+
+func hasValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case string:
+		return t != ""
+	case []interface{}:
+		return len(t) > 0
+	}
+	return true
+}
+
+func allPresent(data map[string]interface{}, fields []string) bool {
+	for _, f := range fields {
+		if lookup(data, f) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	data := map[string]interface{}{
+		"type":       "video",
+		"views":      float64(10),
+		"start_date": "2025-01-01",
+		"end_date":   "2025-01-02T10:00:00Z",
+		"meta":       map[string]interface{}{"lang": "en"},
+	}
+
+	cases := map[string]bool{
+		"type == 'video'":                      true,
+		"type != 'video'":                      false,
+		"views > 5 && views <= 10":             true,
+		"views < -1 || type in ['a', 'video']": true,
+		"end_date > start_date":                true,
+		"!exists(missing)":                     true,
+		"missing == null":                      true,
+		"meta.lang == \"en\"":                  true,
+		"(views > 100)":                        false,
+	}
+
+	for src, want := range cases {
+		e, err := ParseExpr(src)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		got, err := e.Truthy(data)
+		if err != nil || got != want {
+			t.Errorf("%s: got %v (%v), want %v", src, got, err, want)
+		}
+	}
+
+	for _, bad := range []string{"type ==", "type = 'x'", "'open", "exists('x')", "(a"} {
+		if _, err := ParseExpr(bad); err == nil {
+			t.Errorf("%s: expected parse error", bad)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	fields, _ := ParseFields([]byte(`[
+		{"name":"type","type":"text"},
+		{"name":"video_url","type":"text"},
+		{"name":"start_date","type":"text"},
+		{"name":"end_date","type":"text"}
+	]`))
+	rules := []byte(`[
+		{"name":"video_url_required","when":"type == 'video'","require":["video_url"]},
+		{"name":"no_url","when":"type != 'video'","hide":["video_url"]},
+		{"name":"date_order","assert":"end_date > start_date","message":"end_date must be after start_date"}
+	]`)
+
+	if err := CheckRules(rules, fields); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckRules([]byte(`[{"name":"x","assert":"nope > 1"}]`), fields); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("want unknown field error, got %v", err)
+	}
+
+	cases := []struct {
+		data map[string]interface{}
+		rule string
+	}{
+		{map[string]interface{}{"type": "video", "video_url": "x"}, ""},
+		{map[string]interface{}{"type": "video"}, "video_url_required"},
+		{map[string]interface{}{"type": "text", "video_url": "x"}, "no_url"},
+		{map[string]interface{}{"type": "text", "start_date": "2025-02-01", "end_date": "2025-01-01"}, "date_order"},
+		{map[string]interface{}{"type": "text", "start_date": "2025-02-01"}, ""},
+	}
+
+	for _, tc := range cases {
+		err := ValidateRules(rules, tc.data)
+		var ruleErr *RuleError
+		switch {
+		case tc.rule == "" && err != nil:
+			t.Errorf("%v: unexpected %v", tc.data, err)
+		case tc.rule != "" && (!errors.As(err, &ruleErr) || ruleErr.Rule != tc.rule):
+			t.Errorf("%v: want rule %s to fail, got %v", tc.data, tc.rule, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import "strings"
+
+// SearchText joins the values of searchable fields into the text indexed for full-text search
+func SearchText(fields []Field, data map[string]interface{}) string {
+	var parts []string
+	for _, f := range fields {
+		if !f.Searchable {
+			continue
+		}
+		switch v := data[f.Name].(type) {
+		case string:
+			parts = append(parts, v)
+		case map[string]interface{}:
+			if text, ok := richTextPlain(v); ok {
+				parts = append(parts, text)
+			}
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					parts = append(parts, s)
+				} else if text, ok := richTextPlain(item); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// HasSearchableFields reports whether any field is indexed for search
+func HasSearchableFields(fields []Field) bool {
+	for _, f := range fields {
+		if f.Searchable {
+			return true
+		}
+	}
+	return false
+}
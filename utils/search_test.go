@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestSearchText(t *testing.T) {
+	fields, err := ParseFields([]byte(`[
+		{"name": "title", "type": "text", "searchable": true},
+		{"name": "body", "type": "richtext", "searchable": true},
+		{"name": "tags", "type": ["text"], "searchable": true},
+		{"name": "slug", "type": "text"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := SearchText(fields, map[string]interface{}{
+		"title": "Kubernetes in production",
+		"tags":  []interface{}{"k8s", "ops"},
+		"slug":  "kubernetes-in-production",
+	})
+	if want := "Kubernetes in production\nk8s\nops"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := CheckTypes([]byte(`[{"name": "views", "type": "number", "searchable": true}]`), nil); err == nil {
+		t.Error("expected error for searchable number field")
+	}
+}
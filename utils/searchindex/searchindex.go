@@ -0,0 +1,81 @@
+// Package searchindex keeps the full-text search documents of entries up to
+// date. The content API and the background jobs both write through it.
+package searchindex
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	db "github.com/manthan307/nota-cms/db/output"
+	"github.com/manthan307/nota-cms/utils"
+)
+
+// Index rebuilds the search documents of an entry, one for the default
+// locale and one per published translation with the translation laid over the
+// base data. Unpublished translations lose their document.
+func Index(ctx context.Context, queries *db.Queries, content db.Content, fields []utils.Field, defaultLocale string) error {
+	if !utils.HasSearchableFields(fields) {
+		return queries.DeleteSearchDocuments(ctx, content.ID)
+	}
+
+	base := decode(content.Data)
+	if err := document(ctx, queries, content.ID, defaultLocale, utils.SearchText(fields, base)); err != nil {
+		return err
+	}
+
+	rows, err := queries.GetContentLocales(ctx, content.ID)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if row.Locale == defaultLocale {
+			continue
+		}
+		if !row.Published {
+			if err := queries.DeleteSearchDocument(ctx, db.DeleteSearchDocumentParams{ContentID: content.ID, Locale: row.Locale}); err != nil {
+				return err
+			}
+			continue
+		}
+		merged := make(map[string]interface{}, len(base))
+		for k, v := range base {
+			merged[k] = v
+		}
+		for k, v := range decode(row.Data) {
+			merged[k] = v
+		}
+		if err := document(ctx, queries, content.ID, row.Locale, utils.SearchText(fields, merged)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reindex is Index for callers that don't have the schema and default locale
+// at hand, such as background jobs
+func Reindex(ctx context.Context, queries *db.Queries, content db.Content) error {
+	schema, err := queries.GetSchemaByID(ctx, uuid.UUID(content.SchemaID.Bytes))
+	if err != nil {
+		return err
+	}
+	def, err := queries.GetDefaultLocale(ctx)
+	if err != nil {
+		return err
+	}
+	fields, _ := utils.ParseFields(schema.Definition)
+	return Index(ctx, queries, content, fields, def.Code)
+}
+
+func document(ctx context.Context, queries *db.Queries, contentID uuid.UUID, locale, body string) error {
+	if body == "" {
+		return queries.DeleteSearchDocument(ctx, db.DeleteSearchDocumentParams{ContentID: contentID, Locale: locale})
+	}
+	return queries.UpsertSearchDocument(ctx, db.UpsertSearchDocumentParams{ContentID: contentID, Body: body, Locale: locale})
+}
+
+func decode(raw []byte) map[string]interface{} {
+	data := map[string]interface{}{}
+	_ = json.Unmarshal(raw, &data)
+	return data
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SchemaSettings holds per-schema behaviour that is not part of the field definition
+type SchemaSettings struct {
+	Revisions RevisionPolicy  `json:"revisions"`
+	Cache     CachePolicy     `json:"cache"`
+	Workflow  *WorkflowPolicy `json:"workflow,omitempty"` // nil when entries publish freely
+}
+
+// RevisionPolicy decides which old content revisions are pruned. Zero keeps everything,
+// the current revision is always kept.
+type RevisionPolicy struct {
+	MaxCount   int `json:"maxCount,omitempty"`   // keep at most this many revisions per entry
+	MaxAgeDays int `json:"maxAgeDays,omitempty"` // drop revisions older than this
+}
+
+// CachePolicy sets the caching headers of public content reads
+type CachePolicy struct {
+	CacheControl  string   `json:"cacheControl,omitempty"`  // sent as is, e.g. "public, max-age=60"
+	SurrogateKeys []string `json:"surrogateKeys,omitempty"` // extra CDN purge tags
+}
+
+// ParseSettings decodes and validates stored schema settings, unknown keys are rejected
+func ParseSettings(raw []byte) (SchemaSettings, error) {
+	var s SchemaSettings
+	if len(raw) == 0 {
+		return s, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return s, fmt.Errorf("invalid settings: %w", err)
+	}
+	if s.Revisions.MaxCount < 0 || s.Revisions.MaxAgeDays < 0 {
+		return s, fmt.Errorf("invalid settings: revision limits must not be negative")
+	}
+	if strings.ContainsFunc(s.Cache.CacheControl, unicode.IsControl) {
+		return s, fmt.Errorf("invalid settings: cacheControl must be a single header value")
+	}
+	for _, key := range s.Cache.SurrogateKeys {
+		if key == "" || strings.ContainsFunc(key, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) {
+			return s, fmt.Errorf("invalid settings: surrogate key %q must be a non-empty word", key)
+		}
+	}
+	if s.Workflow != nil {
+		if err := s.Workflow.validate(); err != nil {
+			return s, fmt.Errorf("invalid settings: %w", err)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings([]byte(`{"revisions":{"maxCount":5},"cache":{"cacheControl":"public, max-age=60","surrogateKeys":["blog"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Revisions.MaxCount != 5 || s.Cache.CacheControl != "public, max-age=60" || s.Cache.SurrogateKeys[0] != "blog" {
+		t.Errorf("unexpected settings %+v", s)
+	}
+
+	for _, raw := range []string{
+		`{"unknown":1}`,
+		`{"revisions":{"maxAgeDays":-1}}`,
+		`{"cache":{"cacheControl":"public\r\nSet-Cookie: x"}}`,
+		`{"cache":{"surrogateKeys":["two words"]}}`,
+		`{"cache":{"surrogateKeys":[""]}}`,
+	} {
+		if _, err := ParseSettings([]byte(raw)); err == nil {
+			t.Errorf("%s: expected error", raw)
+		}
+	}
+}
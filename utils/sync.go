@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyncToken marks how far a client has synced a schema: the last change it
+// got, ordered by (change_xid, id), and when the token was handed out
+type SyncToken struct {
+	Schema uuid.UUID `json:"s"`
+	Xid    uint64    `json:"x"`
+	ID     uuid.UUID `json:"i"`
+	Issued time.Time `json:"at"`
+}
+
+func (t SyncToken) Encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseSyncToken decodes a token of the given schema
+func ParseSyncToken(s string, schema uuid.UUID) (SyncToken, error) {
+	var t SyncToken
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &t)
+	}
+	if err != nil || t.Issued.IsZero() {
+		return t, fmt.Errorf("invalid sync token")
+	}
+	if t.Schema != schema {
+		return t, fmt.Errorf("sync token belongs to another schema")
+	}
+	return t, nil
+}
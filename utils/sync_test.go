@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSyncToken(t *testing.T) {
+	schema := uuid.New()
+	token := SyncToken{Schema: schema, Xid: 1 << 40, ID: uuid.New(), Issued: time.Now().UTC()}
+
+	got, err := ParseSyncToken(token.Encode(), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Xid != token.Xid || got.ID != token.ID || !got.Issued.Equal(token.Issued) {
+		t.Errorf("round trip changed the token: %+v, want %+v", got, token)
+	}
+
+	if _, err := ParseSyncToken(token.Encode(), uuid.New()); err == nil {
+		t.Error("expected a token of another schema to be rejected")
+	}
+	for _, bad := range []string{"", "not a token", "e30"} {
+		if _, err := ParseSyncToken(bad, schema); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
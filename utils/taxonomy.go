@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"regexp"
+	"slices"
+)
+
+// Slug matches the slugs of taxonomy terms, like "web-development"
+var Slug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// TaxonomyTerms collects the term slugs of data's taxonomy fields, by taxonomy.
+// Values that are not strings are left for the type check to report.
+func TaxonomyTerms(fields []Field, data map[string]interface{}) map[string][]string {
+	terms := map[string][]string{}
+	add := func(taxonomy string, v interface{}) {
+		if s, ok := v.(string); ok && !slices.Contains(terms[taxonomy], s) {
+			terms[taxonomy] = append(terms[taxonomy], s)
+		}
+	}
+	for _, f := range fields {
+		if elem, _ := f.ElemType(); elem != "taxonomy" {
+			continue
+		}
+		switch v := data[f.Name].(type) {
+		case []interface{}:
+			for _, item := range v {
+				add(f.Taxonomy, item)
+			}
+		default:
+			add(f.Taxonomy, v)
+		}
+	}
+	return terms
+}
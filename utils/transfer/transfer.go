@@ -0,0 +1,441 @@
+// Package transfer reads and writes content entries as CSV, NDJSON or JSON
+// for exports and imports. CSV columns are derived from the schema definition.
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/manthan307/nota-cms/utils"
+)
+
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+	JSON   = "json"
+)
+
+// Record is one entry of a file. Published is nil when the file leaves it out.
+type Record struct {
+	ID        string                 `json:"id,omitempty"`
+	Published *bool                  `json:"published,omitempty"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Row is a record as read, with what is needed to report it back.
+// Err is set when the row could not be decoded.
+type Row struct {
+	Number int
+	Record Record
+	Cells  []string        // CSV cells
+	Raw    json.RawMessage // NDJSON line or JSON array element
+	Err    error
+}
+
+// ValidFormat reports whether format is csv, ndjson or json
+func ValidFormat(format string) bool {
+	return format == CSV || format == NDJSON || format == JSON
+}
+
+// ContentType is the media type of a file in format
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/json"
+}
+
+// Columns are the CSV header of a schema: id, published, then every field
+func Columns(fields []utils.Field) []string {
+	cols := []string{"id", "published"}
+	for _, f := range fields {
+		cols = append(cols, f.Name)
+	}
+	return cols
+}
+
+// Writer writes records in one of the formats
+type Writer interface {
+	Write(Record) error
+	// Close finishes the file, it does not close the underlying writer
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer, fields []utils.Field) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w), fields: fields}, nil
+	case NDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case JSON:
+		return &jsonWriter{w: w, enc: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	fields []utils.Field
+	header bool
+}
+
+func (cw *csvWriter) writeHeader() error {
+	if cw.header {
+		return nil
+	}
+	cw.header = true
+	return cw.w.Write(Columns(cw.fields))
+}
+
+func (cw *csvWriter) Write(r Record) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	published := ""
+	if r.Published != nil {
+		published = strconv.FormatBool(*r.Published)
+	}
+	cells := []string{r.ID, published}
+	for _, f := range cw.fields {
+		cell, err := encodeCell(r.Data[f.Name])
+		if err != nil {
+			return fmt.Errorf("field %q: %w", f.Name, err)
+		}
+		cells = append(cells, cell)
+	}
+	return cw.w.Write(cells)
+}
+
+func (cw *csvWriter) Close() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) Write(r Record) error { return nw.enc.Encode(r) }
+func (nw *ndjsonWriter) Close() error         { return nil }
+
+type jsonWriter struct {
+	w     io.Writer
+	enc   *json.Encoder
+	count int
+}
+
+func (jw *jsonWriter) Write(r Record) error {
+	sep := ","
+	if jw.count == 0 {
+		sep = "["
+	}
+	jw.count++
+	if _, err := io.WriteString(jw.w, sep); err != nil {
+		return err
+	}
+	return jw.enc.Encode(r)
+}
+
+func (jw *jsonWriter) Close() error {
+	end := "]\n"
+	if jw.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(jw.w, end)
+	return err
+}
+
+// encodeCell writes scalars as text and arrays and objects as JSON
+func encodeCell(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// Reader reads the rows of a file. Next returns io.EOF after the last row,
+// other errors mean the file itself is broken.
+type Reader interface {
+	Next() (Row, error)
+	// Header is the CSV header, nil for the JSON formats
+	Header() []string
+}
+
+// maxLine bounds a single NDJSON line
+const maxLine = 16 * 1024 * 1024
+
+func NewReader(format string, r io.Reader, fields []utils.Field) (Reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r, fields)
+	case NDJSON:
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), maxLine)
+		return &ndjsonReader{s: s}, nil
+	case JSON:
+		dec := json.NewDecoder(r)
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, errors.New("JSON imports must be an array of records")
+		}
+		return &jsonReader{dec: dec}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+	fields []*utils.Field // per column, nil for id and published
+	number int
+}
+
+func newCSVReader(r io.Reader, fields []utils.Field) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	// Spreadsheets like to start UTF-8 files with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	byName := make(map[string]*utils.Field, len(fields))
+	for i := range fields {
+		byName[fields[i].Name] = &fields[i]
+	}
+	seen := map[string]bool{}
+	cols := make([]*utils.Field, len(header))
+	for i, name := range header {
+		if seen[name] {
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		seen[name] = true
+		if name == "id" || name == "published" {
+			continue
+		}
+		f, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("column %q is not a field of the schema", name)
+		}
+		cols[i] = f
+	}
+	return &csvReader{r: cr, header: header, fields: cols}, nil
+}
+
+func (cr *csvReader) Header() []string { return cr.header }
+
+func (cr *csvReader) Next() (Row, error) {
+	cells, err := cr.r.Read()
+	if errors.Is(err, io.EOF) {
+		return Row{}, io.EOF
+	}
+	cr.number++
+	row := Row{Number: cr.number, Cells: cells}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			row.Err = err
+			return row, nil
+		}
+		return row, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(cells) != len(cr.header) {
+		row.Err = fmt.Errorf("expected %d columns, got %d", len(cr.header), len(cells))
+		return row, nil
+	}
+
+	row.Record.Data = map[string]interface{}{}
+	for i, cell := range cells {
+		switch cr.header[i] {
+		case "id":
+			row.Record.ID = cell
+			continue
+		case "published":
+			if cell == "" {
+				continue
+			}
+			b, err := strconv.ParseBool(cell)
+			if err != nil {
+				row.Err = fmt.Errorf("column %q: expected true or false", "published")
+				return row, nil
+			}
+			row.Record.Published = &b
+			continue
+		}
+		// Empty cells leave the field out
+		if cell == "" {
+			continue
+		}
+		v, err := decodeCell(*cr.fields[i], cell)
+		if err != nil {
+			row.Err = fmt.Errorf("column %q: %w", cr.header[i], err)
+			return row, nil
+		}
+		row.Record.Data[cr.header[i]] = v
+	}
+	return row, nil
+}
+
+// decodeCell turns a CSV cell back into the JSON value of the field's type
+func decodeCell(f utils.Field, cell string) (interface{}, error) {
+	elem, isArray := f.ElemType()
+	if isArray || elem == "json" {
+		var v interface{}
+		if err := json.Unmarshal([]byte(cell), &v); err != nil {
+			return nil, errors.New("expected JSON")
+		}
+		return v, nil
+	}
+	switch elem {
+	case "number":
+		n, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return nil, errors.New("expected a number")
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return nil, errors.New("expected true or false")
+		}
+		return b, nil
+	}
+	return cell, nil
+}
+
+type ndjsonReader struct {
+	s      *bufio.Scanner
+	number int
+}
+
+func (nr *ndjsonReader) Header() []string { return nil }
+
+func (nr *ndjsonReader) Next() (Row, error) {
+	for nr.s.Scan() {
+		line := nr.s.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		nr.number++
+		raw := append(json.RawMessage(nil), line...)
+		return decodeRow(nr.number, raw), nil
+	}
+	if err := nr.s.Err(); err != nil {
+		return Row{}, fmt.Errorf("invalid NDJSON: %w", err)
+	}
+	return Row{}, io.EOF
+}
+
+type jsonReader struct {
+	dec    *json.Decoder
+	number int
+}
+
+func (jr *jsonReader) Header() []string { return nil }
+
+func (jr *jsonReader) Next() (Row, error) {
+	if !jr.dec.More() {
+		return Row{}, io.EOF
+	}
+	var raw json.RawMessage
+	if err := jr.dec.Decode(&raw); err != nil {
+		return Row{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	jr.number++
+	return decodeRow(jr.number, raw), nil
+}
+
+func decodeRow(number int, raw json.RawMessage) Row {
+	row := Row{Number: number, Raw: raw}
+	if err := json.Unmarshal(raw, &row.Record); err != nil {
+		row.Err = fmt.Errorf("invalid record: %w", err)
+		return row
+	}
+	if row.Record.Data == nil {
+		row.Err = errors.New(`record has no "data"`)
+	}
+	return row
+}
+
+// Report lists rejected rows so they can be fixed and imported again.
+// CSV reports repeat the original columns after row and error, the JSON
+// formats are reported as NDJSON lines of { row, error, record }.
+type Report struct {
+	format string
+	csv    *csv.Writer
+	enc    *json.Encoder
+	header []string
+}
+
+func NewReport(format string, w io.Writer, header []string) *Report {
+	if format == CSV {
+		return &Report{format: format, csv: csv.NewWriter(w), header: header}
+	}
+	return &Report{format: format, enc: json.NewEncoder(w)}
+}
+
+// ContentType is the media type of the report
+func (r *Report) ContentType() string {
+	if r.format == CSV {
+		return ContentType(CSV)
+	}
+	return ContentType(NDJSON)
+}
+
+func (r *Report) Add(row Row, message string) error {
+	if r.csv != nil {
+		if r.header != nil {
+			if err := r.csv.Write(append([]string{"row", "error"}, r.header...)); err != nil {
+				return err
+			}
+			r.header = nil
+		}
+		return r.csv.Write(append([]string{strconv.Itoa(row.Number), message}, row.Cells...))
+	}
+	// Lines that are not JSON at all are reported as text
+	var record interface{} = row.Raw
+	if !json.Valid(row.Raw) {
+		record = string(row.Raw)
+	}
+	return r.enc.Encode(struct {
+		Row    int         `json:"row"`
+		Error  string      `json:"error"`
+		Record interface{} `json:"record"`
+	}{row.Number, message, record})
+}
+
+func (r *Report) Close() error {
+	if r.csv == nil {
+		return nil
+	}
+	r.csv.Flush()
+	return r.csv.Error()
+}
@@ -0,0 +1,122 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/manthan307/nota-cms/utils"
+)
+
+var fields = []utils.Field{
+	{Name: "title", Type: "text"},
+	{Name: "price", Type: "number"},
+	{Name: "active", Type: "boolean"},
+	{Name: "tags", Type: []interface{}{"text"}},
+	{Name: "meta", Type: "json"},
+}
+
+func records() []Record {
+	published := true
+	return []Record{
+		{ID: "a", Published: &published, Data: map[string]interface{}{
+			"title":  "Hello, \"world\"",
+			"price":  9.5,
+			"active": true,
+			"tags":   []interface{}{"x", "y"},
+			"meta":   map[string]interface{}{"k": "v"},
+		}},
+		{ID: "b", Data: map[string]interface{}{"title": "Only a title"}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{CSV, NDJSON, JSON} {
+		var buf bytes.Buffer
+		w, err := NewWriter(format, &buf, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range records() {
+			if err := w.Write(r); err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewReader(format, &buf, fields)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		var got []Record
+		for {
+			row, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil || row.Err != nil {
+				t.Fatalf("%s: %v %v", format, err, row.Err)
+			}
+			got = append(got, row.Record)
+		}
+		if !reflect.DeepEqual(got, records()) {
+			t.Errorf("%s: got %#v", format, got)
+		}
+	}
+}
+
+func TestCSVRowErrors(t *testing.T) {
+	in := "\ufeffid,title,price,active\n" +
+		"1,ok,1,true\n" +
+		"2,bad,ten,true\n" +
+		"3,bad,1,maybe\n" +
+		"4,short\n"
+	r, err := NewReader(CSV, strings.NewReader(in), fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failed []int
+	for {
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.Err != nil {
+			failed = append(failed, row.Number)
+		}
+	}
+	if !reflect.DeepEqual(failed, []int{2, 3, 4}) {
+		t.Errorf("failed rows %v", failed)
+	}
+
+	if _, err := NewReader(CSV, strings.NewReader("id,nope\n"), fields); err == nil {
+		t.Error("expected unknown column error")
+	}
+}
+
+func TestReport(t *testing.T) {
+	var buf bytes.Buffer
+	report := NewReport(CSV, &buf, []string{"id", "title"})
+	_ = report.Add(Row{Number: 2, Cells: []string{"x", "Hi"}}, "missing required field")
+	_ = report.Close()
+	want := "row,error,id,title\n2,missing required field,x,Hi\n"
+	if buf.String() != want {
+		t.Errorf("csv report %q", buf.String())
+	}
+
+	buf.Reset()
+	report = NewReport(NDJSON, &buf, nil)
+	_ = report.Add(Row{Number: 1, Raw: []byte("not json")}, "invalid record")
+	if buf.String() != `{"row":1,"error":"invalid record","record":"not json"}`+"\n" {
+		t.Errorf("ndjson report %q", buf.String())
+	}
+}
@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"slices"
+
+	"github.com/google/uuid"
 )
 
 var Types = []string{
@@ -16,16 +20,169 @@ var Types = []string{
 	"image",
 	"video",
 	"richtext",
+	"reference",
+	"enum",
+	"taxonomy",
 }
 
 type Field struct {
 	Name       string      `json:"name"`
 	Type       interface{} `json:"type"` // can be string or []string
 	IsRequired bool        `json:"isRequired"`
+	Ref        string      `json:"ref,omitempty"`        // target schema name for "reference" fields
+	Options    []string    `json:"options,omitempty"`    // allowed values for "enum" fields
+	Taxonomy   string      `json:"taxonomy,omitempty"`   // taxonomy name for "taxonomy" fields
+	Localized  bool        `json:"localized,omitempty"`  // value differs per locale
+	Searchable bool        `json:"searchable,omitempty"` // indexed for full-text search
+	Unique     bool        `json:"unique,omitempty"`     // no two entries of the schema share a value
+	Nodes      []string    `json:"nodes,omitempty"`      // nodes allowed in "richtext" fields, all when unset
+	Marks      []string    `json:"marks,omitempty"`      // marks allowed in "richtext" fields, all when unset
+	UI         *FieldUI    `json:"ui,omitempty"`         // admin UI presentation only, ignored by validation
+}
+
+// Widgets the admin UI knows how to render, with the field types they fit
+var Widgets = map[string][]string{
+	"input":    {"text", "number"},
+	"textarea": {"text", "richtext"},
+	"markdown": {"text", "richtext"},
+	"color":    {"text"},
+	"select":   {"enum"},
+}
+
+// FieldUI holds presentation metadata for the admin UI
+type FieldUI struct {
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+	Placeholder string `json:"placeholder,omitempty"`
+	Widget      string `json:"widget,omitempty"`
+	Order       int    `json:"order,omitempty"`
+	Tab         string `json:"tab,omitempty"`
+	Fieldset    string `json:"fieldset,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+	ReadOnly    bool   `json:"readOnly,omitempty"`
+}
+
+// UnmarshalJSON rejects unknown keys so typos in metadata are reported
+func (u *FieldUI) UnmarshalJSON(data []byte) error {
+	type plain FieldUI
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*plain)(u))
+}
+
+// checkUI validates the shape of a field's presentation metadata
+func checkUI(f Field) error {
+	if f.UI == nil {
+		return nil
+	}
+	if f.UI.Widget != "" {
+		fits, ok := Widgets[f.UI.Widget]
+		if !ok {
+			return fmt.Errorf("field %q: unknown widget %q", f.Name, f.UI.Widget)
+		}
+		elem, _ := f.ElemType()
+		if !slices.Contains(fits, elem) {
+			return fmt.Errorf("field %q: widget %q does not fit type %q", f.Name, f.UI.Widget, elem)
+		}
+	}
+	if f.UI.Order < 0 {
+		return fmt.Errorf("field %q: ui order must not be negative", f.Name)
+	}
+	return nil
+}
+
+// LayoutGroup is a tab or fieldset of the admin form
+type LayoutGroup struct {
+	Name      string        `json:"name"`
+	Fields    []string      `json:"fields,omitempty"`
+	Fieldsets []LayoutGroup `json:"fieldsets,omitempty"`
+}
+
+// Layout groups fields into tabs and fieldsets, sorted by ui.order then definition order.
+// Fields without a tab land in a tab with an empty name.
+func Layout(fields []Field) []LayoutGroup {
+	sorted := slices.Clone(fields)
+	slices.SortStableFunc(sorted, func(a, b Field) int {
+		return a.uiOrder() - b.uiOrder()
+	})
+
+	var tabs []LayoutGroup
+	tabIndex := map[string]int{}
+	for _, f := range sorted {
+		var tab, fieldset string
+		if f.UI != nil {
+			tab, fieldset = f.UI.Tab, f.UI.Fieldset
+		}
+
+		i, ok := tabIndex[tab]
+		if !ok {
+			i = len(tabs)
+			tabIndex[tab] = i
+			tabs = append(tabs, LayoutGroup{Name: tab})
+		}
+
+		if fieldset == "" {
+			tabs[i].Fields = append(tabs[i].Fields, f.Name)
+			continue
+		}
+		j := slices.IndexFunc(tabs[i].Fieldsets, func(g LayoutGroup) bool { return g.Name == fieldset })
+		if j < 0 {
+			j = len(tabs[i].Fieldsets)
+			tabs[i].Fieldsets = append(tabs[i].Fieldsets, LayoutGroup{Name: fieldset})
+		}
+		tabs[i].Fieldsets[j].Fields = append(tabs[i].Fieldsets[j].Fields, f.Name)
+	}
+
+	return tabs
+}
+
+func (f Field) uiOrder() int {
+	if f.UI == nil {
+		return 0
+	}
+	return f.UI.Order
+}
+
+// ElemType returns the primitive type of a field and whether it is an array.
+func (f Field) ElemType() (string, bool) {
+	switch t := f.Type.(type) {
+	case string:
+		return t, false
+	case []interface{}:
+		if len(t) == 0 {
+			return "", true
+		}
+		s, _ := t[0].(string)
+		return s, true
+	}
+	return "", false
+}
+
+// ParseFields decodes a stored schema definition.
+func ParseFields(schemaDef []byte) ([]Field, error) {
+	var fields []Field
+	if err := json.Unmarshal(schemaDef, &fields); err != nil {
+		return nil, fmt.Errorf("invalid schema JSON array: %w", err)
+	}
+	return fields, nil
+}
+
+// Field types whose values can be declared unique
+var uniqueTypes = map[string]bool{"text": true, "number": true, "date": true, "enum": true, "reference": true}
+
+// UniqueFields names the fields declared unique
+func UniqueFields(fields []Field) []string {
+	var names []string
+	for _, f := range fields {
+		if f.Unique {
+			names = append(names, f.Name)
+		}
+	}
+	return names
 }
 
-// Validate schema definition syntax
-func CheckTypes(data []byte) (bool, error) {
+// Validate schema definition syntax and the schema's rules
+func CheckTypes(data []byte, rules []byte) (bool, error) {
 	var fields []Field
 	if err := json.Unmarshal(data, &fields); err != nil {
 		return false, fmt.Errorf("invalid JSON array: %w", err)
@@ -67,6 +224,50 @@ func CheckTypes(data []byte) (bool, error) {
 		default:
 			return false, fmt.Errorf("field %q: type must be string or array", f.Name)
 		}
+
+		elem, _ := f.ElemType()
+		switch elem {
+		case "reference":
+			if f.Ref == "" {
+				return false, fmt.Errorf("field %q: reference fields need a 'ref'", f.Name)
+			}
+		case "enum":
+			if len(f.Options) == 0 {
+				return false, fmt.Errorf("field %q: enum fields need 'options'", f.Name)
+			}
+		case "taxonomy":
+			if f.Taxonomy == "" {
+				return false, fmt.Errorf("field %q: taxonomy fields need a 'taxonomy'", f.Name)
+			}
+			if f.Localized {
+				return false, fmt.Errorf("field %q: taxonomy fields cannot be localized, terms are shared by every locale", f.Name)
+			}
+		}
+		if f.Taxonomy != "" && elem != "taxonomy" {
+			return false, fmt.Errorf("field %q: only taxonomy fields have a 'taxonomy'", f.Name)
+		}
+
+		if f.Searchable && elem != "text" && elem != "richtext" {
+			return false, fmt.Errorf("field %q: only text and richtext fields can be searchable", f.Name)
+		}
+
+		if f.Unique {
+			if _, isArray := f.ElemType(); isArray || f.Localized || !uniqueTypes[elem] {
+				return false, fmt.Errorf("field %q: only single, non-localized text, number, date, enum and reference fields can be unique", f.Name)
+			}
+		}
+
+		if err := checkRichText(f, elem); err != nil {
+			return false, err
+		}
+
+		if err := checkUI(f); err != nil {
+			return false, err
+		}
+	}
+
+	if err := CheckRules(rules, fields); err != nil {
+		return false, err
 	}
 
 	return true, nil
@@ -74,9 +275,9 @@ func CheckTypes(data []byte) (bool, error) {
 
 // Compare schema definition with actual data
 func CompareSchemaWithData(schemaDef []byte, data map[string]interface{}) (bool, error) {
-	var fields []Field
-	if err := json.Unmarshal(schemaDef, &fields); err != nil {
-		return false, fmt.Errorf("invalid schema JSON array: %w", err)
+	fields, err := ParseFields(schemaDef)
+	if err != nil {
+		return false, err
 	}
 
 	fieldMap := make(map[string]Field)
@@ -94,7 +295,7 @@ func CompareSchemaWithData(schemaDef []byte, data map[string]interface{}) (bool,
 			continue
 		}
 
-		if err := matchType(f.Type, val); err != nil {
+		if err := matchType(f, val); err != nil {
 			return false, fmt.Errorf("field %q: %w", f.Name, err)
 		}
 	}
@@ -109,12 +310,13 @@ func CompareSchemaWithData(schemaDef []byte, data map[string]interface{}) (bool,
 }
 
 // Match single field's type
-func matchType(schemaVal interface{}, value interface{}) error {
-	switch t := schemaVal.(type) {
+func matchType(f Field, value interface{}) error {
+	switch t := f.Type.(type) {
 	case string:
 		if !isPrimitiveTypeMatching(t, value) {
 			return fmt.Errorf("expected %s, got %T", t, value)
 		}
+		return matchOptions(f, value)
 	case []interface{}:
 		arr, ok := value.([]interface{})
 		if !ok {
@@ -131,6 +333,9 @@ func matchType(schemaVal interface{}, value interface{}) error {
 			if !isPrimitiveTypeMatching(elemTypeStr, item) {
 				return fmt.Errorf("element %d: expected %s, got %T", i, elemTypeStr, item)
 			}
+			if err := matchOptions(f, item); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
 		}
 	default:
 		return fmt.Errorf("unsupported schema type %T", t)
@@ -138,15 +343,50 @@ func matchType(schemaVal interface{}, value interface{}) error {
 	return nil
 }
 
+// Check enum values against the field's allowed options
+func matchOptions(f Field, value interface{}) error {
+	if elem, _ := f.ElemType(); elem != "enum" {
+		return nil
+	}
+	s := value.(string)
+	for _, o := range f.Options {
+		if o == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %v", s, f.Options)
+}
+
 // Type matching logic
 func isPrimitiveTypeMatching(expectedType string, value interface{}) bool {
 	switch expectedType {
-	case "text", "string", "richtext":
+	case "text", "string", "enum", "taxonomy":
 		_, ok := value.(string)
 		return ok
+	case "richtext":
+		// documents, or strings stored before rich text was structured
+		switch value.(type) {
+		case string, map[string]interface{}:
+			return true
+		}
+		return false
+	case "reference":
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := uuid.Parse(str)
+		return err == nil
 	case "number":
 		_, ok := value.(float64)
 		return ok
+	case "date":
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, ok = ParseDate(str)
+		return ok
 	case "boolean":
 		_, ok := value.(bool)
 		return ok
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckTypesUI(t *testing.T) {
+	cases := []struct {
+		def string
+		err string
+	}{
+		{`[{"name":"title","type":"text","ui":{"label":"Title","widget":"textarea","tab":"Main"}}]`, ""},
+		{`[{"name":"status","type":"enum","options":["a","b"],"ui":{"widget":"select"}}]`, ""},
+		{`[{"name":"title","type":"text","ui":{"widget":"slider"}}]`, "unknown widget"},
+		{`[{"name":"views","type":"number","ui":{"widget":"color"}}]`, "does not fit"},
+		{`[{"name":"title","type":"text","ui":{"lable":"Title"}}]`, "unknown field"},
+		{`[{"name":"title","type":"text","ui":{"hidden":"yes"}}]`, "cannot unmarshal"},
+	}
+
+	for _, tc := range cases {
+		ok, err := CheckTypes([]byte(tc.def), nil)
+		if tc.err == "" {
+			if !ok {
+				t.Errorf("%s: unexpected error %v", tc.def, err)
+			}
+			continue
+		}
+		if ok || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: want error containing %q, got %v", tc.def, tc.err, err)
+		}
+	}
+}
+
+func TestCheckTypesUnique(t *testing.T) {
+	cases := map[string]bool{
+		`[{"name":"slug","type":"text","unique":true}]`:                  true,
+		`[{"name":"sku","type":"number","unique":true}]`:                 true,
+		`[{"name":"tags","type":["text"],"unique":true}]`:                false,
+		`[{"name":"slug","type":"text","localized":true,"unique":true}]`: false,
+		`[{"name":"meta","type":"json","unique":true}]`:                  false,
+	}
+	for def, want := range cases {
+		if ok, err := CheckTypes([]byte(def), nil); ok != want {
+			t.Errorf("%s: got %v (%v)", def, ok, err)
+		}
+	}
+}
+
+func TestCheckTypesTaxonomy(t *testing.T) {
+	cases := map[string]bool{
+		`[{"name":"category","type":"taxonomy","taxonomy":"categories"}]`:          true,
+		`[{"name":"tags","type":["taxonomy"],"taxonomy":"tags"}]`:                  true,
+		`[{"name":"tags","type":["taxonomy"]}]`:                                    false,
+		`[{"name":"tags","type":["taxonomy"],"taxonomy":"tags","localized":true}]`: false,
+		`[{"name":"title","type":"text","taxonomy":"tags"}]`:                       false,
+	}
+	for def, want := range cases {
+		if ok, err := CheckTypes([]byte(def), nil); ok != want {
+			t.Errorf("%s: got %v (%v)", def, ok, err)
+		}
+	}
+
+	fields, _ := ParseFields([]byte(`[{"name":"a","type":"taxonomy","taxonomy":"x"},{"name":"b","type":["taxonomy"],"taxonomy":"x"}]`))
+	terms := TaxonomyTerms(fields, map[string]interface{}{"a": "go", "b": []interface{}{"go", "rust", 3.0}})
+	if len(terms) != 1 || len(terms["x"]) != 2 {
+		t.Errorf("TaxonomyTerms = %v", terms)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	fields, err := ParseFields([]byte(`[
+		{"name":"title","type":"text","ui":{"tab":"Content","order":2}},
+		{"name":"body","type":"richtext","ui":{"tab":"Content","order":1}},
+		{"name":"slug","type":"text","ui":{"tab":"SEO","fieldset":"URL"}},
+		{"name":"views","type":"number"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layout := Layout(fields)
+	if len(layout) != 3 {
+		t.Fatalf("want 3 tabs, got %+v", layout)
+	}
+	// unordered fields (order 0) come first
+	if layout[0].Name != "SEO" || layout[0].Fieldsets[0].Fields[0] != "slug" {
+		t.Errorf("unexpected first tab %+v", layout[0])
+	}
+	if got := strings.Join(layout[2].Fields, ","); layout[2].Name != "Content" || got != "body,title" {
+		t.Errorf("unexpected content tab %+v", layout[2])
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"strconv"
+)
+
+// WebhookEvents lists what webhooks can subscribe to. The events are raised
+// by database triggers, see 0013_webhooks.up.sql.
+var WebhookEvents = []string{
+	"content.created",
+	"content.updated",
+	"content.published",
+	"content.unpublished",
+	"content.deleted",
+	"content.restored",
+	"schema.created",
+	"schema.updated",
+	"schema.deleted",
+	"schema.restored",
+	"media.uploaded",
+	"media.updated",
+	"media.deleted",
+}
+
+// SignWebhook computes the signature header of a delivery: HMAC-SHA256 with
+// the webhook's secret over "<timestamp>.<body>", hex encoded
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookSecret returns a random secret for webhooks created without one
+func NewWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// shared, benchmarking and reserved ranges the netip checks leave out
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// PublicAddr reports whether an address is reachable on the internet, so a
+// webhook can't be pointed at the server itself or its private network
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
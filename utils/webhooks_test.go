@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestSignWebhook(t *testing.T) {
+	got := SignWebhook("topsecret", 1700000000, []byte(`{"event":"content.created"}`))
+	want := "sha256=a8af32c277c766b4afcacbd3af4c3f45c76a6a4bdedcbf17367fc21cdd130bed"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for _, s := range []string{"93.184.216.34", "2606:4700::1111", "8.8.8.8"} {
+		if !PublicAddr(netip.MustParseAddr(s)) {
+			t.Errorf("expected %s to be public", s)
+		}
+	}
+	for _, s := range []string{
+		"127.0.0.1", "::1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "fe80::1",
+		"fd00::1", "0.0.0.0", "::", "100.64.0.1", "224.0.0.1", "::ffff:127.0.0.1", "::ffff:169.254.169.254",
+	} {
+		if PublicAddr(netip.MustParseAddr(s)) {
+			t.Errorf("expected %s to be refused", s)
+		}
+	}
+}
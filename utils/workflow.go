@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// WorkflowPolicy is a per-schema editorial workflow. Entries move between
+// states through the listed transitions and can only be published from one
+// of the PublishFrom states.
+type WorkflowPolicy struct {
+	States      []string             `json:"states"`
+	Initial     string               `json:"initial,omitempty"` // defaults to the first state
+	Transitions []WorkflowTransition `json:"transitions"`
+	PublishFrom []string             `json:"publishFrom"`
+}
+
+// WorkflowTransition allows moving an entry From one state To another for
+// users with at least Role (default editor)
+type WorkflowTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Role string `json:"role,omitempty"`
+}
+
+var workflowRoles = []string{"viewer", "editor", "admin"}
+
+func (w *WorkflowPolicy) validate() error {
+	if len(w.States) == 0 {
+		return fmt.Errorf("workflow needs at least one state")
+	}
+	for i, s := range w.States {
+		if s == "" || slices.Index(w.States, s) != i {
+			return fmt.Errorf("workflow state %q is empty or repeated", s)
+		}
+	}
+	if w.Initial == "" {
+		w.Initial = w.States[0]
+	}
+	if !slices.Contains(w.States, w.Initial) {
+		return fmt.Errorf("workflow initial state %q is not a state", w.Initial)
+	}
+	for i, t := range w.Transitions {
+		if !slices.Contains(w.States, t.From) || !slices.Contains(w.States, t.To) || t.From == t.To {
+			return fmt.Errorf("workflow transition %q -> %q must join two different states", t.From, t.To)
+		}
+		if t.Role == "" {
+			w.Transitions[i].Role = "editor"
+		} else if !slices.Contains(workflowRoles, t.Role) {
+			return fmt.Errorf("workflow transition %q -> %q has unknown role %q", t.From, t.To, t.Role)
+		}
+	}
+	if len(w.PublishFrom) == 0 {
+		return fmt.Errorf("workflow publishFrom needs at least one state")
+	}
+	for _, s := range w.PublishFrom {
+		if !slices.Contains(w.States, s) {
+			return fmt.Errorf("workflow publishFrom state %q is not a state", s)
+		}
+	}
+	return nil
+}
+
+// State resolves an entry's stored state, entries without one (or with a
+// state that was since removed) are in the initial state
+func (w *WorkflowPolicy) State(stored string) string {
+	if slices.Contains(w.States, stored) {
+		return stored
+	}
+	return w.Initial
+}
+
+// Transition finds the transition between two states
+func (w *WorkflowPolicy) Transition(from, to string) (WorkflowTransition, bool) {
+	for _, t := range w.Transitions {
+		if t.From == from && t.To == to {
+			return t, true
+		}
+	}
+	return WorkflowTransition{}, false
+}
+
+// CanPublish reports whether entries in state may be published
+func (w *WorkflowPolicy) CanPublish(state string) bool {
+	return slices.Contains(w.PublishFrom, state)
+}
+
+// Edited resolves the state an entry is left in by a write from before to
+// after. Changing the data sends it back to the initial state, so an approval
+// only covers the data that was reviewed.
+func (w *WorkflowPolicy) Edited(stored string, before, after []byte) string {
+	if !SameJSON(before, after) {
+		return w.Initial
+	}
+	return w.State(stored)
+}
+
+// SameJSON reports whether two JSON documents hold the same value, whatever
+// their key order and spacing
+func SameJSON(a, b []byte) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestWorkflowSettings(t *testing.T) {
+	s, err := ParseSettings([]byte(`{"workflow":{
+		"states":["draft","in_review","approved"],
+		"transitions":[{"from":"draft","to":"in_review"},{"from":"in_review","to":"approved","role":"admin"}],
+		"publishFrom":["approved"]
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := s.Workflow
+	if w.Initial != "draft" || w.State("") != "draft" || w.State("gone") != "draft" || w.State("approved") != "approved" {
+		t.Errorf("unexpected states %+v", w)
+	}
+	if tr, ok := w.Transition("draft", "in_review"); !ok || tr.Role != "editor" {
+		t.Errorf("expected draft -> in_review for editors, got %+v", tr)
+	}
+	if _, ok := w.Transition("draft", "approved"); ok {
+		t.Error("draft -> approved should not be allowed")
+	}
+	if w.CanPublish("in_review") || !w.CanPublish("approved") {
+		t.Error("only approved entries should publish")
+	}
+
+	// an edit after approval needs another review before it is published
+	approved := []byte(`{"title":"A","tags":["x"]}`)
+	if state := w.Edited("approved", approved, []byte(`{ "tags": ["x"], "title": "A" }`)); !w.CanPublish(state) {
+		t.Errorf("rewriting the same data should keep the approval, got %s", state)
+	}
+	if state := w.Edited("approved", approved, []byte(`{"title":"B","tags":["x"]}`)); state != "draft" || w.CanPublish(state) {
+		t.Errorf("an edit after approval should block publishing, got %s", state)
+	}
+
+	for _, raw := range []string{
+		`{"workflow":{"states":[],"publishFrom":[]}}`,
+		`{"workflow":{"states":["a","a"],"publishFrom":["a"]}}`,
+		`{"workflow":{"states":["a"],"initial":"b","publishFrom":["a"]}}`,
+		`{"workflow":{"states":["a","b"],"transitions":[{"from":"a","to":"c"}],"publishFrom":["a"]}}`,
+		`{"workflow":{"states":["a","b"],"transitions":[{"from":"a","to":"b","role":"owner"}],"publishFrom":["a"]}}`,
+		`{"workflow":{"states":["a"],"publishFrom":["b"]}}`,
+	} {
+		if _, err := ParseSettings([]byte(raw)); err == nil {
+			t.Errorf("%s: expected error", raw)
+		}
+	}
+}
@@ -28,6 +28,8 @@ type NormalizedContent = {
   SchemaID?: string;
   Data: Record<string, any>;
   Published: boolean;
+  HasDraft?: boolean;
+  Version?: number;
   CreatedAt?: string;
   UpdatedAt?: string;
   _raw?: any;
@@ -87,6 +89,8 @@ export default function PageContentDashboard() {
       SchemaID: schemaID,
       Data: typeof data === "object" && data !== null ? data : {},
       Published: Boolean(published),
+      HasDraft: Boolean(raw.hasDraft ?? raw.HasDraft),
+      Version: raw.version ?? raw.Version,
       CreatedAt: raw.createdAt ?? raw.CreatedAt ?? raw.created_at,
       UpdatedAt: raw.updatedAt ?? raw.UpdatedAt ?? raw.updated_at,
       _raw: raw,
@@ -132,6 +136,7 @@ export default function PageContentDashboard() {
         content_id: editingContent.ID,
         data: editingContent.Data,
         published: editingContent.Published,
+        version: editingContent.Version,
       };
 
       // v1: router registers POST /api/v1/content/update
@@ -144,9 +149,13 @@ export default function PageContentDashboard() {
       updateContent(updatedNormalized);
       setSelectedContent(updatedNormalized);
       setEditingContent(null);
-    } catch (err) {
+    } catch (err: any) {
       console.error("Failed to update content:", err);
-      toast.error("Failed to update content.");
+      if (err?.response?.status === 412) {
+        toast.error("This entry was changed by someone else. Reload it before saving.");
+      } else {
+        toast.error("Failed to update content.");
+      }
     } finally {
       setLocalLoading(false);
     }
@@ -180,6 +189,7 @@ export default function PageContentDashboard() {
         content_id: selectedContent.ID,
         data: selectedContent.Data,
         published: checked,
+        version: selectedContent.Version,
       };
       const res = await fetch.post("/api/v1/content/update", body);
       const updated = normalizeContentItem(res.data ?? res);
@@ -194,6 +204,29 @@ export default function PageContentDashboard() {
     }
   };
 
+  // Publish or discard the pending edits of a published entry
+  const handleDraft = async (action: "publish" | "discard") => {
+    if (!selectedContent) return;
+    setLocalLoading(true);
+    try {
+      const headers = { "If-Match": `"${selectedContent.Version}"` };
+      const res =
+        action === "publish"
+          ? await fetch.post(`/api/v1/content/publish/${selectedContent.ID}`, null, { headers })
+          : await fetch.delete(`/api/v1/content/draft/${selectedContent.ID}`, { headers });
+      const updated = normalizeContentItem(res.data ?? res);
+      updateContent(updated);
+      setSelectedContent(updated);
+      if (editingContent?.ID === updated.ID) setEditingContent(null);
+      toast.success(action === "publish" ? "Changes published." : "Draft discarded.");
+    } catch (err: any) {
+      console.error(`Failed to ${action} draft:`, err);
+      toast.error(err?.response?.data?.error ?? `Failed to ${action} draft.`);
+    } finally {
+      setLocalLoading(false);
+    }
+  };
+
   // UI helpers
   if (schemaLoading) {
     return (
@@ -324,6 +357,25 @@ export default function PageContentDashboard() {
                   </div>
 
                   <div className="flex gap-2">
+                    {selectedContent.HasDraft && (
+                      <>
+                        <Button
+                          size="sm"
+                          onClick={() => handleDraft("publish")}
+                          disabled={localLoading}
+                        >
+                          Publish changes
+                        </Button>
+                        <Button
+                          variant="outline"
+                          size="sm"
+                          onClick={() => handleDraft("discard")}
+                          disabled={localLoading}
+                        >
+                          Discard draft
+                        </Button>
+                      </>
+                    )}
                     {editingContent?.ID === selectedContent.ID ? (
                       <Button
                         size="sm"
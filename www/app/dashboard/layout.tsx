@@ -5,7 +5,14 @@ import { Spinner } from "@/components/ui/spinner";
 import { useAuth } from "@/context/auth";
 import { useRouter } from "next/navigation";
 import { useEffect } from "react";
-import { AudioWaveform, FileCode2, Home, Inbox, User } from "lucide-react";
+import {
+  AudioWaveform,
+  FileCode2,
+  Home,
+  Inbox,
+  User,
+  Webhook,
+} from "lucide-react";
 
 const data = {
   name: "Nota CMS",
@@ -31,6 +38,11 @@ const data = {
       url: "/dashboard/users",
       icon: User,
     },
+    {
+      title: "Webhooks",
+      url: "/dashboard/webhooks",
+      icon: Webhook,
+    },
   ],
 };
 
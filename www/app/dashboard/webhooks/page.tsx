@@ -0,0 +1,235 @@
+"use client";
+
+import { Separator } from "@/components/ui/separator";
+import { Spinner } from "@/components/ui/spinner";
+import { useEffect, useState } from "react";
+import { Button } from "@/components/ui/button";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+import { Switch } from "@/components/ui/switch";
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "@/components/ui/table";
+import { Plus, RotateCw, Trash2 } from "lucide-react";
+import { fetch } from "@/lib/instance";
+import { toast } from "sonner";
+import { Webhook, WebhookCreateDialog } from "@/components/webhooks/create";
+
+type Delivery = {
+  id: string;
+  event: string;
+  status: "pending" | "delivered" | "dead";
+  attempts: number;
+  responseStatus: number | null;
+  error: string | null;
+  createdAt: string;
+};
+
+export default function PageWebhooksDashboard() {
+  const [webhooks, setWebhooks] = useState<Webhook[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [selected, setSelected] = useState<Webhook | null>(null);
+  const [deliveries, setDeliveries] = useState<Delivery[]>([]);
+  const [status, setStatus] = useState("");
+
+  useEffect(() => {
+    fetch
+      .get("/api/v1/webhooks/list")
+      .then((res) => setWebhooks(res.data.data))
+      .catch(() => toast?.error?.("Failed to fetch webhooks."))
+      .finally(() => setLoading(false));
+  }, []);
+
+  async function loadDeliveries(id: string, status: string) {
+    try {
+      const res = await fetch.get(`/api/v1/webhooks/deliveries/${id}`, {
+        params: status ? { status } : {},
+      });
+      setDeliveries(res.data.data);
+    } catch {
+      toast?.error?.("Failed to fetch deliveries.");
+    }
+  }
+
+  useEffect(() => {
+    if (selected) loadDeliveries(selected.id, status);
+  }, [selected?.id, status]);
+
+  async function handleToggle(webhook: Webhook) {
+    try {
+      const res = await fetch.post(`/api/v1/webhooks/update/${webhook.id}`, {
+        active: !webhook.active,
+      });
+      setWebhooks((prev) =>
+        prev.map((w) => (w.id === webhook.id ? res.data : w))
+      );
+      setSelected(res.data);
+    } catch {
+      toast?.error?.("Failed to update webhook.");
+    }
+  }
+
+  async function handleDelete(id: string) {
+    if (!confirm("Are you sure you want to delete this webhook?")) return;
+    try {
+      await fetch.delete(`/api/v1/webhooks/delete/${id}`);
+      setWebhooks((prev) => prev.filter((w) => w.id !== id));
+      setSelected(null);
+      toast?.success?.("Webhook deleted successfully!");
+    } catch {
+      toast?.error?.("Failed to delete webhook.");
+    }
+  }
+
+  async function handleRedeliver(id: string) {
+    try {
+      const res = await fetch.post(`/api/v1/webhooks/redeliver/${id}`);
+      setDeliveries((prev) => [res.data, ...prev]);
+      toast?.success?.("Delivery queued.");
+    } catch {
+      toast?.error?.("Failed to redeliver.");
+    }
+  }
+
+  if (loading) {
+    return (
+      <div className="h-full w-full flex justify-center items-center">
+        <Spinner className="size-8" />
+      </div>
+    );
+  }
+
+  return (
+    <section className="flex h-full w-full">
+      {/* Sidebar */}
+      <div className="h-full w-1/3 border-r p-4 overflow-y-auto">
+        <div className="flex items-center justify-between mb-4">
+          <h2 className="text-lg font-semibold">Webhooks</h2>
+          <WebhookCreateDialog
+            onCreated={(w) => setWebhooks((prev) => [...prev, w])}
+          >
+            <Button variant="outline" size="sm">
+              <Plus /> Create
+            </Button>
+          </WebhookCreateDialog>
+        </div>
+        <div className="flex flex-col gap-2">
+          {webhooks.length === 0 && (
+            <p className="text-muted-foreground">No Webhook Found.</p>
+          )}
+          {webhooks.map((webhook) => (
+            <button
+              key={webhook.id}
+              onClick={() => setSelected(webhook)}
+              className={`rounded-md border px-3 py-2 text-left truncate transition-colors ${
+                selected?.id === webhook.id ? "bg-accent" : "hover:bg-muted/50"
+              }`}
+            >
+              {webhook.name}
+              {!webhook.active && (
+                <span className="ml-2 text-xs text-muted-foreground">
+                  disabled
+                </span>
+              )}
+            </button>
+          ))}
+        </div>
+      </div>
+
+      <Separator orientation="vertical" className="mx-2" />
+
+      {/* Details Panel */}
+      <div className="flex-1 p-4 overflow-y-auto">
+        {!selected ? (
+          <div className="h-full w-full flex items-center justify-center">
+            <p className="text-muted-foreground">
+              Select a webhook to view its deliveries.
+            </p>
+          </div>
+        ) : (
+          <Card className="shadow-sm">
+            <CardHeader>
+              <CardTitle className="text-xl flex items-center justify-between">
+                {selected.name}
+                <div className="flex items-center gap-3">
+                  <Switch
+                    checked={selected.active}
+                    onCheckedChange={() => handleToggle(selected)}
+                  />
+                  <Button
+                    variant="destructive"
+                    onClick={() => handleDelete(selected.id)}
+                  >
+                    <Trash2 className="h-4 w-4" />
+                  </Button>
+                </div>
+              </CardTitle>
+              <p className="text-sm text-muted-foreground break-all">
+                {selected.url}
+              </p>
+              <p className="text-sm text-muted-foreground">
+                Events: {selected.events.join(", ") || "all"} · Schemas:{" "}
+                {selected.schemas.join(", ") || "all"}
+              </p>
+            </CardHeader>
+
+            <CardContent>
+              <div className="flex gap-2 mb-3">
+                {["", "pending", "delivered", "dead"].map((s) => (
+                  <Button
+                    key={s}
+                    size="sm"
+                    variant={status === s ? "default" : "outline"}
+                    onClick={() => setStatus(s)}
+                  >
+                    {s || "all"}
+                  </Button>
+                ))}
+              </div>
+              <Table>
+                <TableHeader>
+                  <TableRow>
+                    <TableHead>Event</TableHead>
+                    <TableHead>Status</TableHead>
+                    <TableHead>Attempts</TableHead>
+                    <TableHead>Response</TableHead>
+                    <TableHead>Created</TableHead>
+                    <TableHead />
+                  </TableRow>
+                </TableHeader>
+                <TableBody>
+                  {deliveries.map((d) => (
+                    <TableRow key={d.id}>
+                      <TableCell>{d.event}</TableCell>
+                      <TableCell>{d.status}</TableCell>
+                      <TableCell>{d.attempts}</TableCell>
+                      <TableCell className="max-w-48 truncate" title={d.error ?? ""}>
+                        {d.responseStatus ?? d.error ?? "-"}
+                      </TableCell>
+                      <TableCell>
+                        {new Date(d.createdAt).toLocaleString()}
+                      </TableCell>
+                      <TableCell>
+                        <Button
+                          size="sm"
+                          variant="outline"
+                          onClick={() => handleRedeliver(d.id)}
+                        >
+                          <RotateCw className="h-4 w-4" /> Redeliver
+                        </Button>
+                      </TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+            </CardContent>
+          </Card>
+        )}
+      </div>
+    </section>
+  );
+}
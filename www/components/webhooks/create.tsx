@@ -0,0 +1,144 @@
+"use client";
+
+import { Button } from "@/components/ui/button";
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+  DialogTrigger,
+} from "@/components/ui/dialog";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Loader2 } from "lucide-react";
+import { useState } from "react";
+import { fetch } from "@/lib/instance";
+
+export type Webhook = {
+  id: string;
+  name: string;
+  url: string;
+  events: string[];
+  schemas: string[];
+  active: boolean;
+  createdAt: string;
+  updatedAt: string;
+};
+
+// splits a comma separated input, empty means all
+function list(value: string) {
+  return value
+    .split(",")
+    .map((v) => v.trim())
+    .filter(Boolean);
+}
+
+export function WebhookCreateDialog({
+  onCreated,
+  children,
+}: {
+  onCreated: (webhook: Webhook) => void;
+  children: React.ReactNode;
+}) {
+  const [name, setName] = useState("");
+  const [url, setUrl] = useState("");
+  const [events, setEvents] = useState("");
+  const [schemas, setSchemas] = useState("");
+  const [secret, setSecret] = useState<string>();
+  const [error, setError] = useState<string>();
+  const [loading, setLoading] = useState(false);
+  const [open, setOpen] = useState(false);
+
+  async function handleSubmit() {
+    setError(undefined);
+    setLoading(true);
+    try {
+      const res = await fetch.post("/api/v1/webhooks/create", {
+        name,
+        url,
+        events: list(events),
+        schemas: list(schemas),
+      });
+      const { secret, ...webhook } = res.data;
+      onCreated(webhook);
+      setSecret(secret);
+    } catch (err: any) {
+      setError(err?.response?.data?.error || "Something went wrong.");
+    } finally {
+      setLoading(false);
+    }
+  }
+
+  function handleOpenChange(value: boolean) {
+    setOpen(value);
+    if (!value) {
+      setName("");
+      setUrl("");
+      setEvents("");
+      setSchemas("");
+      setSecret(undefined);
+      setError(undefined);
+    }
+  }
+
+  return (
+    <Dialog open={open} onOpenChange={handleOpenChange}>
+      <DialogTrigger asChild>{children}</DialogTrigger>
+      <DialogContent>
+        <DialogHeader>
+          <DialogTitle>Create Webhook</DialogTitle>
+          <DialogDescription>
+            Leave events or schemas empty to receive all of them.
+          </DialogDescription>
+        </DialogHeader>
+
+        {secret ? (
+          <div className="flex flex-col gap-2">
+            <p className="text-sm">
+              Copy the signing secret now, it will not be shown again.
+            </p>
+            <code className="rounded-md border p-2 text-xs break-all">
+              {secret}
+            </code>
+          </div>
+        ) : (
+          <div className="flex flex-col gap-3">
+            <Label>Name</Label>
+            <Input value={name} onChange={(e) => setName(e.target.value)} />
+            <Label>URL</Label>
+            <Input
+              value={url}
+              placeholder="https://example.com/hooks/nota"
+              onChange={(e) => setUrl(e.target.value)}
+            />
+            <Label>Events</Label>
+            <Input
+              value={events}
+              placeholder="content.published, media.uploaded"
+              onChange={(e) => setEvents(e.target.value)}
+            />
+            <Label>Schemas</Label>
+            <Input
+              value={schemas}
+              placeholder="blog, pages"
+              onChange={(e) => setSchemas(e.target.value)}
+            />
+            {error && <p className="text-sm text-red-500">{error}</p>}
+          </div>
+        )}
+
+        <DialogFooter>
+          {secret ? (
+            <Button onClick={() => handleOpenChange(false)}>Done</Button>
+          ) : (
+            <Button onClick={handleSubmit} disabled={loading}>
+              {loading && <Loader2 className="animate-spin" />} Create
+            </Button>
+          )}
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  );
+}
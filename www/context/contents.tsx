@@ -54,14 +54,17 @@ export const ContentContextProvider = ({
 
     try {
       const res = await fetch.get<ContentResponse>(
-        `/api/v1/content/get_all/${name}`
+        `/api/v1/content/preview_all/${name}`
       );
 
       // Normalize key casing from backend → frontend
-      const normalized = (res?.data ?? []).map((item: any) => ({
+      const normalized = (res?.data?.data ?? []).map((item: any) => ({
         ID: item.ID ?? item.id,
         SchemaID: item.SchemaID ?? item.schemaID,
         Data: item.Data ?? item.data,
+        Published: item.Published ?? item.published,
+        HasDraft: item.HasDraft ?? item.hasDraft,
+        Version: item.Version ?? item.version,
         CreatedAt: item.CreatedAt ?? item.createdAt,
       }));
 